@@ -1,6 +1,8 @@
 package bkg
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,13 +10,18 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
 const (
 	DaemonPIDFile = ".tyn/daemon.pid"
 	DaemonLogFile = ".tyn/daemon.log"
+
+	startTimeout = 5 * time.Second
+	stopTimeout  = 5 * time.Second
 )
 
+// EnsureDaemon makes sure a daemon is running and accepts connections, starting one if needed.
 func EnsureDaemon() error {
 	running, err := IsDaemonRunning()
 	if err != nil {
@@ -22,10 +29,14 @@ func EnsureDaemon() error {
 	}
 
 	if running {
-		return nil
+		client, err := Dial()
+		if err != nil {
+			return fmt.Errorf("daemon is running but not reachable: %w", err)
+		}
+		return client.Close()
 	}
 
-	pid, err := StartDetachedDaemon()
+	pid, err := StartDaemon()
 	if err != nil {
 		return fmt.Errorf("error starting daemon: %w", err)
 	}
@@ -34,25 +45,45 @@ func EnsureDaemon() error {
 	return nil
 }
 
-func IsDaemonRunning() (bool, error) {
-	pidFile, err := pidFilePath()
+// StartDaemon starts the daemon and waits until it accepts connections, so the command that
+// started it is not sent before the daemon listens. If the daemon exits during startup, the
+// error includes the end of its log.
+func StartDaemon() (int, error) {
+	cmd, err := startDaemonProcess()
 	if err != nil {
-		return false, err
-	}
-
-	if _, err := os.Stat(pidFile); os.IsNotExist(err) {
-		return false, nil
+		return 0, err
 	}
 
-	pidBytes, err := os.ReadFile(pidFile)
-	if err != nil {
-		return false, fmt.Errorf("error reading PID file: %w", err)
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	deadline := time.Now().Add(startTimeout)
+	for {
+		client, err := Dial()
+		if err == nil {
+			client.Close()
+			return cmd.Process.Pid, nil
+		}
+
+		select {
+		case waitErr := <-exited:
+			removePidFile()
+			return 0, fmt.Errorf("daemon exited during startup (%v)%s", waitErr, logTail(5))
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		if time.Now().After(deadline) {
+			return cmd.Process.Pid, fmt.Errorf("daemon (PID %d) did not accept connections within %s: %w", cmd.Process.Pid, startTimeout, err)
+		}
 	}
+}
 
-	pidStr := strings.TrimSpace(string(pidBytes))
-	pid, err := strconv.Atoi(pidStr)
-	if err != nil {
-		return false, fmt.Errorf("invalid PID in file: %w", err)
+func IsDaemonRunning() (bool, error) {
+	pid, err := readPidFile()
+	if err != nil || pid == 0 {
+		return false, err
 	}
 
 	process, err := os.FindProcess(pid)
@@ -72,26 +103,27 @@ func IsDaemonRunning() (bool, error) {
 	return true, nil
 }
 
-func StartDetachedDaemon() (int, error) {
+// startDaemonProcess runs tn serve --daemon in its own session, logging to the daemon log.
+func startDaemonProcess() (*exec.Cmd, error) {
 	exe, err := os.Executable()
 	if err != nil {
-		return 0, fmt.Errorf("error getting executable path: %w", err)
+		return nil, fmt.Errorf("error getting executable path: %w", err)
 	}
 
-	logFile, err := logFilePath()
+	logFile, err := LogFilePath()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	logDir := filepath.Dir(logFile)
 	err = os.MkdirAll(logDir, 0755)
 	if err != nil {
-		return 0, fmt.Errorf("error creating log directory: %w", err)
+		return nil, fmt.Errorf("error creating log directory: %w", err)
 	}
 
 	logFd, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return 0, fmt.Errorf("error opening log file: %w", err)
+		return nil, fmt.Errorf("error opening log file: %w", err)
 	}
 
 	cmd := exec.Command(exe, "serve", "--daemon")
@@ -106,25 +138,143 @@ func StartDetachedDaemon() (int, error) {
 	err = cmd.Start()
 	if err != nil {
 		logFd.Close()
-		return 0, fmt.Errorf("error starting daemon process: %w", err)
+		return nil, fmt.Errorf("error starting daemon process: %w", err)
 	}
 
-	pid := cmd.Process.Pid
+	// The daemon has its own copy of the log file descriptor
+	logFd.Close()
 
-	err = writePidFile(pid)
+	err = writePidFile(cmd.Process.Pid)
 	if err != nil {
-		logFd.Close()
-		return 0, fmt.Errorf("error writing PID file: %w", err)
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("error writing PID file: %w", err)
 	}
 
-	err = cmd.Process.Release()
+	return cmd, nil
+}
+
+// StopDaemon shuts the daemon down and waits for it to exit. It asks over IPC first, so the
+// daemon can finish what it is doing, and falls back to SIGTERM if the daemon does not answer
+// or does not exit in time. It reports whether a daemon was running.
+func StopDaemon() (bool, error) {
+	pid, err := requestShutdown()
+	if err == nil {
+		if waitForExit(pid, stopTimeout) {
+			removePidFile()
+			return true, nil
+		}
+	}
+
+	running, runErr := IsDaemonRunning()
+	if runErr != nil {
+		return false, runErr
+	}
+
+	if !running {
+		removePidFile()
+		if err != nil && !isNotListening(err) {
+			return false, err
+		}
+		return err == nil, nil
+	}
+
+	pid, err = readPidFile()
 	if err != nil {
-		logFd.Close()
-		return 0, fmt.Errorf("error releasing daemon process: %w", err)
+		return false, err
 	}
 
-	logFd.Close()
-	return pid, nil
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false, fmt.Errorf("error finding daemon process: %w", err)
+	}
+
+	err = process.Signal(syscall.SIGTERM)
+	if err != nil {
+		return false, fmt.Errorf("error stopping daemon: %w", err)
+	}
+
+	if !waitForExit(pid, stopTimeout) {
+		return true, fmt.Errorf("daemon (PID %d) did not exit within %s", pid, stopTimeout)
+	}
+
+	removePidFile()
+	return true, nil
+}
+
+// GetDaemonStatus asks the running daemon about itself.
+func GetDaemonStatus() (DaemonStatus, error) {
+	resp, err := SendCommand("daemon-status", nil)
+	if err != nil {
+		return DaemonStatus{}, err
+	}
+
+	if !resp.Success {
+		return DaemonStatus{}, fmt.Errorf("daemon returned error: %s", resp.Error)
+	}
+
+	var status DaemonStatus
+	err = json.Unmarshal(resp.Data, &status)
+	if err != nil {
+		return DaemonStatus{}, fmt.Errorf("error reading daemon status: %w", err)
+	}
+
+	return status, nil
+}
+
+// requestShutdown asks the daemon to shut down and returns its PID.
+func requestShutdown() (int, error) {
+	client, err := Dial()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	client.SetDeadline(time.Now().Add(stopTimeout))
+	resp, err := client.Send("daemon-status", nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var status DaemonStatus
+	if resp.Success {
+		err = json.Unmarshal(resp.Data, &status)
+		if err != nil {
+			return 0, fmt.Errorf("error reading daemon status: %w", err)
+		}
+	}
+
+	// The daemon may exit before its answer arrives, so a closed connection also counts as accepted
+	resp, err = client.Send("shutdown", nil)
+	if err == nil && !resp.Success {
+		return 0, fmt.Errorf("daemon refused to shut down: %s", resp.Error)
+	}
+
+	return status.PID, nil
+}
+
+// waitForExit polls until the process is gone. A PID of 0 is treated as already gone
+// once the daemon socket stops accepting connections.
+func waitForExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if pid == 0 {
+			client, err := Dial()
+			if err != nil {
+				return true
+			}
+			client.Close()
+		} else if process, err := os.FindProcess(pid); err != nil || process.Signal(syscall.Signal(0)) != nil {
+			return true
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+func isNotListening(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED)
 }
 
 func pidFilePath() (string, error) {
@@ -135,7 +285,8 @@ func pidFilePath() (string, error) {
 	return filepath.Join(home, DaemonPIDFile), nil
 }
 
-func logFilePath() (string, error) {
+// LogFilePath is where the daemon writes its log.
+func LogFilePath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("error getting user home directory: %w", err)
@@ -163,6 +314,65 @@ func writePidFile(pid int) error {
 	return nil
 }
 
+// readPidFile returns the PID recorded by the last daemon start, or 0 if there is none.
+func readPidFile() (int, error) {
+	pidFile, err := pidFilePath()
+	if err != nil {
+		return 0, err
+	}
+
+	pidBytes, err := os.ReadFile(pidFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading PID file: %w", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid PID in file: %w", err)
+	}
+
+	return pid, nil
+}
+
+func removePidFile() {
+	pidFile, err := pidFilePath()
+	if err != nil {
+		return
+	}
+	os.Remove(pidFile)
+}
+
+// removeOwnPidFile removes the PID file if it names this process, leaving another daemon's alone.
+func removeOwnPidFile() {
+	pid, err := readPidFile()
+	if err == nil && pid == os.Getpid() {
+		removePidFile()
+	}
+}
+
+// logTail returns the last lines of the daemon log, formatted to be appended to an error.
+func logTail(lines int) string {
+	logFile, err := LogFilePath()
+	if err != nil {
+		return ""
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		return ""
+	}
+
+	all := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+
+	return fmt.Sprintf("; last lines of %s:\n%s", logFile, strings.Join(all, "\n"))
+}
+
 func isExecutableMatch(pid int) (bool, error) {
 	procPath := fmt.Sprintf("/proc/%d/exe", pid)
 	target, err := os.Readlink(procPath)
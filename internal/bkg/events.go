@@ -0,0 +1,152 @@
+package bkg
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+)
+
+const (
+	// subscribeCommand turns a connection into a stream of events matching a model.EventFilter.
+	subscribeCommand = "subscribe"
+
+	// eventBuffer is how many events a subscriber may fall behind before it is dropped.
+	eventBuffer = 64
+)
+
+// eventBus hands the daemon's events to its subscribers.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[chan model.Event]model.EventFilter
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[chan model.Event]model.EventFilter)}
+}
+
+// subscribe returns a channel of the events matching filter, and a function that ends the
+// subscription. The channel is closed when the subscription ends.
+func (b *eventBus) subscribe(filter model.EventFilter) (<-chan model.Event, func()) {
+	ch := make(chan model.Event, eventBuffer)
+
+	b.mu.Lock()
+	b.subs[ch] = filter
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(ch)
+	}
+}
+
+// publish sends e to the matching subscribers without waiting for them. A subscriber whose
+// buffer is full is dropped rather than let miss events silently.
+func (b *eventBus) publish(e model.Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, filter := range b.subs {
+		if !filter.Matches(e) {
+			continue
+		}
+
+		select {
+		case ch <- e:
+		default:
+			log.Printf("Dropping event subscriber that fell %d events behind", eventBuffer)
+			b.remove(ch)
+		}
+	}
+}
+
+// remove ends a subscription. b.mu must be held.
+func (b *eventBus) remove(ch chan model.Event) {
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// eventRepo publishes an event for every node the daemon creates, changes or deletes.
+type eventRepo struct {
+	svc.Repo
+	events *eventBus
+}
+
+func (r *eventRepo) Create(ctx context.Context, node model.Node) error {
+	err := r.Repo.Create(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	r.events.publish(model.Event{Type: model.EventType.NodeCreated, Node: &node})
+	return nil
+}
+
+func (r *eventRepo) CreateAll(ctx context.Context, nodes []model.Node) error {
+	err := r.Repo.CreateAll(ctx, nodes)
+	if err != nil {
+		return err
+	}
+
+	for i := range nodes {
+		r.events.publish(model.Event{Type: model.EventType.NodeCreated, Node: &nodes[i]})
+	}
+	return nil
+}
+
+// Update stores node and publishes the update, and a status change if there was one.
+func (r *eventRepo) Update(ctx context.Context, node model.Node) error {
+	prior, getErr := r.Repo.Get(ctx, node.ID)
+
+	err := r.Repo.Update(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	r.events.publish(model.Event{Type: model.EventType.NodeUpdated, Node: &node})
+
+	if getErr == nil && prior.Status != node.Status {
+		r.events.publish(model.Event{Type: model.EventType.StatusChanged, Node: &node, PriorStatus: prior.Status})
+	}
+	return nil
+}
+
+func (r *eventRepo) Delete(ctx context.Context, id string) error {
+	node, getErr := r.Repo.Get(ctx, id)
+
+	err := r.Repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if getErr == nil {
+		r.events.publish(model.Event{Type: model.EventType.NodeDeleted, Node: &node})
+	}
+	return nil
+}
+
+// Import writes an import and publishes the nodes it created and overwrote.
+func (r *eventRepo) Import(ctx context.Context, batch model.ImportBatch) error {
+	err := r.Repo.Import(ctx, batch)
+	if err != nil {
+		return err
+	}
+
+	for i := range batch.Create {
+		r.events.publish(model.Event{Type: model.EventType.NodeCreated, Node: &batch.Create[i]})
+	}
+	for i := range batch.Overwrite {
+		r.events.publish(model.Event{Type: model.EventType.NodeUpdated, Node: &batch.Overwrite[i]})
+	}
+	return nil
+}
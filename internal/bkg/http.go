@@ -0,0 +1,195 @@
+package bkg
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	HTTPTokenFile = ".tyn/http.token"
+
+	// maxRequestBody bounds the JSON bodies the HTTP API reads.
+	maxRequestBody = 1 << 20
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// startHTTP serves the HTTP API on cfg.HTTPAddress, which must be a loopback address. Every
+// request except the OpenAPI description needs the bearer token from HTTPToken.
+func (s *Service) startHTTP() (*http.Server, error) {
+	addr := s.cfg.Snapshot().HTTPAddress
+
+	err := checkLoopback(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := HTTPToken()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error listening on %s: %w", addr, err)
+	}
+
+	server := &http.Server{
+		Addr:              listener.Addr().String(),
+		Handler:           s.httpHandler(token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("Error serving HTTP API: %v", err)
+		}
+	}()
+
+	return server, nil
+}
+
+func (s *Service) httpHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, requireToken(token, h))
+	}
+
+	handle("GET /api/v1/nodes", s.httpListNodes)
+	handle("POST /api/v1/nodes", s.httpCreateNode)
+	handle("GET /api/v1/nodes/{id}", s.httpGetNode)
+	handle("PATCH /api/v1/nodes/{id}", s.httpUpdateNode)
+	handle("DELETE /api/v1/nodes/{id}", s.httpDeleteNode)
+	handle("POST /api/v1/nodes/{id}/tags", s.httpAddTags)
+	handle("DELETE /api/v1/nodes/{id}/tags/{tag}", s.httpRemoveTag)
+	handle("POST /api/v1/nodes/{id}/places", s.httpAddPlaces)
+	handle("DELETE /api/v1/nodes/{id}/places/{place}", s.httpRemovePlace)
+
+	handle("GET /api/v1/tasks", s.httpListTasks)
+	handle("PUT /api/v1/tasks/{id}/status", s.httpChangeStatus)
+	handle("PUT /api/v1/tasks/{id}/priority", s.httpChangePriority)
+	handle("GET /api/v1/tasks/{id}/dependencies", s.httpDependencies)
+	handle("POST /api/v1/tasks/{id}/dependencies", s.httpAddDependency)
+	handle("DELETE /api/v1/tasks/{id}/dependencies/{dependsOn}", s.httpRemoveDependency)
+
+	handle("GET /api/v1/tags", s.httpListTags)
+	handle("GET /api/v1/places", s.httpListPlaces)
+	handle("GET /api/v1/search", s.httpSearch)
+
+	return mux
+}
+
+// HTTPToken returns the token HTTP API clients send as "Authorization: Bearer <token>",
+// creating it the first time. It is kept in ~/.tyn/http.token, readable only by its owner.
+func HTTPToken() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting user home directory: %w", err)
+	}
+
+	path := filepath.Join(home, HTTPTokenFile)
+
+	data, err := os.ReadFile(path)
+	if err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("error reading HTTP token: %w", err)
+	}
+
+	buf := make([]byte, 32)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("error generating HTTP token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return "", fmt.Errorf("error creating token directory: %w", err)
+	}
+
+	err = os.WriteFile(path, []byte(token+"\n"), 0600)
+	if err != nil {
+		return "", fmt.Errorf("error writing HTTP token: %w", err)
+	}
+
+	return token, nil
+}
+
+// checkLoopback rejects addresses other machines could reach.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid HTTP API address %q: %w", addr, err)
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("HTTP API address %q is not a loopback address", addr)
+	}
+
+	return nil
+}
+
+func requireToken(token string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("Error writing HTTP response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,533 @@
+package bkg
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+)
+
+// The HTTP API describes nodes with model.ExportNode, the same snake_case form tn export writes.
+
+type apiStatusChange struct {
+	Task           model.ExportNode   `json:"task"`
+	OriginalStatus string             `json:"original_status"`
+	NewStatus      string             `json:"new_status"`
+	Next           *model.ExportNode  `json:"next,omitempty"`
+	OpenChildren   []model.ExportNode `json:"open_children,omitempty"`
+	Unblocked      []model.ExportNode `json:"unblocked,omitempty"`
+	Blocked        []model.ExportNode `json:"blocked,omitempty"`
+	BlockedBy      []model.ExportNode `json:"blocked_by,omitempty"`
+}
+
+type apiPriorityChange struct {
+	Task             model.ExportNode `json:"task"`
+	OriginalPriority int              `json:"original_priority"`
+	NewPriority      int              `json:"new_priority"`
+}
+
+type apiDependencies struct {
+	Task         model.ExportNode   `json:"task"`
+	Dependencies []model.ExportNode `json:"dependencies"`
+	Dependents   []model.ExportNode `json:"dependents"`
+}
+
+type apiDependencyChange struct {
+	Task           model.ExportNode `json:"task"`
+	DependsOn      model.ExportNode `json:"depends_on"`
+	OriginalStatus string           `json:"original_status"`
+	NewStatus      string           `json:"new_status"`
+}
+
+type apiLabel struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type apiSearchResult struct {
+	Node model.ExportNode `json:"node"`
+	// Snippet is HTML: the text is escaped and the matches are wrapped in <mark>.
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
+
+type apiCreateNode struct {
+	Text string `json:"text"`
+}
+
+// apiUpdateNode changes only the fields that are given. Tags and places replace the current ones.
+type apiUpdateNode struct {
+	Text   string    `json:"text,omitempty"`
+	Tags   *[]string `json:"tags,omitempty"`
+	Places *[]string `json:"places,omitempty"`
+	Due    string    `json:"due,omitempty"`
+}
+
+type apiLabels struct {
+	Tags   []string `json:"tags,omitempty"`
+	Places []string `json:"places,omitempty"`
+}
+
+// apiChange sets a value, or moves it with an operation such as next or up.
+type apiChange struct {
+	Operation string `json:"operation,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Priority  string `json:"priority,omitempty"`
+}
+
+type apiDependency struct {
+	DependsOn string `json:"depends_on"`
+}
+
+func (s *Service) httpListNodes(w http.ResponseWriter, r *http.Request) {
+	filter, err := queryFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.writeNodes(w, filter)
+}
+
+func (s *Service) httpListTasks(w http.ResponseWriter, r *http.Request) {
+	filter, err := queryFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter.Type = model.Type.Task
+
+	s.writeNodes(w, filter)
+}
+
+func (s *Service) writeNodes(w http.ResponseWriter, filter model.Filter) {
+	nodes, err := s.svc.List(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiNodes(nodes))
+}
+
+func (s *Service) httpCreateNode(w http.ResponseWriter, r *http.Request) {
+	var req apiCreateNode
+	err := readJSON(r, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("text is required"))
+		return
+	}
+
+	node, err := s.svc.Capture(req.Text)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, model.NewExportNode(node))
+}
+
+func (s *Service) httpGetNode(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.findNode(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, model.NewExportNode(node))
+}
+
+// httpDeleteNode moves a node and its subtasks to the trash.
+func (s *Service) httpDeleteNode(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.findNode(w, r)
+	if !ok {
+		return
+	}
+
+	nodes, err := s.svc.TrashNodes(r.Context(), []string{node.ID})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiNodes(nodes))
+}
+
+func (s *Service) httpUpdateNode(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.findNode(w, r)
+	if !ok {
+		return
+	}
+
+	var req apiUpdateNode
+	err := readJSON(r, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var tags, places []string
+	if req.Tags != nil {
+		tags = append([]string{}, *req.Tags...)
+	}
+	if req.Places != nil {
+		places = append([]string{}, *req.Places...)
+	}
+
+	s.updateNode(w, r, node.ID, tags, places, req.Due, req.Text)
+}
+
+func (s *Service) httpAddTags(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.findNode(w, r)
+	if !ok {
+		return
+	}
+
+	var req apiLabels
+	err := readJSON(r, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.updateNode(w, r, node.ID, addLabels(node.Tags, req.Tags), nil, "", "")
+}
+
+func (s *Service) httpRemoveTag(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.findNode(w, r)
+	if !ok {
+		return
+	}
+
+	s.updateNode(w, r, node.ID, removeLabel(node.Tags, r.PathValue("tag")), nil, "", "")
+}
+
+func (s *Service) httpAddPlaces(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.findNode(w, r)
+	if !ok {
+		return
+	}
+
+	var req apiLabels
+	err := readJSON(r, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.updateNode(w, r, node.ID, nil, addLabels(node.Places, req.Places), "", "")
+}
+
+func (s *Service) httpRemovePlace(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.findNode(w, r)
+	if !ok {
+		return
+	}
+
+	s.updateNode(w, r, node.ID, nil, removeLabel(node.Places, r.PathValue("place")), "", "")
+}
+
+// updateNode applies the changes through Svc.UpdateNode and answers with the updated node.
+func (s *Service) updateNode(w http.ResponseWriter, r *http.Request, id string, tags, places []string, due, text string) {
+	err := s.svc.UpdateNode(r.Context(), id, tags, places, due, text)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	node, err := s.svc.Repo.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, model.NewExportNode(node))
+}
+
+func (s *Service) httpChangeStatus(w http.ResponseWriter, r *http.Request) {
+	var req apiChange
+	err := readJSON(r, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	change, err := s.svc.ChangeStatus(r.Context(), r.PathValue("id"), operation(req, req.Status), req.Status)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result := apiStatusChange{
+		Task:           model.NewExportNode(change.Task),
+		OriginalStatus: change.OriginalStatus,
+		NewStatus:      change.NewStatus,
+		OpenChildren:   apiNodes(change.OpenChildren),
+		Unblocked:      apiNodes(change.Unblocked),
+		Blocked:        apiNodes(change.Blocked),
+		BlockedBy:      apiNodes(change.BlockedBy),
+	}
+	if change.Next != nil {
+		next := model.NewExportNode(*change.Next)
+		result.Next = &next
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Service) httpChangePriority(w http.ResponseWriter, r *http.Request) {
+	var req apiChange
+	err := readJSON(r, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	change, err := s.svc.ChangePriority(r.Context(), r.PathValue("id"), operation(req, req.Priority), req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiPriorityChange{
+		Task:             model.NewExportNode(change.Task),
+		OriginalPriority: change.OriginalPriority,
+		NewPriority:      change.NewPriority,
+	})
+}
+
+func (s *Service) httpDependencies(w http.ResponseWriter, r *http.Request) {
+	deps, err := s.svc.Dependencies(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiDependencies{
+		Task:         model.NewExportNode(deps.Task),
+		Dependencies: apiNodes(deps.Dependencies),
+		Dependents:   apiNodes(deps.Dependents),
+	})
+}
+
+func (s *Service) httpAddDependency(w http.ResponseWriter, r *http.Request) {
+	var req apiDependency
+	err := readJSON(r, &req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	change, err := s.svc.AddDependency(r.Context(), r.PathValue("id"), req.DependsOn)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAPIDependencyChange(change))
+}
+
+func (s *Service) httpRemoveDependency(w http.ResponseWriter, r *http.Request) {
+	change, err := s.svc.RemoveDependency(r.Context(), r.PathValue("id"), r.PathValue("dependsOn"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newAPIDependencyChange(change))
+}
+
+func (s *Service) httpListTags(w http.ResponseWriter, r *http.Request) {
+	s.writeLabels(w, r, func(n model.Node) []string { return n.Tags })
+}
+
+func (s *Service) httpListPlaces(w http.ResponseWriter, r *http.Request) {
+	s.writeLabels(w, r, func(n model.Node) []string { return n.Places })
+}
+
+// writeLabels answers with every tag or place in use and how many nodes have it, most used first.
+func (s *Service) writeLabels(w http.ResponseWriter, r *http.Request, labels func(model.Node) []string) {
+	nodes, err := s.svc.Repo.ListAll(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	counts := map[string]int{}
+	for _, n := range nodes {
+		for _, l := range labels(n) {
+			counts[l]++
+		}
+	}
+
+	result := []apiLabel{}
+	for name, count := range counts {
+		result = append(result, apiLabel{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Service) httpSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("q is required"))
+		return
+	}
+
+	limit := svc.DefaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit '%s'", v))
+			return
+		}
+		limit = n
+	}
+
+	results, err := s.svc.Search(r.Context(), query, limit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	highlight := strings.NewReplacer(model.HighlightStart, "<mark>", model.HighlightEnd, "</mark>")
+
+	found := []apiSearchResult{}
+	for _, res := range results {
+		found = append(found, apiSearchResult{
+			Node:    model.NewExportNode(res.Node),
+			Snippet: highlight.Replace(html.EscapeString(res.Snippet)),
+			Rank:    res.Rank,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, found)
+}
+
+// findNode looks up the node named by the id in the path, by full ID or prefix. It answers 404
+// if there is none and 409 if the prefix matches several nodes.
+func (s *Service) findNode(w http.ResponseWriter, r *http.Request) (model.Node, bool) {
+	node, err := s.svc.ResolveID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, model.ErrAmbiguousID):
+			status = http.StatusConflict
+		case errors.Is(err, model.ErrInvalid):
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err)
+		return model.Node{}, false
+	}
+
+	return node, true
+}
+
+// queryFilter reads the type, tag, place, status, archived and q query parameters. Tags and places
+// may be repeated or separated by commas.
+func queryFilter(r *http.Request) (model.Filter, error) {
+	q := r.URL.Query()
+
+	filter := model.Filter{
+		Type:   q.Get("type"),
+		Tags:   splitValues(q["tag"]),
+		Places: splitValues(q["place"]),
+		Status: q.Get("status"),
+	}
+
+	if filter.Type != "" && !model.Type.Validate(filter.Type) {
+		return model.Filter{}, fmt.Errorf("invalid type '%s', valid types are: %s", filter.Type, strings.Join(model.Type.Values(), ", "))
+	}
+
+	if archived := q.Get("archived"); archived != "" {
+		var err error
+		filter.Archived, err = strconv.ParseBool(archived)
+		if err != nil {
+			return model.Filter{}, fmt.Errorf("invalid archived value '%s', use true or false", archived)
+		}
+	}
+
+	var err error
+	filter.Query, err = svc.ParseQuery(q.Get("q"))
+	if err != nil {
+		return model.Filter{}, err
+	}
+
+	return filter, nil
+}
+
+func splitValues(values []string) []string {
+	var result []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// operation defaults to "set" when only a value is given.
+func operation(req apiChange, value string) string {
+	if req.Operation == "" && value != "" {
+		return "set"
+	}
+	return req.Operation
+}
+
+func addLabels(current, added []string) []string {
+	result := append([]string{}, current...)
+	for _, l := range added {
+		if !containsString(result, l) {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+func removeLabel(current []string, removed string) []string {
+	result := []string{}
+	for _, l := range current {
+		if l != removed {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+func apiNodes(nodes []model.Node) []model.ExportNode {
+	result := []model.ExportNode{}
+	for _, n := range nodes {
+		result = append(result, model.NewExportNode(n))
+	}
+	return result
+}
+
+func newAPIDependencyChange(change svc.DependencyChange) apiDependencyChange {
+	return apiDependencyChange{
+		Task:           model.NewExportNode(change.Task),
+		DependsOn:      model.NewExportNode(change.DependsOn),
+		OriginalStatus: change.OriginalStatus,
+		NewStatus:      change.NewStatus,
+	}
+}
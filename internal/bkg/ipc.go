@@ -1,15 +1,25 @@
 package bkg
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"net"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
 )
 
 const (
 	SocketFile = ".tyn/daemon.sock"
+
+	// handshakeTimeout bounds the hello exchange, so a hung daemon is reported instead of waited on.
+	handshakeTimeout = 2 * time.Second
 )
 
 type Message struct {
@@ -21,6 +31,8 @@ type Response struct {
 	Success bool            `json:"success"`
 	Data    json.RawMessage `json:"data"`
 	Error   string          `json:"error,omitempty"`
+	// Code classifies a failure, see CodeNotFound and the other codes.
+	Code int `json:"code,omitempty"`
 }
 
 type UpdateParams struct {
@@ -55,6 +67,58 @@ type DateCmdParams struct {
 }
 
 func SendCommand(cmd string, params interface{}) (*Response, error) {
+	client, err := Dial()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	return client.Send(cmd, params)
+}
+
+// versionWarned keeps a process from warning about a daemon version mismatch on every connection.
+var versionWarned bool
+
+// Client is a connection to the daemon. After the handshake in Dial it can send any number of commands.
+type Client struct {
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+	// Daemon is what the daemon reported about itself in the handshake.
+	Daemon HelloResult
+}
+
+// Dial connects to the daemon and checks that both sides speak the same protocol version.
+func Dial() (*Client, error) {
+	sockPath, err := getSocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial("unix", sockPath)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to daemon: %w", err)
+	}
+
+	client := &Client{
+		conn: conn,
+		enc:  json.NewEncoder(conn),
+		dec:  json.NewDecoder(conn),
+	}
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	err = client.handshake()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return client, nil
+}
+
+// Send sends one command and waits for its response.
+func (c *Client) Send(cmd string, params interface{}) (*Response, error) {
 	var paramsJSON []byte
 	var err error
 	if params != nil {
@@ -69,117 +133,381 @@ func SendCommand(cmd string, params interface{}) (*Response, error) {
 		Params:  paramsJSON,
 	}
 
-	msgJSON, err := json.Marshal(msg)
+	err = c.enc.Encode(msg)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling message: %w", err)
+		return nil, fmt.Errorf("error sending message to daemon: %w", err)
 	}
 
-	sockPath, err := getSocketPath()
+	var resp Response
+	err = c.dec.Decode(&resp)
+	if err == io.EOF {
+		return nil, fmt.Errorf("daemon closed the connection before responding")
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error reading response from daemon: %w", err)
 	}
 
-	conn, err := net.Dial("unix", sockPath)
+	return &resp, nil
+}
+
+// Subscribe turns the connection into a stream of the events matching filter. Read them with
+// NextEvent; the connection cannot send commands afterwards.
+func (c *Client) Subscribe(filter model.EventFilter) error {
+	resp, err := c.Send(subscribeCommand, filter)
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to daemon: %w", err)
+		return err
 	}
-	defer conn.Close()
 
-	_, err = conn.Write(msgJSON)
-	if err != nil {
-		return nil, fmt.Errorf("error sending message to daemon: %w", err)
+	if !resp.Success {
+		return fmt.Errorf("daemon returned error: %s", resp.Error)
 	}
 
-	buf := make([]byte, 4096)
-	n, err := conn.Read(buf)
+	return nil
+}
+
+// NextEvent waits for the next event of a subscription. It returns io.EOF when the daemon ends the stream.
+func (c *Client) NextEvent() (model.Event, error) {
+	var e model.Event
+	err := c.dec.Decode(&e)
+	return e, err
+}
+
+// SetDeadline limits how long the following commands may take. A zero time removes the limit.
+func (c *Client) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) handshake() error {
+	resp, err := c.Send(helloCommand, HelloParams{ProtocolVersion: ProtocolVersion, Version: Version})
 	if err != nil {
-		return nil, fmt.Errorf("error reading response from daemon: %w", err)
+		return fmt.Errorf("error during handshake with daemon: %w", err)
 	}
 
-	var resp Response
-	err = json.Unmarshal(buf[:n], &resp)
+	if !resp.Success {
+		// A daemon from before the handshake does not know the hello command
+		if resp.Error == "unknown command: "+helloCommand {
+			return fmt.Errorf("the running daemon is from an older tn that does not speak IPC protocol version %d; run 'tn daemon restart'",
+				ProtocolVersion)
+		}
+		return fmt.Errorf("daemon rejected the handshake: %s", resp.Error)
+	}
+
+	err = json.Unmarshal(resp.Data, &c.Daemon)
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling response: %w", err)
+		return fmt.Errorf("error reading handshake response: %w", err)
 	}
 
-	return &resp, nil
+	if c.Daemon.Version != Version && !versionWarned {
+		versionWarned = true
+		fmt.Fprintf(os.Stderr, "Warning: the running daemon is tn %s, this is tn %s; run 'tn daemon restart' to use this version\n",
+			c.Daemon.Version, Version)
+	}
+
+	return nil
+}
+
+// Server accepts IPC connections on the daemon socket and serves them with a handler until Shutdown.
+type Server struct {
+	listener  net.Listener
+	handler   func(Message) Response
+	subscribe func(model.EventFilter) (<-chan model.Event, func())
+	wg        sync.WaitGroup
+
+	// mu guards conns and closing. conns maps each open connection to whether it is busy with a request.
+	mu      sync.Mutex
+	conns   map[net.Conn]bool
+	closing bool
 }
 
-func HandleConnections(handler func(Message) Response) error {
+// Listen creates the daemon socket, replacing a stale one, and returns a server ready to Serve.
+// Requests go to handler; subscribe requests get their events from subscribe.
+func Listen(handler func(Message) Response, subscribe func(model.EventFilter) (<-chan model.Event, func())) (*Server, error) {
 	sockPath, err := getSocketPath()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	_, err = os.Stat(sockPath)
 	if err == nil {
 		err = os.Remove(sockPath)
 		if err != nil {
-			return fmt.Errorf("error removing existing socket: %w", err)
+			return nil, fmt.Errorf("error removing existing socket: %w", err)
 		}
 	}
 
 	sockDir := filepath.Dir(sockPath)
 	err = os.MkdirAll(sockDir, 0755)
 	if err != nil {
-		return fmt.Errorf("error creating socket directory: %w", err)
+		return nil, fmt.Errorf("error creating socket directory: %w", err)
 	}
 
 	listener, err := net.Listen("unix", sockPath)
 	if err != nil {
-		return fmt.Errorf("error creating socket listener: %w", err)
+		return nil, fmt.Errorf("error creating socket listener: %w", err)
 	}
 
 	err = os.Chmod(sockPath, 0600)
 	if err != nil {
-		return fmt.Errorf("error setting socket permissions: %w", err)
+		listener.Close()
+		return nil, fmt.Errorf("error setting socket permissions: %w", err)
 	}
 
+	return &Server{
+		listener:  listener,
+		handler:   handler,
+		subscribe: subscribe,
+		conns:     make(map[net.Conn]bool),
+	}, nil
+}
+
+// Serve accepts connections until Shutdown is called.
+func (s *Server) Serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.isClosing() {
+				return
+			}
+			log.Printf("Error accepting connection: %v", err)
+			continue
+		}
+
+		if !s.track(conn) {
+			conn.Close()
+			return
+		}
+
+		s.wg.Add(1)
+		go s.handleConnection(conn)
+	}
+}
+
+// Shutdown stops accepting connections, closes the idle ones and waits for requests in flight
+// to be answered. When ctx ends first the remaining connections are closed and ctx's error returned.
+// Closing the listener also removes the socket file.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	s.listener.Close()
+	for conn, busy := range s.conns {
+		if !busy {
+			conn.Close()
+		}
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
 	go func() {
-		for {
-			conn, err := listener.Accept()
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// handleConnection serves the requests of one client until it disconnects or the server shuts down.
+// Each message is either a JSON-RPC 2.0 request or batch, or a legacy Message. The first one must
+// be the hello handshake; a client speaking another protocol version is answered with an error
+// and disconnected.
+func (s *Server) handleConnection(conn net.Conn) {
+	defer s.wg.Done()
+	defer s.untrack(conn)
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	greeted := false
+	// rpc tells how to report a message that cannot be read at all
+	rpc := false
+
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			return
+		}
+		if err != nil && s.isClosing() {
+			// Shutdown closed the idle connection
+			return
+		}
+		if err != nil {
+			log.Printf("Error reading message: %v", err)
+			if rpc {
+				enc.Encode(newRPCError(nil, CodeParseError, fmt.Sprintf("parse error: %v", err)))
+			} else {
+				enc.Encode(Response{Success: false, Error: fmt.Sprintf("invalid message: %v", err), Code: CodeParseError})
+			}
+			return
+		}
+
+		req, batch, isRPC, rpcErr := parseRPC(raw)
+		rpc = rpc || isRPC
+
+		if rpcErr != nil {
+			log.Printf("Error reading request: %v", rpcErr)
+			err = enc.Encode(newRPCError(nil, CodeInvalidRequest, fmt.Sprintf("invalid request: %v", rpcErr)))
+			if err != nil || !greeted {
+				return
+			}
+			continue
+		}
+
+		var msg Message
+		if !isRPC {
+			err = json.Unmarshal(raw, &msg)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error accepting connection: %v\n", err)
-				continue
+				log.Printf("Error reading message: %v", err)
+				enc.Encode(Response{Success: false, Error: fmt.Sprintf("invalid message: %v", err), Code: CodeInvalidRequest})
+				return
 			}
+		}
 
-			go handleConnection(conn, handler)
+		switch {
+		case greeted && isRPC && batch == nil && req.Method == subscribeCommand:
+			s.stream(conn, req.Params, func(resp Response) error {
+				if len(req.ID) == 0 {
+					return nil
+				}
+				return enc.Encode(newRPCResponse(req.ID, resp))
+			}, func(e model.Event) error {
+				return enc.Encode(rpcNotification{JSONRPC: jsonrpcVersion, Method: rpcEventMethod, Params: e})
+			})
+			return
+		case greeted && !isRPC && msg.Command == subscribeCommand:
+			s.stream(conn, msg.Params, func(resp Response) error {
+				return enc.Encode(resp)
+			}, func(e model.Event) error {
+				return enc.Encode(e)
+			})
+			return
 		}
-	}()
 
-	return nil
+		s.setBusy(conn, true)
+
+		var reply interface{}
+		switch {
+		case isRPC:
+			reply = s.serveRPC(req, batch, &greeted)
+		case greeted:
+			reply = s.handler(msg)
+		default:
+			resp := handleHello(msg)
+			greeted = resp.Success
+			reply = resp
+		}
+
+		if reply != nil {
+			err = enc.Encode(reply)
+			if err != nil {
+				log.Printf("Error writing response: %v", err)
+				return
+			}
+		}
+
+		// A connection that finishes its request during shutdown is not read from again
+		if !s.setBusy(conn, false) || !greeted {
+			return
+		}
+	}
 }
 
-func handleConnection(conn net.Conn, handler func(Message) Response) {
-	defer conn.Close()
+// stream answers a subscribe request with reply and then writes the matching events to conn
+// with send until the client disconnects or the server shuts down. The connection stays idle
+// for Shutdown, which closes it like any other waiting connection.
+func (s *Server) stream(conn net.Conn, params json.RawMessage, reply func(Response) error, send func(model.Event) error) {
+	var filter model.EventFilter
+	if len(params) > 0 {
+		err := json.Unmarshal(params, &filter)
+		if err != nil {
+			reply(Response{Success: false, Error: fmt.Sprintf("invalid subscribe parameters: %v", err), Code: CodeInvalidParams})
+			return
+		}
+	}
 
-	buf := make([]byte, 4096)
-	n, err := conn.Read(buf)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading from connection: %v\n", err)
-		return
+	for _, t := range filter.Types {
+		if !model.EventType.Validate(t) {
+			reply(Response{Success: false, Error: fmt.Sprintf("unknown event type: %s", t), Code: CodeInvalidParams})
+			return
+		}
 	}
 
-	var msg Message
-	err = json.Unmarshal(buf[:n], &msg)
+	events, unsubscribe := s.subscribe(filter)
+	defer unsubscribe()
+
+	err := reply(Response{Success: true})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error unmarshaling message: %v\n", err)
 		return
 	}
 
-	resp := handler(msg)
+	// The client sends nothing more, so reading only tells when it goes away
+	gone := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(gone)
+	}()
 
-	respJSON, err := json.Marshal(resp)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling response: %v\n", err)
-		return
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			err = send(e)
+			if err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
 	}
+}
 
-	_, err = conn.Write(respJSON)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing response: %v\n", err)
-		return
+// track registers a new idle connection. It reports false once the server is shutting down.
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closing {
+		return false
 	}
+	s.conns[conn] = false
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, conn)
+	conn.Close()
+}
+
+// setBusy marks whether conn is handling a request. It reports false once the server is shutting down.
+func (s *Server) setBusy(conn net.Conn, busy bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conns[conn] = busy
+	return !s.closing
+}
+
+func (s *Server) isClosing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
 }
 
 func getSocketPath() (string, error) {
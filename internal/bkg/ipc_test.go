@@ -0,0 +1,145 @@
+package bkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// startTestServer serves handler on a socket under a temporary home directory.
+func startTestServer(t *testing.T, handler func(Message) Response) {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+
+	subscribe := func(model.EventFilter) (<-chan model.Event, func()) {
+		return make(chan model.Event), func() {}
+	}
+
+	server, err := Listen(handler, subscribe)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	go server.Serve()
+
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+}
+
+// echo answers every command with its own parameters.
+func echo(msg Message) Response {
+	return Response{Success: true, Data: msg.Params}
+}
+
+func echoedText(t *testing.T, resp *Response) string {
+	t.Helper()
+
+	if !resp.Success {
+		t.Fatalf("response error = %s", resp.Error)
+	}
+
+	var params TextParams
+	err := json.Unmarshal(resp.Data, &params)
+	if err != nil {
+		t.Fatalf("error reading response: %v", err)
+	}
+	return params.Text
+}
+
+func TestIPCLargeMessage(t *testing.T) {
+	startTestServer(t, echo)
+
+	client, err := Dial()
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	text := strings.Repeat("0123456789abcdef", 64*1024/16)
+	resp, err := client.Send("echo", TextParams{Text: text})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got := echoedText(t, resp); got != text {
+		t.Errorf("Send() echoed %d bytes, want the %d sent", len(got), len(text))
+	}
+}
+
+func TestIPCSeveralRequestsOnOneConnection(t *testing.T) {
+	startTestServer(t, echo)
+
+	client, err := Dial()
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	// Sizes around the 4 KB a single read often returns, so messages span reads.
+	for i, size := range []int{10, 4095, 4096, 4097, 12000, 1} {
+		text := fmt.Sprintf("%d:%s", i, strings.Repeat("x", size))
+
+		resp, err := client.Send("echo", TextParams{Text: text})
+		if err != nil {
+			t.Fatalf("Send() %d error = %v", i, err)
+		}
+
+		if got := echoedText(t, resp); got != text {
+			t.Errorf("Send() %d echoed %.10q (%d bytes), want %.10q (%d bytes)", i, got, len(got), text, len(text))
+		}
+	}
+}
+
+func TestIPCPipelinedRequests(t *testing.T) {
+	startTestServer(t, echo)
+
+	sockPath, err := getSocketPath()
+	if err != nil {
+		t.Fatalf("getSocketPath() error = %v", err)
+	}
+
+	conn, err := net.Dial("unix", sockPath)
+	if err != nil {
+		t.Fatalf("error connecting: %v", err)
+	}
+	defer conn.Close()
+
+	// The handshake and every request go out in one write, before any response is read.
+	var out strings.Builder
+	enc := json.NewEncoder(&out)
+	enc.Encode(map[string]interface{}{"command": helloCommand, "params": HelloParams{ProtocolVersion: ProtocolVersion, Version: Version}})
+
+	texts := []string{"first", strings.Repeat("y", 5000), "third"}
+	for _, text := range texts {
+		enc.Encode(map[string]interface{}{"command": "echo", "params": TextParams{Text: text}})
+	}
+
+	_, err = conn.Write([]byte(out.String()))
+	if err != nil {
+		t.Fatalf("error writing requests: %v", err)
+	}
+
+	dec := json.NewDecoder(conn)
+
+	var hello Response
+	err = dec.Decode(&hello)
+	if err != nil || !hello.Success {
+		t.Fatalf("handshake response = %+v, error = %v", hello, err)
+	}
+
+	for i, text := range texts {
+		var resp Response
+		err = dec.Decode(&resp)
+		if err != nil {
+			t.Fatalf("error reading response %d: %v", i, err)
+		}
+
+		if got := echoedText(t, &resp); got != text {
+			t.Errorf("response %d = %.10q (%d bytes), want %.10q (%d bytes)", i, got, len(got), text, len(text))
+		}
+	}
+}
@@ -1,12 +1,21 @@
 package bkg
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 )
 
 type CaptureParams struct {
-	Text string `json:"text"`
+	Text   string `json:"text"`
+	Parent string `json:"parent,omitempty"`
+}
+
+// CaptureBatchParams carries texts to capture as one node each, in a single transaction.
+type CaptureBatchParams struct {
+	Texts  []string `json:"texts"`
+	Parent string   `json:"parent,omitempty"`
 }
 
 func (s *Service) handleCapture(params json.RawMessage) Response {
@@ -16,14 +25,16 @@ func (s *Service) handleCapture(params json.RawMessage) Response {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("invalid parameters: %v", err),
+			Code:    CodeInvalidParams,
 		}
 	}
 
-	node, err := s.svc.Capture(p.Text)
+	node, err := s.svc.CaptureSub(p.Parent, p.Text)
 	if err != nil {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("error capturing: %v", err),
+			Code:    errorCode(err),
 		}
 	}
 
@@ -40,3 +51,23 @@ func (s *Service) handleCapture(params json.RawMessage) Response {
 		Data:    nodeJSON,
 	}
 }
+
+// handleCaptureBatch captures every text or none, and answers with the nodes created.
+func (s *Service) handleCaptureBatch(p json.RawMessage) Response {
+	var params CaptureBatchParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing capture-batch params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Batch capture requested: %d texts", len(params.Texts))
+
+	nodes, err := s.svc.CaptureAll(context.Background(), params.Parent, params.Texts)
+	if err != nil {
+		log.Printf("Error capturing batch: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error capturing: %v", err), Code: errorCode(err)}
+	}
+
+	return nodesResponse(nodes)
+}
@@ -0,0 +1,64 @@
+package bkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// DaemonStatus is what a running daemon reports about itself. Zero times mean the job has not run yet.
+type DaemonStatus struct {
+	PID                 int       `json:"pid"`
+	StartedAt           time.Time `json:"started_at"`
+	Version             string    `json:"version"`
+	ProtocolVersion     int       `json:"protocol_version"`
+	DBPath              string    `json:"db_path"`
+	LogPath             string    `json:"log_path"`
+	PollInterval        string    `json:"poll_interval"`
+	HTTPAddress         string    `json:"http_address,omitempty"`
+	LastJournal         time.Time `json:"last_journal,omitempty"`
+	LastNotificationRun time.Time `json:"last_notification_run,omitempty"`
+	LastBackup          time.Time `json:"last_backup,omitempty"`
+}
+
+func (s *Service) handleDaemonStatus() Response {
+	logPath, _ := LogFilePath()
+
+	var httpAddress string
+	if s.httpServer != nil {
+		httpAddress = s.httpServer.Addr
+	}
+
+	s.mu.Lock()
+	status := DaemonStatus{
+		PID:                 os.Getpid(),
+		StartedAt:           s.startedAt,
+		Version:             Version,
+		ProtocolVersion:     ProtocolVersion,
+		DBPath:              s.repo.Path(),
+		LogPath:             logPath,
+		PollInterval:        s.cfg.Snapshot().PollInterval.String(),
+		HTTPAddress:         httpAddress,
+		LastJournal:         s.lastJournalGen,
+		LastNotificationRun: s.lastNotificationRun,
+		LastBackup:          s.lastBackup,
+	}
+	s.mu.Unlock()
+
+	statusJSON, err := json.Marshal(status)
+	if err != nil {
+		log.Printf("Error encoding daemon status: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: statusJSON}
+}
+
+// handleShutdown stops the serve loop; the daemon exits once it returns.
+func (s *Service) handleShutdown() Response {
+	log.Println("Shutdown requested over IPC")
+	s.stop()
+	return Response{Success: true}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
 )
 
 func (s *Service) handleDate(params json.RawMessage) Response {
@@ -15,17 +17,19 @@ func (s *Service) handleDate(params json.RawMessage) Response {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("error unmarshaling date params: %v", err),
+			Code:    CodeInvalidParams,
 		}
 	}
 
 	log.Printf("Handling date operation: %s for task %s with date %s", dateParams.Operation, dateParams.ID, dateParams.Date)
 
 	ctx := context.Background()
-	task, err := s.svc.Repo.GetTaskByID(ctx, dateParams.ID)
+	task, err := s.svc.ResolveID(ctx, dateParams.ID)
 	if err != nil {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("error fetching task with ID %s: %v", dateParams.ID, err),
+			Code:    errorCode(err),
 		}
 	}
 
@@ -34,22 +38,23 @@ func (s *Service) handleDate(params json.RawMessage) Response {
 	var newDate string
 
 	if task.DueDate != nil {
-		originalDate = task.DueDate.Format("2006-01-02")
+		originalDate = model.FormatDueDate(*task.DueDate)
 	} else {
 		originalDate = "none"
 	}
 
 	switch dateParams.Operation {
 	case "set":
-		date, err := time.Parse("2006-01-02", dateParams.Date)
+		date, err := svc.ParseDate(dateParams.Date)
 		if err != nil {
 			return Response{
 				Success: false,
 				Error:   fmt.Sprintf("invalid date format: %v", err),
+				Code:    errorCode(err),
 			}
 		}
 		task.DueDate = &date
-		newDate = dateParams.Date
+		newDate = model.FormatDueDate(date)
 		message = fmt.Sprintf("Set due date to %s for task %s", newDate, dateParams.ID)
 
 	case "remove":
@@ -61,6 +66,7 @@ func (s *Service) handleDate(params json.RawMessage) Response {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("unknown date operation: %s", dateParams.Operation),
+			Code:    CodeInvalidParams,
 		}
 	}
 
@@ -69,6 +75,7 @@ func (s *Service) handleDate(params json.RawMessage) Response {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("error updating task: %v", err),
+			Code:    errorCode(err),
 		}
 	}
 
@@ -0,0 +1,90 @@
+package bkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+)
+
+type DependParams struct {
+	ID        string `json:"id"`
+	DependsOn string `json:"depends_on"`
+	Operation string `json:"operation"` // "add", "remove", or "list"
+}
+
+type DependResult struct {
+	TaskID         string       `json:"task_id"`
+	DependsOnID    string       `json:"depends_on_id,omitempty"`
+	OriginalStatus string       `json:"original_status,omitempty"`
+	NewStatus      string       `json:"new_status,omitempty"`
+	Dependencies   []model.Node `json:"dependencies,omitempty"`
+	Dependents     []model.Node `json:"dependents,omitempty"`
+}
+
+func (s *Service) handleDepend(p json.RawMessage) Response {
+	var params DependParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing depend params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Dependency operation requested: ID=%s, DependsOn=%s, Operation=%s", params.ID, params.DependsOn, params.Operation)
+
+	ctx := context.Background()
+
+	var result DependResult
+	switch params.Operation {
+	case "add":
+		change, err := s.svc.AddDependency(ctx, params.ID, params.DependsOn)
+		if err != nil {
+			log.Printf("Error adding dependency: %v", err)
+			return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+		}
+		result = NewDependResult(ctx, s.svc, change)
+	case "remove":
+		change, err := s.svc.RemoveDependency(ctx, params.ID, params.DependsOn)
+		if err != nil {
+			log.Printf("Error removing dependency: %v", err)
+			return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+		}
+		result = NewDependResult(ctx, s.svc, change)
+	case "list":
+		deps, err := s.svc.Dependencies(ctx, params.ID)
+		if err != nil {
+			log.Printf("Error listing dependencies: %v", err)
+			return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+		}
+		result = DependResult{
+			TaskID:       s.svc.NodeShortIDs(ctx, deps.Task)[deps.Task.ID],
+			Dependencies: deps.Dependencies,
+			Dependents:   deps.Dependents,
+		}
+	default:
+		return Response{Success: false, Error: fmt.Sprintf("invalid operation: %s", params.Operation), Code: CodeInvalidParams}
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: resultJSON}
+}
+
+// NewDependResult summarizes an added or removed dependency for display, with IDs shortened
+// to their shortest unique prefix.
+func NewDependResult(ctx context.Context, s *svc.Svc, change svc.DependencyChange) DependResult {
+	short := s.NodeShortIDs(ctx, change.Task, change.DependsOn)
+	return DependResult{
+		TaskID:         short[change.Task.ID],
+		DependsOnID:    short[change.DependsOn.ID],
+		OriginalStatus: change.OriginalStatus,
+		NewStatus:      change.NewStatus,
+	}
+}
@@ -0,0 +1,71 @@
+package bkg
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the version of the IPC protocol: newline-delimited JSON messages, any number
+// per connection, starting with a hello handshake. Version 1 was one unframed message per connection.
+// Bump it whenever a change to the messages would break an older peer.
+const ProtocolVersion = 2
+
+// Version is the tyn build, reported in the handshake so a daemon left running from a previous
+// build is noticed. Release builds set it with
+// -ldflags "-X github.com/adrianpk/tyn/internal/bkg.Version=<version>".
+var Version = "dev"
+
+const helloCommand = "hello"
+
+type HelloParams struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	Version         string `json:"version"`
+}
+
+type HelloResult struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	Version         string `json:"version"`
+}
+
+func handleHello(msg Message) Response {
+	if msg.Command != helloCommand {
+		return Response{
+			Success: false,
+			Error: fmt.Sprintf("this daemon speaks IPC protocol version %d and expects a handshake first; upgrade tn or run 'tn daemon restart'",
+				ProtocolVersion),
+			Code: CodeHandshake,
+		}
+	}
+
+	var params HelloParams
+	err := json.Unmarshal(msg.Params, &params)
+	if err != nil {
+		return Response{
+			Success: false,
+			Error:   fmt.Sprintf("invalid hello parameters: %v", err),
+			Code:    CodeInvalidParams,
+		}
+	}
+
+	if params.ProtocolVersion != ProtocolVersion {
+		return Response{
+			Success: false,
+			Error: fmt.Sprintf("IPC protocol version mismatch: tn %s speaks version %d, the daemon (tn %s) speaks version %d; run 'tn daemon restart'",
+				params.Version, params.ProtocolVersion, Version, ProtocolVersion),
+			Code: CodeHandshake,
+		}
+	}
+
+	data, err := json.Marshal(HelloResult{ProtocolVersion: ProtocolVersion, Version: Version})
+	if err != nil {
+		return Response{
+			Success: false,
+			Error:   fmt.Sprintf("error marshaling hello result: %v", err),
+		}
+	}
+
+	return Response{
+		Success: true,
+		Data:    data,
+	}
+}
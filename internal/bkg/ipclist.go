@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
 )
 
 type ListParams struct {
@@ -12,6 +13,10 @@ type ListParams struct {
 	Tags   []string `json:"tags,omitempty"`
 	Places []string `json:"places,omitempty"`
 	Status string   `json:"status,omitempty"`
+	// Archived lists the archived nodes instead of the others.
+	Archived bool `json:"archived,omitempty"`
+	// Query is a query in the language of svc.ParseQuery.
+	Query string `json:"query,omitempty"`
 }
 
 func (s *Service) handleList(params json.RawMessage) Response {
@@ -21,6 +26,7 @@ func (s *Service) handleList(params json.RawMessage) Response {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("invalid parameters: %v", err),
+			Code:    CodeInvalidParams,
 		}
 	}
 
@@ -42,11 +48,23 @@ func (s *Service) handleList(params json.RawMessage) Response {
 		filter.Status = p.Status
 	}
 
+	filter.Archived = p.Archived
+
+	filter.Query, err = svc.ParseQuery(p.Query)
+	if err != nil {
+		return Response{
+			Success: false,
+			Error:   err.Error(),
+			Code:    CodeInvalidParams,
+		}
+	}
+
 	nodes, err := s.svc.List(filter)
 	if err != nil {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("error listing: %v", err),
+			Code:    errorCode(err),
 		}
 	}
 
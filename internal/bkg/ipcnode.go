@@ -0,0 +1,138 @@
+package bkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+type GetParams struct {
+	ID string `json:"id"`
+}
+
+// EditParams carries a node edited as text, in the form svc.FormatEditable renders.
+type EditParams struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type EditResult struct {
+	Node    model.Node `json:"node"`
+	Changed []string   `json:"changed"`
+}
+
+type ConvertParams struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Draft string `json:"draft,omitempty"`
+}
+
+type ConvertResult struct {
+	ID           string `json:"id"`
+	OriginalType string `json:"original_type"`
+	NewType      string `json:"new_type"`
+}
+
+// handleGet answers with the node an ID or prefix refers to.
+func (s *Service) handleGet(p json.RawMessage) Response {
+	var params GetParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing get params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	node, err := s.svc.ResolveID(context.Background(), params.ID)
+	if err != nil {
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	nodeJSON, err := json.Marshal(node)
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: nodeJSON}
+}
+
+func (s *Service) handleText(p json.RawMessage) Response {
+	var params TextParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing text params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Text change requested: ID=%s", params.ID)
+
+	node, err := s.svc.EditText(context.Background(), params.ID, params.Text)
+	if err != nil {
+		log.Printf("Error changing text: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	return messageResponse(fmt.Sprintf("Updated the text of %s %s", node.Type, params.ID))
+}
+
+// handleEdit applies a node edited as text and answers with the node and the fields that changed.
+func (s *Service) handleEdit(p json.RawMessage) Response {
+	var params EditParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing edit params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Edit requested: ID=%s", params.ID)
+
+	node, changed, err := s.svc.ApplyEdit(context.Background(), params.ID, params.Text)
+	if err != nil {
+		log.Printf("Error applying edit: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	if changed == nil {
+		changed = []string{}
+	}
+
+	resultJSON, err := json.Marshal(EditResult{Node: node, Changed: changed})
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: resultJSON}
+}
+
+func (s *Service) handleConvert(p json.RawMessage) Response {
+	var params ConvertParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing convert params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Conversion requested: ID=%s, Type=%s", params.ID, params.Type)
+
+	change, err := s.svc.ConvertNode(context.Background(), params.ID, params.Type, params.Draft)
+	if err != nil {
+		log.Printf("Error converting node: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	resultJSON, err := json.Marshal(ConvertResult{
+		ID:           change.Node.ID,
+		OriginalType: change.OriginalType,
+		NewType:      change.NewType,
+	})
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: resultJSON}
+}
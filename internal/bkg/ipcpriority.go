@@ -0,0 +1,50 @@
+package bkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+type PriorityParams struct {
+	ID        string `json:"id"`
+	Priority  string `json:"priority"`
+	Operation string `json:"operation"` // "set", "up", or "down"
+}
+
+type PriorityResult struct {
+	OriginalPriority int `json:"original_priority"`
+	NewPriority      int `json:"new_priority"`
+}
+
+func (s *Service) handlePriority(p json.RawMessage) Response {
+	var params PriorityParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing priority params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Priority change requested: ID=%s, Priority=%s, Operation=%s", params.ID, params.Priority, params.Operation)
+
+	ctx := context.Background()
+	change, err := s.svc.ChangePriority(ctx, params.ID, params.Operation, params.Priority)
+	if err != nil {
+		log.Printf("Error changing priority: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	log.Printf("Priority updated successfully: %d → %d", change.OriginalPriority, change.NewPriority)
+
+	resultJSON, err := json.Marshal(PriorityResult{
+		OriginalPriority: change.OriginalPriority,
+		NewPriority:      change.NewPriority,
+	})
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: resultJSON}
+}
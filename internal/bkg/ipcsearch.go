@@ -0,0 +1,38 @@
+package bkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+type SearchParams struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+func (s *Service) handleSearch(p json.RawMessage) Response {
+	var params SearchParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing search params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Search requested: Query=%s, Limit=%d", params.Query, params.Limit)
+
+	results, err := s.svc.Search(context.Background(), params.Query, params.Limit)
+	if err != nil {
+		log.Printf("Error searching: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	resultJSON, err := json.Marshal(results)
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: resultJSON}
+}
@@ -0,0 +1,36 @@
+package bkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+type ShortIDsParams struct {
+	IDs []string `json:"ids"`
+}
+
+// handleShortIDs answers with the shortest unique prefix of each ID, keyed by the ID.
+func (s *Service) handleShortIDs(p json.RawMessage) Response {
+	var params ShortIDsParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing short-ids params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	short, err := s.svc.ShortIDs(context.Background(), params.IDs)
+	if err != nil {
+		log.Printf("Error computing short IDs: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	resultJSON, err := json.Marshal(short)
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: resultJSON}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
 )
 
 type StatusParams struct {
@@ -15,93 +16,86 @@ type StatusParams struct {
 	Operation string `json:"operation"` // "set", "next", or "prev"
 }
 
+type StatusResult struct {
+	OriginalStatus string   `json:"original_status"`
+	NewStatus      string   `json:"new_status"`
+	NextID         string   `json:"next_id,omitempty"`
+	NextDueDate    string   `json:"next_due_date,omitempty"`
+	OpenSubtasks   []string `json:"open_subtasks,omitempty"`
+	Unblocked      []string `json:"unblocked,omitempty"`
+	Blocked        []string `json:"blocked,omitempty"`
+	BlockedBy      []string `json:"blocked_by,omitempty"`
+	PriorStatus    string   `json:"prior_status,omitempty"`
+}
+
 func (s *Service) handleStatus(p json.RawMessage) Response {
 	var params StatusParams
 	err := json.Unmarshal(p, &params)
 	if err != nil {
 		log.Printf("Error parsing status params: %v", err)
-		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err)}
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
 	}
 
 	log.Printf("Status change requested: ID=%s, Status=%s, Operation=%s", params.ID, params.Status, params.Operation)
 
 	ctx := context.Background()
-	nodes, err := s.svc.Repo.List(ctx)
+	change, err := s.svc.ChangeStatus(ctx, params.ID, params.Operation, params.Status)
 	if err != nil {
-		log.Printf("Error listing nodes: %v", err)
-		return Response{Success: false, Error: fmt.Sprintf("error listing nodes: %v", err)}
+		log.Printf("Error changing status: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
 	}
 
-	var targetNode model.Node
-	var found bool
-
-	for _, node := range nodes {
-		if len(params.ID) <= 4 && node.ShortID() == params.ID {
-			targetNode = node
-			found = true
-			log.Printf("Found node by ShortID: %s", node.ID)
-			break
-		} else if len(params.ID) > 4 && len(node.ID) >= len(params.ID) && node.ID[:len(params.ID)] == params.ID {
-			targetNode = node
-			found = true
-			log.Printf("Found node by prefix: %s", node.ID)
-			break
-		}
-	}
+	log.Printf("Status updated successfully: '%s' → '%s'", change.OriginalStatus, change.NewStatus)
 
-	if !found {
-		log.Printf("Task with ID '%s' not found", params.ID)
-		return Response{Success: false, Error: fmt.Sprintf("task with ID '%s' not found", params.ID)}
+	result := NewStatusResult(ctx, s.svc, change)
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
 	}
 
-	if targetNode.Type != "task" {
-		log.Printf("Node with ID '%s' is not a task", params.ID)
-		return Response{Success: false, Error: fmt.Sprintf("node with ID '%s' is not a task", params.ID)}
+	return Response{Success: true, Data: resultJSON}
+}
+
+// NewStatusResult summarizes a status change for display, with IDs shortened to their
+// shortest unique prefix.
+func NewStatusResult(ctx context.Context, s *svc.Svc, change svc.StatusChange) StatusResult {
+	result := StatusResult{
+		OriginalStatus: change.OriginalStatus,
+		NewStatus:      change.NewStatus,
 	}
 
-	originalStatus := targetNode.Status
-	var newStatus string
-
-	switch params.Operation {
-	case "set":
-		if !model.ValidStatus(params.Status) {
-			log.Printf("Invalid status: %s", params.Status)
-			return Response{Success: false, Error: fmt.Sprintf("invalid status: %s", params.Status)}
-		}
-		newStatus = params.Status
-	case "next":
-		newStatus = model.NextStatus(targetNode.Status)
-	case "prev":
-		newStatus = model.PreviousStatus(targetNode.Status)
-	default:
-		log.Printf("Invalid operation: %s", params.Operation)
-		return Response{Success: false, Error: fmt.Sprintf("invalid operation: %s", params.Operation)}
+	nodes := append(append(append([]model.Node(nil), change.OpenChildren...), change.Unblocked...), change.Blocked...)
+	nodes = append(nodes, change.BlockedBy...)
+	if change.Next != nil {
+		nodes = append(nodes, *change.Next)
 	}
+	short := s.NodeShortIDs(ctx, nodes...)
 
-	log.Printf("Changing status from '%s' to '%s'", originalStatus, newStatus)
-	targetNode.Status = newStatus
+	if change.Next != nil {
+		result.NextID = short[change.Next.ID]
+		result.NextDueDate = model.FormatDueDate(*change.Next.DueDate)
+	}
 
-	err = s.svc.Repo.Update(ctx, targetNode)
-	if err != nil {
-		log.Printf("Error updating task: %v", err)
-		return Response{Success: false, Error: fmt.Sprintf("error updating task: %v", err)}
+	for _, child := range change.OpenChildren {
+		result.OpenSubtasks = append(result.OpenSubtasks, fmt.Sprintf("%s %s", short[child.ID], child.Content))
 	}
 
-	log.Printf("Status updated successfully: '%s' → '%s'", originalStatus, newStatus)
+	for _, task := range change.Unblocked {
+		result.Unblocked = append(result.Unblocked, fmt.Sprintf("%s %s", short[task.ID], task.Content))
+	}
 
-	result := struct {
-		OriginalStatus string `json:"original_status"`
-		NewStatus      string `json:"new_status"`
-	}{
-		OriginalStatus: originalStatus,
-		NewStatus:      newStatus,
+	for _, task := range change.Blocked {
+		result.Blocked = append(result.Blocked, fmt.Sprintf("%s %s", short[task.ID], task.Content))
 	}
 
-	resultJSON, err := json.Marshal(result)
-	if err != nil {
-		log.Printf("Error encoding result: %v", err)
-		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	for _, dep := range change.BlockedBy {
+		result.BlockedBy = append(result.BlockedBy, fmt.Sprintf("%s %s", short[dep.ID], dep.Content))
+	}
+	if len(change.BlockedBy) > 0 {
+		result.PriorStatus = change.Task.PriorStatus
 	}
 
-	return Response{Success: true, Data: resultJSON}
+	return result
 }
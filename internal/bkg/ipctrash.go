@@ -0,0 +1,110 @@
+package bkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// IDsParams names the nodes rm, archive and unarchive work on.
+type IDsParams struct {
+	IDs []string `json:"ids"`
+}
+
+type TrashParams struct {
+	Operation string   `json:"operation"`
+	IDs       []string `json:"ids,omitempty"`
+}
+
+// handleRemove moves nodes to the trash and answers with every node moved, subtasks included.
+func (s *Service) handleRemove(p json.RawMessage) Response {
+	var params IDsParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing rm params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Removal requested: IDs=%v", params.IDs)
+
+	nodes, err := s.svc.TrashNodes(context.Background(), params.IDs)
+	if err != nil {
+		log.Printf("Error moving nodes to the trash: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	return nodesResponse(nodes)
+}
+
+// handleTrash lists ("list"), restores ("restore") or empties ("empty") the trash. Every
+// operation answers with the nodes it concerns.
+func (s *Service) handleTrash(p json.RawMessage) Response {
+	var params TrashParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing trash params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	ctx := context.Background()
+
+	var nodes []model.Node
+	switch params.Operation {
+	case "list":
+		nodes, err = s.svc.Trash(ctx)
+	case "restore":
+		log.Printf("Restore requested: IDs=%v", params.IDs)
+		nodes, err = s.svc.RestoreNodes(ctx, params.IDs)
+	case "empty":
+		log.Println("Emptying the trash")
+		nodes, err = s.svc.EmptyTrash(ctx, time.Time{})
+	default:
+		return Response{Success: false, Error: fmt.Sprintf("unknown operation: %s", params.Operation), Code: CodeInvalidParams}
+	}
+
+	if err != nil {
+		log.Printf("Error in trash %s: %v", params.Operation, err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	return nodesResponse(nodes)
+}
+
+// handleArchive archives nodes, or unarchives them when archive is false, and answers with the
+// nodes whose state changed.
+func (s *Service) handleArchive(p json.RawMessage, archive bool) Response {
+	var params IDsParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing archive params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	log.Printf("Archive change requested: IDs=%v, archive=%t", params.IDs, archive)
+
+	nodes, err := s.svc.ArchiveNodes(context.Background(), params.IDs, archive)
+	if err != nil {
+		log.Printf("Error changing archive state: %v", err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	return nodesResponse(nodes)
+}
+
+func nodesResponse(nodes []model.Node) Response {
+	if nodes == nil {
+		nodes = []model.Node{}
+	}
+
+	nodesJSON, err := json.Marshal(nodes)
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: nodesJSON}
+}
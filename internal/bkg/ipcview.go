@@ -0,0 +1,73 @@
+package bkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// ViewParams names a saved view and, to save one, its query and journal section.
+type ViewParams struct {
+	Operation string `json:"operation"`
+	Name      string `json:"name,omitempty"`
+	Query     string `json:"query,omitempty"`
+	Section   string `json:"section,omitempty"`
+}
+
+// ViewResult holds the views an operation concerns and, for "run", the nodes the view matches.
+type ViewResult struct {
+	Views []model.View `json:"views"`
+	Nodes []model.Node `json:"nodes,omitempty"`
+}
+
+// handleView saves ("save"), lists ("list"), deletes ("rm") or runs ("run") saved views.
+func (s *Service) handleView(p json.RawMessage) Response {
+	var params ViewParams
+	err := json.Unmarshal(p, &params)
+	if err != nil {
+		log.Printf("Error parsing view params: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error parsing params: %v", err), Code: CodeInvalidParams}
+	}
+
+	ctx := context.Background()
+
+	var result ViewResult
+	var view model.View
+	switch params.Operation {
+	case "save":
+		log.Printf("Saving view %q: %s", params.Name, params.Query)
+		view, err = s.svc.SaveView(ctx, params.Name, params.Query, params.Section)
+		result.Views = []model.View{view}
+	case "list":
+		result.Views, err = s.svc.Views(ctx)
+	case "rm":
+		log.Printf("Deleting view %q", params.Name)
+		view, err = s.svc.DeleteView(ctx, params.Name)
+		result.Views = []model.View{view}
+	case "run":
+		view, result.Nodes, err = s.svc.RunView(ctx, params.Name)
+		result.Views = []model.View{view}
+	default:
+		return Response{Success: false, Error: fmt.Sprintf("unknown operation: %s", params.Operation), Code: CodeInvalidParams}
+	}
+
+	if err != nil {
+		log.Printf("Error in view %s: %v", params.Operation, err)
+		return Response{Success: false, Error: err.Error(), Code: errorCode(err)}
+	}
+
+	if result.Views == nil {
+		result.Views = []model.View{}
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Error encoding result: %v", err)
+		return Response{Success: false, Error: fmt.Sprintf("error encoding result: %v", err)}
+	}
+
+	return Response{Success: true, Data: resultJSON}
+}
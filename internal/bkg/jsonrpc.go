@@ -0,0 +1,219 @@
+package bkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// jsonrpcVersion marks a message as JSON-RPC 2.0. The socket accepts it next to the legacy
+// {"command", "params"} envelope, picking the format per message, so clients can move over
+// one request at a time. Methods are named after the legacy commands.
+const jsonrpcVersion = "2.0"
+
+// Error codes sent with failed responses, in both envelopes. The -32700 to -32603 range is
+// the one JSON-RPC 2.0 reserves; the others classify the errors of the commands themselves.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	// CodeServerError is any failure not classified below.
+	CodeServerError = -32000
+	CodeNotFound    = -32001
+	CodeAmbiguousID = -32002
+	CodeValidation  = -32003
+	// CodeHandshake means the connection did not start with a matching hello.
+	CodeHandshake = -32004
+)
+
+// rpcEventMethod names the notifications that carry the events of a subscription.
+const rpcEventMethod = "event"
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// ID is absent in notifications, which get no response.
+	ID json.RawMessage `json:"id,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+	ID      json.RawMessage  `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// errorCode classifies an error from the service layer.
+func errorCode(err error) int {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, model.ErrAmbiguousID):
+		return CodeAmbiguousID
+	case errors.Is(err, model.ErrInvalid):
+		return CodeValidation
+	default:
+		return CodeServerError
+	}
+}
+
+// parseRPC tells a JSON-RPC message from a legacy one. A batch is returned as its raw entries;
+// a single request is returned decoded. A message with a jsonrpc member is JSON-RPC even when
+// it cannot be decoded as a request; err reports why, to be answered as an invalid request.
+func parseRPC(raw json.RawMessage) (req rpcRequest, batch []json.RawMessage, ok bool, err error) {
+	raw = bytes.TrimSpace(raw)
+
+	if len(raw) > 0 && raw[0] == '[' {
+		err = json.Unmarshal(raw, &batch)
+		return rpcRequest{}, batch, err == nil, nil
+	}
+
+	var members map[string]json.RawMessage
+	err = json.Unmarshal(raw, &members)
+	if err != nil {
+		// Not an object, the legacy path reports it
+		return rpcRequest{}, nil, false, nil
+	}
+	if _, ok := members["jsonrpc"]; !ok {
+		return rpcRequest{}, nil, false, nil
+	}
+
+	err = json.Unmarshal(raw, &req)
+	if err != nil {
+		return rpcRequest{}, nil, true, err
+	}
+
+	return req, nil, true, nil
+}
+
+// serveRPC answers a JSON-RPC request or batch. It returns nil when there is nothing to send,
+// which is the case for notifications and for batches made only of notifications.
+func (s *Server) serveRPC(req rpcRequest, batch []json.RawMessage, greeted *bool) interface{} {
+	if batch == nil {
+		resp := s.callRPC(req, greeted, false)
+		if resp == nil {
+			return nil
+		}
+		return resp
+	}
+
+	if len(batch) == 0 {
+		return newRPCError(nil, CodeInvalidRequest, "empty batch")
+	}
+
+	var responses []*rpcResponse
+	for _, raw := range batch {
+		var req rpcRequest
+		err := json.Unmarshal(raw, &req)
+		if err != nil {
+			responses = append(responses, newRPCError(nil, CodeInvalidRequest, fmt.Sprintf("invalid request: %v", err)))
+			continue
+		}
+
+		resp := s.callRPC(req, greeted, true)
+		if resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// callRPC runs one request through the same handlers as the legacy envelope. Until the hello
+// handshake succeeds, only hello is answered.
+func (s *Server) callRPC(req rpcRequest, greeted *bool, inBatch bool) *rpcResponse {
+	if req.JSONRPC != jsonrpcVersion || req.Method == "" {
+		return newRPCError(validID(req.ID), CodeInvalidRequest, fmt.Sprintf("invalid request: jsonrpc must be %q and method set", jsonrpcVersion))
+	}
+	if validID(req.ID) == nil && len(req.ID) > 0 {
+		return newRPCError(nil, CodeInvalidRequest, "invalid request: id must be a string, a number or null")
+	}
+	if len(req.Params) > 0 && req.Params[0] != '{' && req.Params[0] != '[' && !bytes.Equal(req.Params, []byte("null")) {
+		return newRPCError(req.ID, CodeInvalidRequest, "invalid request: params must be an object or an array")
+	}
+
+	params := req.Params
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	msg := Message{Command: req.Method, Params: params}
+
+	var resp Response
+	switch {
+	case !*greeted:
+		resp = handleHello(msg)
+		*greeted = resp.Success
+	case req.Method == subscribeCommand && inBatch:
+		resp = Response{Success: false, Error: "subscribe cannot be part of a batch", Code: CodeInvalidRequest}
+	default:
+		resp = s.handler(msg)
+	}
+
+	if len(req.ID) == 0 {
+		return nil
+	}
+	return newRPCResponse(req.ID, resp)
+}
+
+// validID returns id if it is a string, a number or null, the types JSON-RPC allows, and nil
+// otherwise. Decoding accepts any JSON value as id.
+func validID(id json.RawMessage) json.RawMessage {
+	id = bytes.TrimSpace(id)
+	if len(id) == 0 {
+		return nil
+	}
+
+	switch c := id[0]; {
+	case c == '"', c == '-', c >= '0' && c <= '9', bytes.Equal(id, []byte("null")):
+		return id
+	default:
+		return nil
+	}
+}
+
+// newRPCResponse translates a handler response into a JSON-RPC one.
+func newRPCResponse(id json.RawMessage, resp Response) *rpcResponse {
+	if !resp.Success {
+		code := resp.Code
+		if code == 0 {
+			code = CodeServerError
+		}
+		return newRPCError(id, code, resp.Error)
+	}
+
+	result := resp.Data
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+
+	return &rpcResponse{JSONRPC: jsonrpcVersion, Result: &result, ID: id}
+}
+
+func newRPCError(id json.RawMessage, code int, message string) *rpcResponse {
+	return &rpcResponse{
+		JSONRPC: jsonrpcVersion,
+		Error:   &rpcError{Code: code, Message: message},
+		ID:      id,
+	}
+}
@@ -0,0 +1,104 @@
+package bkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestParseRPC(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantRPC bool
+		wantErr bool
+	}{
+		{name: "request", raw: `{"jsonrpc":"2.0","method":"list","id":1}`, wantRPC: true},
+		{name: "batch", raw: `[{"jsonrpc":"2.0","method":"list","id":1}]`, wantRPC: true},
+		{name: "legacy", raw: `{"command":"list","params":{}}`},
+		{name: "not an object", raw: `"list"`},
+		{name: "wrong method type", raw: `{"jsonrpc":"2.0","method":5,"id":1}`, wantRPC: true, wantErr: true},
+		{name: "wrong version type", raw: `{"jsonrpc":2,"method":"list","id":1}`, wantRPC: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, isRPC, err := parseRPC(json.RawMessage(tt.raw))
+			if isRPC != tt.wantRPC || (err != nil) != tt.wantErr {
+				t.Errorf("parseRPC(%s) = %v, %v, want %v, error %v", tt.raw, isRPC, err, tt.wantRPC, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRPCInvalidRequests(t *testing.T) {
+	startTestServer(t, echo)
+
+	sockPath, err := getSocketPath()
+	if err != nil {
+		t.Fatalf("getSocketPath() error = %v", err)
+	}
+
+	conn, err := net.Dial("unix", sockPath)
+	if err != nil {
+		t.Fatalf("error connecting: %v", err)
+	}
+	defer conn.Close()
+
+	dec := json.NewDecoder(conn)
+	call := func(raw string) rpcResponse {
+		t.Helper()
+
+		_, err := conn.Write([]byte(raw + "\n"))
+		if err != nil {
+			t.Fatalf("error writing %s: %v", raw, err)
+		}
+
+		var resp rpcResponse
+		err = dec.Decode(&resp)
+		if err != nil {
+			t.Fatalf("error reading the response to %s: %v", raw, err)
+		}
+		return resp
+	}
+
+	hello := call(`{"jsonrpc":"2.0","method":"hello","params":{"protocol_version":` + fmt.Sprint(ProtocolVersion) + `},"id":0}`)
+	if hello.Error != nil {
+		t.Fatalf("hello error = %+v", hello.Error)
+	}
+
+	tests := []struct {
+		name   string
+		raw    string
+		wantID string
+	}{
+		{name: "wrong method type", raw: `{"jsonrpc":"2.0","method":5,"id":1}`, wantID: "null"},
+		{name: "object id", raw: `{"jsonrpc":"2.0","method":"echo","id":{"n":1}}`, wantID: "null"},
+		{name: "string params", raw: `{"jsonrpc":"2.0","method":"echo","params":"text","id":2}`, wantID: "2"},
+		{name: "wrong version", raw: `{"jsonrpc":"1.0","method":"echo","id":"a"}`, wantID: `"a"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := call(tt.raw)
+			if resp.Error == nil || resp.Error.Code != CodeInvalidRequest {
+				t.Fatalf("response to %s = %+v, want error %d", tt.raw, resp.Error, CodeInvalidRequest)
+			}
+
+			id := string(resp.ID)
+			if id == "" {
+				id = "null"
+			}
+			if id != tt.wantID {
+				t.Errorf("response to %s has id %s, want %s", tt.raw, id, tt.wantID)
+			}
+		})
+	}
+
+	// The connection still serves valid requests afterwards.
+	resp := call(`{"jsonrpc":"2.0","method":"echo","params":{"text":"still here"},"id":3}`)
+	if resp.Error != nil || resp.Result == nil || string(*resp.Result) != `{"text":"still here"}` {
+		t.Errorf("echo after invalid requests = %+v", resp)
+	}
+}
@@ -5,29 +5,60 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/adrianpk/tyn/internal/config"
 	"github.com/adrianpk/tyn/internal/journal"
+	"github.com/adrianpk/tyn/internal/model"
 	"github.com/adrianpk/tyn/internal/notify"
 	"github.com/adrianpk/tyn/internal/repo/sqlite"
 	"github.com/adrianpk/tyn/internal/svc"
 )
 
-const DefaultPollInterval = 30 * time.Second
+const (
+	DefaultPollInterval = 30 * time.Second
+
+	// trashPurgeInterval is how often the daemon looks for nodes past the trash retention.
+	trashPurgeInterval = 1 * time.Hour
+
+	// drainTimeout bounds how long shutdown waits for requests in flight. It is shorter than
+	// stopTimeout, so tn daemon stop sees the daemon exit before it falls back to SIGTERM.
+	drainTimeout = 3 * time.Second
+)
 
 type Service struct {
 	svc                   *svc.Svc
+	repo                  *sqlite.TynRepo
+	cfg                   *config.Config
 	journalGenerator      *journal.Generator
-	lastJournalGen        time.Time
+	events                *eventBus
+	startedAt             time.Time
 	lastNotificationCheck time.Time
+	lastTrashPurge        time.Time
 	notifiedTaskIDs       map[string]bool
+
+	// httpServer serves the HTTP API; it is nil when the API is off. It is set before the IPC
+	// server starts serving and not changed afterwards.
+	httpServer *http.Server
+
+	// cancel ends the serve loop.
+	cancel context.CancelFunc
+
+	// mu guards the run times below. The serve loop writes them and daemon-status reads them.
+	mu                  sync.Mutex
+	lastJournalGen      time.Time
+	lastNotificationRun time.Time
+	lastBackup          time.Time
 }
 
 func ServeLoop(isDaemon bool, cfg *config.Config) {
 	if isDaemon {
-		logFile, err := logFilePath()
+		logFile, err := LogFilePath()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting log file path: %v\n", err)
 			return
@@ -45,22 +76,47 @@ func ServeLoop(isDaemon bool, cfg *config.Config) {
 
 	log.Println("Starting Tyn background service...")
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+
 	repo, err := sqlite.NewTynRepo(cfg)
 	if err != nil {
 		log.Fatalf("Error initializing repository: %v", err)
 	}
 
+	events := newEventBus()
+
 	service := &Service{
-		svc:              svc.New(repo, cfg),
-		journalGenerator: journal.New(repo),
-		notifiedTaskIDs:  make(map[string]bool),
+		svc:             svc.New(&eventRepo{Repo: repo, events: events}, cfg),
+		repo:            repo,
+		cfg:             cfg,
+		events:          events,
+		startedAt:       time.Now(),
+		notifiedTaskIDs: make(map[string]bool),
+		cancel:          cancel,
 	}
+	service.journalGenerator = journal.New(repo, service.svc)
 
-	err = HandleConnections(service.handleMessage)
+	server, err := Listen(service.handleMessage, events.subscribe)
 	if err != nil {
 		log.Fatalf("Error starting IPC handler: %v", err)
 	}
 
+	if cfg.HTTPAddress != "" {
+		httpServer, err := service.startHTTP()
+		if err != nil {
+			log.Printf("Error starting HTTP API, continuing without it: %v\n", err)
+		} else {
+			service.httpServer = httpServer
+			log.Printf("HTTP API listening on http://%s\n", cfg.HTTPAddress)
+		}
+	}
+
+	go server.Serve()
+
 	log.Println("IPC server started successfully")
 
 	log.Println("Initial journal generation on startup...")
@@ -69,7 +125,13 @@ func ServeLoop(isDaemon bool, cfg *config.Config) {
 		log.Printf("Error generating initial journal: %v\n", err)
 	} else {
 		log.Println("Initial journal generated successfully")
-		service.lastJournalGen = time.Now()
+		service.setRunTime(&service.lastJournalGen, time.Now())
+	}
+
+	if latest, ok, err := sqlite.LatestAutomaticBackup(); err != nil {
+		log.Printf("Error reading backups: %v\n", err)
+	} else if ok {
+		service.setRunTime(&service.lastBackup, latest.CreatedAt)
 	}
 
 	for {
@@ -90,12 +152,146 @@ func ServeLoop(isDaemon bool, cfg *config.Config) {
 				log.Printf("Error generating journal: %v\n", err)
 			} else {
 				log.Println("Journal generated successfully")
-				service.lastJournalGen = time.Now()
+				service.setRunTime(&service.lastJournalGen, time.Now())
+				service.events.publish(model.Event{Type: model.EventType.JournalGenerated})
+			}
+		}
+
+		err = service.backupIfDue()
+		if err != nil {
+			log.Printf("Error backing up database: %v\n", err)
+		}
+
+		err = service.purgeTrashIfDue()
+		if err != nil {
+			log.Printf("Error purging trash: %v\n", err)
+		}
+
+		if !service.wait(ctx, signals) {
+			break
+		}
+	}
+
+	// A second SIGTERM or SIGINT now kills the process, should shutting down hang
+	signal.Stop(signals)
+	service.close(server)
+}
+
+// wait sleeps for the poll interval, reloading the configuration on SIGHUP. It reports false
+// when the daemon should shut down instead, on SIGTERM, SIGINT or a shutdown request.
+func (s *Service) wait(ctx context.Context, signals <-chan os.Signal) bool {
+	timer := time.NewTimer(s.cfg.Snapshot().PollInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				s.reloadConfig()
+				continue
 			}
+			log.Printf("Received %v, stopping Tyn background service", sig)
+			return false
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// close stops accepting connections, waits for the requests in flight, brings the journal up
+// to date and closes the database. The socket goes with the listener; the PID file is removed
+// only if it names this process.
+func (s *Service) close(server *Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	if s.httpServer != nil {
+		err := s.httpServer.Shutdown(ctx)
+		if err != nil {
+			log.Printf("Error waiting for HTTP requests: %v\n", err)
 		}
+	}
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		log.Printf("Error waiting for open connections: %v\n", err)
+	}
+
+	log.Println("Flushing journal...")
+	err = s.journalGenerator.GenerateDaily()
+	if err != nil {
+		log.Printf("Error generating journal: %v\n", err)
+	}
+
+	err = s.repo.Close()
+	if err != nil {
+		log.Printf("Error closing database: %v\n", err)
+	}
+
+	removeOwnPidFile()
+
+	log.Println("Tyn background service stopped")
+}
+
+// reloadConfig re-reads the configuration file and environment. The new poll interval applies
+// from the next wait on.
+func (s *Service) reloadConfig() {
+	log.Println("Received SIGHUP, reloading configuration")
+
+	config.Reload(s.cfg)
+	cfg := s.cfg.Snapshot()
+
+	log.Printf("Configuration reloaded: poll interval %s, journal update interval %s, %d backups every %s\n",
+		cfg.PollInterval, cfg.JournalUpdateInterval, cfg.DatabaseBackupCount, cfg.DatabaseBackupInterval)
+}
+
+// stop asks the serve loop to return. It is safe to call more than once.
+func (s *Service) stop() {
+	s.cancel()
+}
+
+func (s *Service) setRunTime(t *time.Time, value time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*t = value
+}
+
+// backupIfDue takes a scheduled backup once DatabaseBackupInterval has passed since the last
+// automatic one. A backup count or interval of 0 turns scheduled backups off.
+func (s *Service) backupIfDue() error {
+	cfg := s.cfg.Snapshot()
+	if cfg.DatabaseBackupCount <= 0 || cfg.DatabaseBackupInterval <= 0 {
+		return nil
+	}
 
-		time.Sleep(cfg.PollInterval)
+	if time.Since(s.lastBackup) < cfg.DatabaseBackupInterval {
+		return nil
+	}
+
+	_, err := s.repo.Backup(context.Background(), sqlite.BackupScheduled)
+	if err != nil {
+		return err
 	}
+
+	s.setRunTime(&s.lastBackup, time.Now())
+	return nil
+}
+
+// purgeTrashIfDue deletes the nodes that have been in the trash longer than TrashRetention,
+// checking at most once per trashPurgeInterval.
+func (s *Service) purgeTrashIfDue() error {
+	if time.Since(s.lastTrashPurge) < trashPurgeInterval {
+		return nil
+	}
+	s.lastTrashPurge = time.Now()
+
+	purged, err := s.svc.PurgeTrash(context.Background())
+	if len(purged) > 0 {
+		log.Printf("Purged %d nodes from the trash\n", len(purged))
+	}
+	return err
 }
 
 func (s *Service) processPendingNodes() error {
@@ -110,22 +306,55 @@ func (s *Service) handleMessage(msg Message) Response {
 	switch msg.Command {
 	case "capture":
 		return s.handleCapture(msg.Params)
+	case "capture-batch":
+		return s.handleCaptureBatch(msg.Params)
 	case "list":
 		return s.handleList(msg.Params)
 	case "status":
 		return s.handleStatus(msg.Params)
+	case "priority":
+		return s.handlePriority(msg.Params)
+	case "depend":
+		return s.handleDepend(msg.Params)
+	case "search":
+		return s.handleSearch(msg.Params)
+	case "short-ids":
+		return s.handleShortIDs(msg.Params)
 	case "update":
 		return s.handleUpdate(msg.Params)
+	case "get":
+		return s.handleGet(msg.Params)
+	case "text":
+		return s.handleText(msg.Params)
+	case "edit":
+		return s.handleEdit(msg.Params)
+	case "convert":
+		return s.handleConvert(msg.Params)
+	case "rm":
+		return s.handleRemove(msg.Params)
+	case "trash":
+		return s.handleTrash(msg.Params)
+	case "archive":
+		return s.handleArchive(msg.Params, true)
+	case "unarchive":
+		return s.handleArchive(msg.Params, false)
 	case "tag":
 		return s.handleTag(msg.Params)
 	case "place":
 		return s.handlePlace(msg.Params)
 	case "date":
 		return s.handleDate(msg.Params)
+	case "view":
+		return s.handleView(msg.Params)
+	case "daemon-status":
+		return s.handleDaemonStatus()
+	case "shutdown":
+		return s.handleShutdown()
 	default:
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("unknown command: %s", msg.Command),
+			Code:    CodeMethodNotFound,
 		}
 	}
 }
@@ -137,15 +366,17 @@ func (s *Service) handleUpdate(params json.RawMessage) Response {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("error unmarshaling update params: %v", err),
+			Code:    CodeInvalidParams,
 		}
 	}
 
 	ctx := context.Background()
-	err = s.svc.UpdateTask(ctx, updateParams.ID, updateParams.Tags, updateParams.Places, updateParams.Due, updateParams.Text)
+	err = s.svc.UpdateNode(ctx, updateParams.ID, updateParams.Tags, updateParams.Places, updateParams.Due, updateParams.Text)
 	if err != nil {
 		return Response{
 			Success: false,
 			Error:   fmt.Sprintf("error updating task: %v", err),
+			Code:    errorCode(err),
 		}
 	}
 
@@ -161,6 +392,7 @@ func (s *Service) checkOverdueTasks() error {
 	}
 
 	log.Println("Checking for overdue tasks...")
+	s.setRunTime(&s.lastNotificationRun, time.Now())
 
 	ctx := context.Background()
 	overdueTasks, err := s.svc.GetOverdueTasks(ctx)
@@ -189,6 +421,8 @@ func (s *Service) checkOverdueTasks() error {
 
 		s.notifiedTaskIDs[task.ID] = true
 
+		s.events.publish(model.Event{Type: model.EventType.TaskOverdue, Node: &task})
+
 		dueDateStr := "unknown"
 		if task.DueDate != nil {
 			localDueDate := task.DueDate.In(time.Local)
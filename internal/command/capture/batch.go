@@ -0,0 +1,88 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+)
+
+// captureLines captures a node per line of path, or of standard input when path is empty or -,
+// with extra added to every line, and prints the nodes created.
+func (c *CaptureCommand) captureLines(ctx context.Context, path, extra string) error {
+	input, err := readInput(path)
+	if err != nil {
+		return err
+	}
+
+	var texts []string
+	for _, line := range strings.Split(input, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && extra != "" {
+			line += " " + extra
+		}
+		// Blank lines are kept, and skipped by the service, so errors give the line number
+		texts = append(texts, line)
+	}
+
+	var nodes []model.Node
+	err = common.Run(c.Svc, func(direct *svc.Svc) error {
+		var err error
+		nodes, err = direct.CaptureAll(ctx, "", texts)
+		return err
+	}, func() error {
+		return common.Send("capture-batch", bkg.CaptureBatchParams{Texts: texts}, &nodes)
+	})
+	if err != nil {
+		return err
+	}
+
+	printCaptured(c.Svc, nodes)
+	return nil
+}
+
+// readInput returns the contents of path, or of standard input when path is empty or -.
+func readInput(path string) (string, error) {
+	if path == "" || path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("error reading standard input: %w", err)
+		}
+		return string(data), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+func printCaptured(s *svc.Svc, nodes []model.Node) {
+	if len(nodes) == 0 {
+		fmt.Println("Nothing captured.")
+		return
+	}
+
+	noun := "nodes"
+	if len(nodes) == 1 {
+		noun = "node"
+	}
+	fmt.Printf("Captured %d %s:\n", len(nodes), noun)
+
+	short := common.ShortIDs(s, nodes)
+	idWidth := common.IDWidth(short)
+	for _, n := range nodes {
+		content := []rune(strings.Join(strings.Fields(n.Content), " "))
+		if len(content) > 50 {
+			content = append(content[:47], []rune("...")...)
+		}
+		fmt.Printf("  %-*s %-6s %s\n", idWidth, short[n.ID], n.Type, string(content))
+	}
+}
@@ -27,38 +27,94 @@ func NewCommand(svc *svc.Svc) *cobra.Command {
 		},
 	}
 
+	var edit, eachLine bool
+	var file string
+
 	cobraCmd := &cobra.Command{
 		Use:     "capture",
 		Aliases: []string{"cap", "c"},
 		Short:   "capture a new node",
-		Args:    cobra.ArbitraryArgs,
+		Long: `Capture a new node from the words given. With - as the first argument the node is read from
+standard input instead, and the words after it are added to it.
+
+With --each-line, every line of standard input becomes a node, and with --file every line of a
+file does. The words given are added to each line, so a shared #tag can be given once. Blank
+lines are skipped, and the nodes are stored together: if a line does not parse, none is.`,
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cobra *cobra.Command, args []string) error {
+			fromStdin := len(args) > 0 && args[0] == "-"
+			if fromStdin {
+				args = args[1:]
+			}
+
+			if edit && (fromStdin || eachLine || file != "") {
+				return fmt.Errorf("--edit cannot be combined with reading from standard input or a file")
+			}
+
+			if eachLine || file != "" {
+				return cmd.captureLines(cobra.Context(), file, strings.Join(args, " "))
+			}
+
+			if fromStdin {
+				text, err := readInput("-")
+				if err != nil {
+					return err
+				}
+				args = append([]string{text}, args...)
+			}
+
+			if edit {
+				text, err := editText(strings.Join(args, " "))
+				if err != nil {
+					return err
+				}
+				if text == "" {
+					fmt.Println("Nothing captured.")
+					return nil
+				}
+				args = []string{text}
+			}
+
 			flags := common.ExtractFlagsFromCommand(cobra)
 			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
 		},
 	}
 
+	cobraCmd.Flags().BoolVarP(&edit, "edit", "e", false, "write the node in $EDITOR, starting from the text given, if any")
+	cobraCmd.Flags().BoolVar(&eachLine, "each-line", false, "capture a node per line of standard input")
+	cobraCmd.Flags().StringVarP(&file, "file", "f", "", "capture a node per line of `FILE`, - for standard input")
+
 	cmd.CobraCmd = cobraCmd
 	return cobraCmd
 }
 
-func (c *CaptureCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
-	input := strings.Join(args, " ")
+// editText opens the capture template in the editor, with text on its first line, and returns
+// what was written without the comment lines.
+func editText(text string) (string, error) {
+	editor, err := common.NewEditor(text + svc.CaptureTemplate)
+	if err != nil {
+		return "", err
+	}
+	defer editor.Remove()
 
-	node, err := c.Svc.Parser(input)
+	edited, err := editor.Edit()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	node.GenID()
+	return strings.TrimSpace(svc.StripComments(edited)), nil
+}
 
-	log.Printf("Captured node: %s", node.ID)
+func (c *CaptureCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	input := strings.Join(args, " ")
 
-	err = c.Svc.Repo.Create(ctx, node)
+	node, err := c.Svc.Capture(input)
 	if err != nil {
 		return err
 	}
 
+	log.Printf("Captured node: %s", node.ID)
+
 	log.Printf("%+v", node)
 	return nil
 }
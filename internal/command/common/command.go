@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/model"
 	"github.com/adrianpk/tyn/internal/svc"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -20,6 +22,12 @@ type Command interface {
 	Name() string
 }
 
+var (
+	directMu  sync.Mutex
+	openSvc   func() (*svc.Svc, error)
+	directSvc *svc.Svc
+)
+
 type BaseCommand struct {
 	Svc         *svc.Svc
 	CobraCmd    *cobra.Command
@@ -38,7 +46,13 @@ func (b *BaseCommand) Execute(ctx context.Context, args []string, flags map[stri
 	directFn func(context.Context, []string, map[string]interface{}) error,
 	ipcFn func([]string, map[string]interface{}) error) error {
 
-	if b.Svc != nil {
+	s, err := DirectSvc(b.Svc)
+	if err != nil {
+		return err
+	}
+
+	if s != nil {
+		b.Svc = s
 		log.Printf("Executing command '%s' directly (service available)", b.CommandName)
 		return directFn(ctx, args, flags)
 	}
@@ -47,6 +61,122 @@ func (b *BaseCommand) Execute(ctx context.Context, args []string, flags map[stri
 	return ipcFn(args, flags)
 }
 
+// UseDirect makes commands work on the database themselves instead of going through the daemon.
+// open is called once, by the first command that needs the service.
+func UseDirect(open func() (*svc.Svc, error)) {
+	directMu.Lock()
+	defer directMu.Unlock()
+	openSvc = open
+}
+
+// IsDirect reports whether commands work on the database instead of going through the daemon.
+func IsDirect() bool {
+	directMu.Lock()
+	defer directMu.Unlock()
+	return openSvc != nil
+}
+
+// DirectSvc returns the service a command should use directly: s when it has one, otherwise the
+// one opened for direct mode. It returns nil when commands go through the daemon.
+func DirectSvc(s *svc.Svc) (*svc.Svc, error) {
+	if s != nil {
+		return s, nil
+	}
+
+	directMu.Lock()
+	defer directMu.Unlock()
+
+	if directSvc == nil && openSvc != nil {
+		opened, err := openSvc()
+		if err != nil {
+			return nil, err
+		}
+		directSvc = opened
+	}
+
+	return directSvc, nil
+}
+
+// Run calls direct with the service when commands work on the database, otherwise ipc.
+func Run(s *svc.Svc, direct func(*svc.Svc) error, ipc func() error) error {
+	d, err := DirectSvc(s)
+	if err != nil {
+		return err
+	}
+
+	if d != nil {
+		return direct(d)
+	}
+
+	return ipc()
+}
+
+// Send sends a command to the daemon and reads its result into result.
+func Send(command string, params, result interface{}) error {
+	resp, err := bkg.SendCommand(command, params)
+	if err != nil {
+		return fmt.Errorf("error communicating with daemon: %w", err)
+	}
+
+	return UnmarshalResponse(resp, result)
+}
+
+// ShortIDs returns the shortest prefix that identifies each of nodes, asking s, the direct service
+// or the daemon. If none can tell, the IDs are cut to model.MinShortID characters.
+func ShortIDs(s *svc.Svc, nodes []model.Node) map[string]string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+
+	short, err := shortIDs(s, ids)
+	if err != nil {
+		log.Printf("Error getting short IDs: %v", err)
+		short = make(map[string]string, len(nodes))
+		for _, n := range nodes {
+			short[n.ID] = n.ShortID()
+		}
+	}
+
+	return short
+}
+
+func shortIDs(s *svc.Svc, ids []string) (map[string]string, error) {
+	if len(ids) == 0 {
+		return map[string]string{}, nil
+	}
+
+	direct, err := DirectSvc(s)
+	if err != nil {
+		return nil, err
+	}
+	if direct != nil {
+		return direct.ShortIDs(context.Background(), ids)
+	}
+
+	resp, err := bkg.SendCommand("short-ids", bkg.ShortIDsParams{IDs: ids})
+	if err != nil {
+		return nil, fmt.Errorf("error communicating with daemon: %w", err)
+	}
+
+	var short map[string]string
+	err = UnmarshalResponse(resp, &short)
+	if err != nil {
+		return nil, err
+	}
+
+	return short, nil
+}
+
+// IDWidth is the width of an ID column holding short, at least as wide as its header.
+func IDWidth(short map[string]string) int {
+	width := 6
+	for _, id := range short {
+		width = max(width, len(id)+1)
+	}
+	return width
+}
+
 func SendToIPC(commandName string, params interface{}) error {
 	resp, err := bkg.SendCommand(commandName, params)
 	if err != nil {
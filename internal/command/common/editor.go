@@ -0,0 +1,69 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultEditor is used when neither VISUAL nor EDITOR is set.
+const defaultEditor = "vi"
+
+// Editor is a text opened in the user's editor, kept in a temporary file until Remove.
+type Editor struct {
+	Path string
+}
+
+// NewEditor writes text to a temporary file for editing.
+func NewEditor(text string) (*Editor, error) {
+	f, err := os.CreateTemp("", "tyn-*.md")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(text)
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("error writing temp file: %w", err)
+	}
+
+	return &Editor{Path: f.Name()}, nil
+}
+
+// Edit opens the file in $VISUAL or $EDITOR, falling back to vi, waits for the editor to exit
+// and returns the text it saved.
+func (e *Editor) Edit() (string, error) {
+	editor := os.Getenv("VISUAL")
+	if strings.TrimSpace(editor) == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if strings.TrimSpace(editor) == "" {
+		editor = defaultEditor
+	}
+
+	// The editor may come with arguments, as in EDITOR="code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], e.Path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("error running editor %s: %w", args[0], err)
+	}
+
+	text, err := os.ReadFile(e.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading temp file: %w", err)
+	}
+
+	return string(text), nil
+}
+
+// Remove deletes the temporary file.
+func (e *Editor) Remove() {
+	os.Remove(e.Path)
+}
@@ -0,0 +1,266 @@
+package common
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+)
+
+// Output formats of listings, chosen with --output.
+const (
+	OutputTable = "table"
+	OutputPlain = "plain"
+	OutputJSON  = "json"
+	OutputJSONL = "jsonl"
+	OutputCSV   = "csv"
+)
+
+var outputFormats = []string{OutputTable, OutputPlain, OutputJSON, OutputJSONL, OutputCSV}
+
+// output is the format chosen for this run, and the --format template if one was given.
+var output = struct {
+	format string
+	tmpl   *template.Template
+}{format: OutputTable}
+
+// templateFuncs are available to --format templates, as in {{join .Tags ","}} or {{date .DueDate}}.
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"date": formatDate,
+}
+
+// UseOutput sets how listings are printed: format is one of the Output constants, and tmpl a
+// text/template run for each node instead, if it is not empty.
+func UseOutput(format, tmpl string) error {
+	valid := false
+	for _, f := range outputFormats {
+		valid = valid || f == format
+	}
+	if !valid {
+		return fmt.Errorf("invalid output '%s', valid outputs are: %s", format, strings.Join(outputFormats, ", "))
+	}
+
+	output.format = format
+	output.tmpl = nil
+
+	if tmpl == "" {
+		return nil
+	}
+
+	if format != OutputTable && format != OutputPlain {
+		return fmt.Errorf("--format prints text, it cannot be combined with --output %s", format)
+	}
+
+	t, err := template.New("format").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid --format template: %w", err)
+	}
+
+	output.tmpl = t
+	return nil
+}
+
+// IsTable reports whether listings are printed as tables for people, the default.
+func IsTable() bool {
+	return output.format == OutputTable && output.tmpl == nil
+}
+
+// record is a node as --format templates see it: the fields of model.Node, the short ID it is
+// listed with and, for search results, the snippet and rank.
+type record struct {
+	model.Node
+	ShortID string
+	Snippet string
+	Rank    float64
+}
+
+// jsonRecord is a node in JSON and JSONL output, in the snake_case form of tn export.
+type jsonRecord struct {
+	model.ExportNode
+	Snippet string  `json:"snippet,omitempty"`
+	Rank    float64 `json:"rank,omitempty"`
+}
+
+// PrintList prints nodes in the chosen output, calling table for the default table.
+func PrintList(s *svc.Svc, nodes []model.Node, table func()) error {
+	if IsTable() {
+		table()
+		return nil
+	}
+
+	return printRecords(os.Stdout, records(s, nodes), false, false)
+}
+
+// PrintOne prints a single node in the chosen output, calling table for the default. JSON
+// output is an object rather than an array.
+func PrintOne(s *svc.Svc, node model.Node, table func()) error {
+	if IsTable() {
+		table()
+		return nil
+	}
+
+	return printRecords(os.Stdout, records(s, []model.Node{node}), true, false)
+}
+
+// PrintResults prints search results in the chosen output, calling table for the default.
+func PrintResults(s *svc.Svc, results []model.SearchResult, table func()) error {
+	if IsTable() {
+		table()
+		return nil
+	}
+
+	nodes := make([]model.Node, len(results))
+	for i, r := range results {
+		nodes[i] = r.Node
+	}
+
+	recs := records(s, nodes)
+	for i, r := range results {
+		recs[i].Snippet = PlainSnippet(r.Snippet)
+		recs[i].Rank = r.Rank
+	}
+
+	return printRecords(os.Stdout, recs, false, true)
+}
+
+// PlainSnippet removes the highlight markers from a search snippet.
+func PlainSnippet(snippet string) string {
+	return strings.NewReplacer(model.HighlightStart, "", model.HighlightEnd, "").Replace(snippet)
+}
+
+// records wraps nodes for printing. Short IDs are only looked up when a template may use them.
+func records(s *svc.Svc, nodes []model.Node) []record {
+	var short map[string]string
+	if output.tmpl != nil {
+		short = ShortIDs(s, nodes)
+	}
+
+	recs := make([]record, len(nodes))
+	for i, n := range nodes {
+		recs[i] = record{Node: n, ShortID: short[n.ID]}
+	}
+	return recs
+}
+
+func printRecords(w io.Writer, recs []record, single, search bool) error {
+	switch {
+	case output.tmpl != nil:
+		for _, r := range recs {
+			err := output.tmpl.Execute(w, r)
+			if err != nil {
+				return fmt.Errorf("error running --format template: %w", err)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+
+	case output.format == OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if single {
+			return enc.Encode(newJSONRecord(recs[0]))
+		}
+
+		list := make([]jsonRecord, len(recs))
+		for i, r := range recs {
+			list[i] = newJSONRecord(r)
+		}
+		return enc.Encode(list)
+
+	case output.format == OutputJSONL:
+		enc := json.NewEncoder(w)
+		for _, r := range recs {
+			err := enc.Encode(newJSONRecord(r))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case output.format == OutputCSV:
+		return printCSV(w, recs, search)
+
+	default:
+		for _, r := range recs {
+			fmt.Fprintln(w, strings.Join(plainFields(r, search), "\t"))
+		}
+		return nil
+	}
+}
+
+func newJSONRecord(r record) jsonRecord {
+	return jsonRecord{ExportNode: model.NewExportNode(r.Node), Snippet: r.Snippet, Rank: r.Rank}
+}
+
+// printCSV writes a header and a row per node. Tags and places are separated by spaces.
+func printCSV(out io.Writer, recs []record, search bool) error {
+	w := csv.NewWriter(out)
+
+	header := []string{"id", "type", "status", "priority", "content", "tags", "places", "due_date", "date", "link", "parent_id"}
+	if search {
+		header = append(header, "snippet", "rank")
+	}
+
+	err := w.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range recs {
+		row := []string{
+			r.ID, r.Type, r.Status, strconv.Itoa(r.Priority), r.Content,
+			strings.Join(r.Tags, " "), strings.Join(r.Places, " "),
+			formatDate(r.DueDate), formatDate(r.Date), r.Link, r.ParentID,
+		}
+		if search {
+			row = append(row, r.Snippet, strconv.FormatFloat(r.Rank, 'f', -1, 64))
+		}
+
+		err = w.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// plainFields are the tab separated fields of a node in plain output: the full ID, type, status,
+// due date, content on one line and labels, or the snippet for search results.
+func plainFields(r record, search bool) []string {
+	text := r.Content
+	if search {
+		text = r.Snippet
+	}
+
+	return []string{
+		r.ID, r.Type, r.Status, formatDate(r.DueDate),
+		strings.Join(strings.Fields(text), " "),
+		FormatLabels(r.Node),
+	}
+}
+
+// formatDate formats a time.Time or *time.Time in local time, RFC 3339, and nil as an empty string.
+func formatDate(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Local().Format(time.RFC3339)
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return t.Local().Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,242 @@
+package common
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// useOutput sets the output for a test and restores the default table afterwards.
+func useOutput(t *testing.T, format, tmpl string) {
+	t.Helper()
+
+	err := UseOutput(format, tmpl)
+	if err != nil {
+		t.Fatalf("UseOutput(%q, %q) error = %v", format, tmpl, err)
+	}
+	t.Cleanup(func() { UseOutput(OutputTable, "") })
+}
+
+func sampleRecords() []record {
+	date := time.Date(2025, 7, 1, 9, 30, 0, 0, time.UTC)
+	due := date.Add(48 * time.Hour)
+
+	return []record{
+		{Node: model.Node{ID: "a1", Type: model.Type.Task, Status: model.Status.Todo, Priority: 2,
+			Content: `Call "Ann", then Bob`, Tags: []string{"work", "phone"}, Places: []string{"office"},
+			Date: date, DueDate: &due}, ShortID: "a"},
+		{Node: model.Node{ID: "b2", Type: model.Type.Link, Content: "Read\nlater", Link: "https://example.com",
+			Date: date, ParentID: "a1"}, ShortID: "b"},
+	}
+}
+
+func TestUseOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		tmpl      string
+		wantErr   bool
+		wantTable bool
+	}{
+		{name: "table", format: OutputTable, wantTable: true},
+		{name: "plain", format: OutputPlain},
+		{name: "json", format: OutputJSON},
+		{name: "jsonl", format: OutputJSONL},
+		{name: "csv", format: OutputCSV},
+		{name: "template on table", format: OutputTable, tmpl: "{{.ShortID}}"},
+		{name: "template on plain", format: OutputPlain, tmpl: "{{.ShortID}}"},
+		{name: "unknown format", format: "yaml", wantErr: true},
+		{name: "empty format", format: "", wantErr: true},
+		{name: "template with json", format: OutputJSON, tmpl: "{{.ID}}", wantErr: true},
+		{name: "template with csv", format: OutputCSV, tmpl: "{{.ID}}", wantErr: true},
+		{name: "unparsable template", format: OutputTable, tmpl: "{{.ID", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { UseOutput(OutputTable, "") })
+
+			err := UseOutput(tt.format, tt.tmpl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if IsTable() != tt.wantTable {
+				t.Errorf("IsTable() = %v, want %v", IsTable(), tt.wantTable)
+			}
+		})
+	}
+}
+
+func TestPrintRecords(t *testing.T) {
+	recs := sampleRecords()
+	date, due := formatDate(recs[0].Date), formatDate(recs[0].DueDate)
+
+	tests := []struct {
+		name   string
+		format string
+		tmpl   string
+		want   string
+	}{
+		{
+			name:   "plain",
+			format: OutputPlain,
+			want: "a1\ttask\ttodo\t" + due + "\tCall \"Ann\", then Bob\t#work #phone @office\n" +
+				"b2\tlink\t\t\tRead later\t\n",
+		},
+		{
+			name:   "template",
+			format: OutputTable,
+			tmpl:   `{{.ShortID}} {{join .Tags ","}} {{date .DueDate}}`,
+			want:   "a work,phone " + due + "\nb  \n",
+		},
+		{
+			name:   "csv",
+			format: OutputCSV,
+			want: "id,type,status,priority,content,tags,places,due_date,date,link,parent_id\n" +
+				`a1,task,todo,2,"Call ""Ann"", then Bob",work phone,office,` + due + "," + date + ",,\n" +
+				"b2,link,,0,\"Read\nlater\",,,," + date + ",https://example.com,a1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useOutput(t, tt.format, tt.tmpl)
+
+			var buf bytes.Buffer
+			err := printRecords(&buf, recs, false, false)
+			if err != nil {
+				t.Fatalf("printRecords() error = %v", err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printRecords() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintRecordsCSVRoundTrip(t *testing.T) {
+	useOutput(t, OutputCSV, "")
+
+	recs := sampleRecords()
+	recs[0].Snippet, recs[0].Rank = "call, ann", 1.5
+
+	var buf bytes.Buffer
+	err := printRecords(&buf, recs, false, true)
+	if err != nil {
+		t.Fatalf("printRecords() error = %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("error reading CSV output: %v", err)
+	}
+
+	if len(rows) != len(recs)+1 {
+		t.Fatalf("CSV output has %d rows, want a header and %d records", len(rows), len(recs))
+	}
+
+	header := strings.Join(rows[0], ",")
+	if header != "id,type,status,priority,content,tags,places,due_date,date,link,parent_id,snippet,rank" {
+		t.Errorf("CSV header = %s", header)
+	}
+
+	for i, r := range recs {
+		row := rows[i+1]
+		if row[0] != r.ID || row[4] != r.Content {
+			t.Errorf("CSV row %d = id %q content %q, want %q %q", i, row[0], row[4], r.ID, r.Content)
+		}
+	}
+	if rows[1][11] != "call, ann" || rows[1][12] != "1.5" {
+		t.Errorf("CSV snippet and rank = %q %q, want %q %q", rows[1][11], rows[1][12], "call, ann", "1.5")
+	}
+}
+
+func TestPrintRecordsJSON(t *testing.T) {
+	recs := sampleRecords()
+
+	tests := []struct {
+		name   string
+		format string
+		single bool
+		lines  int
+	}{
+		{name: "json list", format: OutputJSON},
+		{name: "json single", format: OutputJSON, single: true},
+		{name: "jsonl", format: OutputJSONL, lines: len(recs)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useOutput(t, tt.format, "")
+
+			var buf bytes.Buffer
+			err := printRecords(&buf, recs, tt.single, false)
+			if err != nil {
+				t.Fatalf("printRecords() error = %v", err)
+			}
+
+			var got []map[string]interface{}
+			switch {
+			case tt.lines > 0:
+				lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+				if len(lines) != tt.lines {
+					t.Fatalf("jsonl output has %d lines, want %d", len(lines), tt.lines)
+				}
+				for _, line := range lines {
+					var obj map[string]interface{}
+					err = json.Unmarshal([]byte(line), &obj)
+					if err != nil {
+						t.Fatalf("error reading jsonl line %q: %v", line, err)
+					}
+					got = append(got, obj)
+				}
+			case tt.single:
+				var obj map[string]interface{}
+				err = json.Unmarshal(buf.Bytes(), &obj)
+				got = append(got, obj)
+			default:
+				err = json.Unmarshal(buf.Bytes(), &got)
+			}
+			if err != nil {
+				t.Fatalf("error reading output %s: %v", buf.String(), err)
+			}
+
+			want := len(recs)
+			if tt.single {
+				want = 1
+			}
+			if len(got) != want {
+				t.Fatalf("output has %d records, want %d", len(got), want)
+			}
+
+			for i, obj := range got {
+				if obj["id"] != recs[i].ID || obj["content"] != recs[i].Content {
+					t.Errorf("record %d = %v, want id %q content %q", i, obj, recs[i].ID, recs[i].Content)
+				}
+			}
+			if _, ok := got[0]["due_date"]; !ok {
+				t.Errorf("record 0 = %v, want the snake_case due_date of tn export", got[0])
+			}
+		})
+	}
+}
+
+func TestPrintRecordsTemplateError(t *testing.T) {
+	useOutput(t, OutputPlain, "{{.Missing}}")
+
+	var buf bytes.Buffer
+	err := printRecords(&buf, sampleRecords(), false, false)
+	if err == nil || !strings.Contains(err.Error(), "--format template") {
+		t.Errorf("printRecords() error = %v, want a --format template error", err)
+	}
+}
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+)
+
+// PrintNodes prints nodes of any type as a table, tasks with their status.
+func PrintNodes(s *svc.Svc, nodes []model.Node) {
+	if len(nodes) == 0 {
+		fmt.Println("No nodes found.")
+		return
+	}
+
+	short := ShortIDs(s, nodes)
+	idWidth := IDWidth(short)
+
+	// The content column takes what the other columns leave of the terminal, keeping 20 for labels
+	contentWidth := ColumnWidth(idWidth+30, 20, 50)
+
+	fmt.Printf("%-*s %-6s %-*s %s\n", idWidth, "ID", "TYPE", contentWidth, "CONTENT", "TAGS/PLACES")
+	fmt.Println(strings.Repeat("-", idWidth+30+contentWidth))
+
+	for _, node := range nodes {
+		content := strings.Join(strings.Fields(node.Content), " ")
+		if node.Type == model.Type.Task {
+			content = "[" + node.Status + "] " + content
+		}
+
+		fmt.Printf("%-*s %-6s %-*s %s\n", idWidth, short[node.ID], node.Type, contentWidth, Truncate(content, contentWidth), FormatLabels(node))
+	}
+}
+
+// FormatLabels returns the tags and places of node as #tag and @place words.
+func FormatLabels(node model.Node) string {
+	var labels []string
+	for _, tag := range node.Tags {
+		labels = append(labels, "#"+tag)
+	}
+	for _, place := range node.Places {
+		labels = append(labels, "@"+place)
+	}
+
+	return strings.Join(labels, " ")
+}
+
+// Truncate shortens s to width runes, marking the cut with an ellipsis when there is room for one.
+func Truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// TerminalWidth returns the number of columns of the terminal stdout writes to, or of $COLUMNS
+// when stdout is not a terminal. It is 0 when neither tells.
+func TerminalWidth() int {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err == nil && ws.Col > 0 {
+		return int(ws.Col)
+	}
+
+	cols, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err == nil && cols > 0 {
+		return cols
+	}
+
+	return 0
+}
+
+// ColumnWidth is the width left for a column that takes up the rest of a terminal line after
+// used columns, at least min, or fallback when the terminal width is unknown.
+func ColumnWidth(used, min, fallback int) int {
+	width := TerminalWidth()
+	if width == 0 {
+		return fallback
+	}
+	return max(width-used, min)
+}
+
+// Color reports whether output may be colored: stdout is a terminal, tables are printed, and
+// neither NO_COLOR (https://no-color.org) nor TERM=dumb ask for plain text.
+func Color() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTable() && IsTerminal(os.Stdout)
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,293 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/spf13/cobra"
+)
+
+const followInterval = 250 * time.Millisecond
+
+type DaemonLogsCommand struct {
+	CobraCmd *cobra.Command
+	lines    int
+	follow   bool
+}
+
+// NewCommand returns the daemon command group, which manages the background service
+// other commands talk to. None of its subcommands start the daemon implicitly.
+func NewCommand() *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Manage the background service",
+		Long:  "Start, stop and inspect the background service that stores captures, generates the journal and sends notifications",
+	}
+
+	cobraCmd.AddCommand(newStartCommand())
+	cobraCmd.AddCommand(newStopCommand())
+	cobraCmd.AddCommand(newRestartCommand())
+	cobraCmd.AddCommand(newStatusCommand())
+	cobraCmd.AddCommand(newLogsCommand())
+
+	return cobraCmd
+}
+
+func newStartCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if status, err := bkg.GetDaemonStatus(); err == nil {
+				fmt.Printf("Daemon is already running (PID %d).\n", status.PID)
+				return nil
+			}
+
+			return start()
+		},
+	}
+}
+
+func newStopCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon",
+		Long:  "Ask the daemon to shut down, and terminate it if it does not exit in time",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return stop()
+		},
+	}
+}
+
+func newRestartCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restart",
+		Short: "Restart the daemon",
+		Long:  "Stop the daemon if it is running and start it again, for example after upgrading tn or changing the configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := stop()
+			if err != nil {
+				return err
+			}
+
+			return start()
+		},
+	}
+}
+
+func newStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the daemon is running and what it has been doing",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := bkg.GetDaemonStatus()
+			if err != nil {
+				running, _ := bkg.IsDaemonRunning()
+				if running {
+					return fmt.Errorf("daemon process is running but not responding: %w", err)
+				}
+
+				fmt.Println("Daemon is not running.")
+				return nil
+			}
+
+			printStatus(status)
+			return nil
+		},
+	}
+}
+
+func newLogsCommand() *cobra.Command {
+	cmd := &DaemonLogsCommand{}
+
+	cobraCmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show the daemon log",
+		Long:  "Print the last lines of the daemon log, and keep printing new lines with --follow",
+		Args:  cobra.NoArgs,
+		RunE: func(cobra *cobra.Command, args []string) error {
+			return cmd.run()
+		},
+	}
+
+	cobraCmd.Flags().IntVarP(&cmd.lines, "lines", "n", 20, "number of lines to show")
+	cobraCmd.Flags().BoolVarP(&cmd.follow, "follow", "f", false, "keep printing lines as they are written")
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *DaemonLogsCommand) run() error {
+	logFile, err := bkg.LogFilePath()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(logFile)
+	if os.IsNotExist(err) && !c.follow {
+		fmt.Printf("No daemon log at %s yet.\n", logFile)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening daemon log: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("error reading daemon log: %w", err)
+	}
+
+	fmt.Print(lastLines(string(data), c.lines))
+
+	if !c.follow {
+		return nil
+	}
+
+	return follow(f, int64(len(data)))
+}
+
+// follow prints what is appended to f from offset on, until interrupted. A log that shrinks
+// was truncated or replaced, so it is read again from the start.
+func follow(f *os.File, offset int64) error {
+	buf := make([]byte, 32*1024)
+	for {
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("error reading daemon log: %w", err)
+		}
+
+		if info.Size() < offset {
+			offset = 0
+		}
+
+		for info.Size() > offset {
+			n, err := f.ReadAt(buf, offset)
+			if n > 0 {
+				os.Stdout.Write(buf[:n])
+				offset += int64(n)
+			}
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("error reading daemon log: %w", err)
+			}
+			if n == 0 {
+				break
+			}
+		}
+
+		time.Sleep(followInterval)
+	}
+}
+
+func start() error {
+	pid, err := bkg.StartDaemon()
+	if err != nil {
+		return fmt.Errorf("error starting daemon: %w", err)
+	}
+
+	fmt.Printf("Daemon started (PID %d).\n", pid)
+	return nil
+}
+
+func stop() error {
+	stopped, err := bkg.StopDaemon()
+	if err != nil {
+		return err
+	}
+
+	if stopped {
+		fmt.Println("Daemon stopped.")
+	} else {
+		fmt.Println("Daemon is not running.")
+	}
+	return nil
+}
+
+func printStatus(status bkg.DaemonStatus) {
+	fmt.Println("Daemon is running.")
+	fmt.Println()
+	fmt.Printf("%-20s %d\n", "PID:", status.PID)
+	fmt.Printf("%-20s %s (since %s)\n", "Uptime:", formatDuration(time.Since(status.StartedAt)), formatTime(status.StartedAt))
+	fmt.Printf("%-20s %s (IPC protocol %d)\n", "Version:", status.Version, status.ProtocolVersion)
+	fmt.Printf("%-20s %s\n", "Database:", status.DBPath)
+	fmt.Printf("%-20s %s\n", "Log:", status.LogPath)
+	fmt.Printf("%-20s %s\n", "Poll interval:", status.PollInterval)
+	fmt.Printf("%-20s %s\n", "HTTP API:", formatHTTPAddress(status.HTTPAddress))
+	fmt.Printf("%-20s %s\n", "Last journal:", formatRun(status.LastJournal))
+	fmt.Printf("%-20s %s\n", "Last notifications:", formatRun(status.LastNotificationRun))
+	fmt.Printf("%-20s %s\n", "Last backup:", formatRun(status.LastBackup))
+}
+
+func formatHTTPAddress(addr string) string {
+	if addr == "" {
+		return "off"
+	}
+	return "http://" + addr + "/api/v1"
+}
+
+func formatRun(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return fmt.Sprintf("%s (%s ago)", formatTime(t), formatDuration(time.Since(t)))
+}
+
+func formatTime(t time.Time) string {
+	return t.In(time.Local).Format("2006-01-02 15:04:05")
+}
+
+// formatDuration rounds d to its two largest units, such as 3d 4h or 5m 12s.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	}
+
+	var parts []string
+	for _, u := range units {
+		if d >= u.size {
+			parts = append(parts, fmt.Sprintf("%d%s", d/u.size, u.name))
+			d %= u.size
+		}
+		if len(parts) == 2 {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return "0s"
+	}
+	return strings.Join(parts, " ")
+}
+
+func lastLines(s string, n int) string {
+	if n <= 0 || s == "" {
+		return ""
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return strings.Join(lines, "")
+}
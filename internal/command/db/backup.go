@@ -0,0 +1,147 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/adrianpk/tyn/internal/repo/sqlite"
+	"github.com/spf13/cobra"
+)
+
+type DBBackupCommand struct {
+	CobraCmd *cobra.Command
+	cfg      *config.Config
+	output   string
+}
+
+type DBRestoreCommand struct {
+	CobraCmd *cobra.Command
+	cfg      *config.Config
+}
+
+func newBackupCommand(cfg *config.Config) *cobra.Command {
+	cmd := &DBBackupCommand{cfg: cfg}
+
+	cobraCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the database",
+		Long:  "Write a consistent copy of the database to the backup directory, or to a file of your choice with --output. Manual backups are never rotated away.",
+		Args:  cobra.NoArgs,
+		RunE: func(cobra *cobra.Command, args []string) error {
+			return cmd.run(cobra)
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&cmd.output, "output", "o", "", "write the backup to this file instead of the backup directory")
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *DBBackupCommand) run(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+
+	if c.output != "" {
+		err := sqlite.WriteBackup(ctx, c.output)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Database backed up to %s\n", c.output)
+		return nil
+	}
+
+	backup, err := sqlite.CreateBackup(ctx, c.cfg, sqlite.BackupManual)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database backed up to %s\n", backup.Path)
+	return nil
+}
+
+func newBackupsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "backups",
+		Short: "List database backups",
+		Long:  "List the backups in the backup directory, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backups, err := sqlite.ListBackups()
+			if err != nil {
+				return err
+			}
+
+			if len(backups) == 0 {
+				fmt.Printf("No backups in %s\n", sqlite.BackupDir())
+				return nil
+			}
+
+			fmt.Printf("Backups in %s:\n\n", sqlite.BackupDir())
+			fmt.Printf("%-20s %-14s %10s  %s\n", "CREATED", "REASON", "SIZE", "NAME")
+			fmt.Println("----------------------------------------------------------------------")
+			for _, b := range backups {
+				fmt.Printf("%-20s %-14s %10s  %s\n",
+					b.CreatedAt.Format("2006-01-02 15:04:05"), b.Reason, formatSize(b.Size), b.Name)
+			}
+
+			return nil
+		},
+	}
+}
+
+func newRestoreCommand(cfg *config.Config) *cobra.Command {
+	cmd := &DBRestoreCommand{cfg: cfg}
+
+	cobraCmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore the database from a backup",
+		Long:  "Replace the database with a backup, given as a file path or as a name listed by 'tn db backups'. The daemon is stopped first and the current database is backed up before it is replaced.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			return cmd.run(cobra, args[0])
+		},
+	}
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *DBRestoreCommand) run(cmd *cobra.Command, nameOrPath string) error {
+	path, err := sqlite.ResolveBackup(nameOrPath)
+	if err != nil {
+		return err
+	}
+
+	stopped, err := bkg.StopDaemon()
+	if err != nil {
+		return fmt.Errorf("error stopping daemon before restore: %w", err)
+	}
+	if stopped {
+		fmt.Println("Stopped the daemon.")
+	}
+
+	previous, err := sqlite.RestoreBackup(cmd.Context(), c.cfg, path)
+	if previous.Path != "" {
+		fmt.Printf("Previous database backed up to %s\n", previous.Path)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database restored from %s\n", path)
+	fmt.Println("The daemon will start again with the next tn command.")
+	return nil
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/adrianpk/tyn/internal/repo/sqlite"
+	"github.com/spf13/cobra"
+)
+
+type DBMigrateCommand struct {
+	CobraCmd *cobra.Command
+	cfg      *config.Config
+	status   bool
+	dryRun   bool
+}
+
+// NewCommand returns the db command group. Its subcommands work on the database file
+// directly and do not go through the daemon.
+func NewCommand(cfg *config.Config) *cobra.Command {
+	cobraCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the tyn database",
+		Long:  "Inspect and maintain the SQLite database that stores your nodes",
+	}
+
+	cobraCmd.AddCommand(newMigrateCommand(cfg))
+	cobraCmd.AddCommand(newBackupCommand(cfg))
+	cobraCmd.AddCommand(newBackupsCommand())
+	cobraCmd.AddCommand(newRestoreCommand(cfg))
+
+	return cobraCmd
+}
+
+func newMigrateCommand(cfg *config.Config) *cobra.Command {
+	cmd := &DBMigrateCommand{cfg: cfg}
+
+	cobraCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending schema migrations",
+		Long:  "Apply pending schema migrations, or show the migration status with --status and the pending migrations with --dry-run",
+		Args:  cobra.NoArgs,
+		RunE: func(cobra *cobra.Command, args []string) error {
+			return cmd.run(cobra)
+		},
+	}
+
+	cobraCmd.Flags().BoolVar(&cmd.status, "status", false, "show applied and pending migrations")
+	cobraCmd.Flags().BoolVar(&cmd.dryRun, "dry-run", false, "show the migrations that would be applied without applying them")
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *DBMigrateCommand) run(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+
+	migrator, err := sqlite.OpenMigrator(c.cfg, c.status || c.dryRun)
+	if err != nil {
+		return fmt.Errorf("error opening database: %w", err)
+	}
+	defer migrator.Close()
+
+	if c.status {
+		return printStatus(cmd, migrator)
+	}
+
+	if c.dryRun {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(pending) == 0 {
+			fmt.Printf("Database is up to date (version %d).\n", sqlite.LatestSchemaVersion())
+			return nil
+		}
+
+		fmt.Printf("%d migration(s) would be applied:\n", len(pending))
+		for _, m := range pending {
+			fmt.Printf("  %3d  %s\n", m.Version, m.Name)
+		}
+		return nil
+	}
+
+	applied, err := migrator.Migrate(ctx)
+	for _, m := range applied {
+		fmt.Printf("Applied migration %d: %s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Printf("Database is up to date (version %d).\n", sqlite.LatestSchemaVersion())
+	}
+
+	return nil
+}
+
+func printStatus(cmd *cobra.Command, migrator *sqlite.Migrator) error {
+	ctx := cmd.Context()
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	states, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Schema version: %d (latest: %d)\n", version, sqlite.LatestSchemaVersion())
+	if version > sqlite.LatestSchemaVersion() {
+		fmt.Println("Warning: the database was migrated by a newer version of tyn")
+	}
+	fmt.Println()
+
+	fmt.Printf("%-8s %-10s %-20s %s\n", "VERSION", "STATE", "APPLIED", "NAME")
+	fmt.Println("----------------------------------------------------------------------")
+	for _, s := range states {
+		state, appliedAt := "pending", ""
+		if s.Applied() {
+			state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-8d %-10s %-20s %s\n", s.Version, state, appliedAt, s.Name)
+	}
+
+	return nil
+}
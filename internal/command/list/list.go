@@ -18,6 +18,7 @@ type ListCommand struct {
 	tagFilter    string
 	placeFilter  string
 	statusFilter string
+	archived     bool
 }
 
 func NewCommand(svc *svc.Svc) *cobra.Command {
@@ -32,7 +33,20 @@ func NewCommand(svc *svc.Svc) *cobra.Command {
 		Use:     "list",
 		Aliases: []string{"ls", "l"},
 		Short:   "list all nodes or filter by type (note, task, link), tag, or place",
-		Args:    cobra.MaximumNArgs(1),
+		Long: `List all nodes, or the ones of a type (note, task, link, draft) given as the only argument.
+Otherwise the arguments are a query:
+
+  tn list "type:task and (#work or #ops) and not :done and due<+7d sort:due"
+
+Terms are #tag, @place, :status, !priority, type:, tag:, place:, status: and priority:,
+due and created compared with <, <=, >, >= or : to a date such as 2025-07-04, today or +7d,
+due:none and due:any, and words or "quoted text" the content contains. Terms next to each
+other must all hold; and, or, not and parentheses combine them. sort:field[,field] orders by
+due, created, priority, status, type or text, descending with a leading -.
+
+Several tags must all match: "#a #b" lists the nodes tagged both a and b. Earlier versions
+listed the nodes with either tag; use "#a or #b", or --tag a,b, for that.`,
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cobra *cobra.Command, args []string) error {
 			flags := common.ExtractFlagsFromCommand(cobra)
 			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
@@ -42,51 +56,39 @@ func NewCommand(svc *svc.Svc) *cobra.Command {
 	cobraCmd.Flags().StringVarP(&cmd.tagFilter, "tag", "t", "", "filter by tag")
 	cobraCmd.Flags().StringVarP(&cmd.placeFilter, "place", "p", "", "filter by place")
 	cobraCmd.Flags().StringVarP(&cmd.statusFilter, "status", "s", "", "filter by status")
+	cobraCmd.Flags().BoolVar(&cmd.archived, "archived", false, "list archived nodes instead")
 
 	cmd.CobraCmd = cobraCmd
 	return cobraCmd
 }
 
 func (c *ListCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
-	var filterType string
-	if len(args) == 1 {
-		filterType = args[0]
-	}
+	filter := c.filter(args)
 
-	nodes, err := c.Svc.Repo.List(ctx)
+	var err error
+	filter.Query, err = svc.ParseQuery(query(args))
 	if err != nil {
 		return err
 	}
 
-	for _, node := range nodes {
-		if matchesFilters(node, filterType, c.tagFilter, c.placeFilter, c.statusFilter) {
-			fmt.Println(printNode(node))
-		}
+	nodes, err := c.Svc.List(filter)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return c.print(nodes)
 }
 
 func (c *ListCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
-	var filterType string
-	if len(args) == 1 {
-		filterType = args[0]
-	}
+	filter := c.filter(args)
 
 	params := bkg.ListParams{
-		Type: filterType,
-	}
-
-	if c.tagFilter != "" {
-		params.Tags = strings.Split(c.tagFilter, ",")
-	}
-
-	if c.placeFilter != "" {
-		params.Places = strings.Split(c.placeFilter, ",")
-	}
-
-	if c.statusFilter != "" {
-		params.Status = c.statusFilter
+		Type:     filter.Type,
+		Tags:     filter.Tags,
+		Places:   filter.Places,
+		Status:   filter.Status,
+		Archived: filter.Archived,
+		Query:    query(args),
 	}
 
 	resp, err := bkg.SendCommand("list", params)
@@ -104,70 +106,45 @@ func (c *ListCommand) ExecuteViaIPC(args []string, flags map[string]interface{})
 		return fmt.Errorf("error parsing response: %w", err)
 	}
 
-	for _, node := range nodes {
-		fmt.Println(printNode(node))
-	}
-
-	return nil
+	return c.print(nodes)
 }
 
-func hasTag(node model.Node, tag string) bool {
-	for _, t := range node.Tags {
-		if t == tag {
-			return true
-		}
-	}
-	return false
+func (c *ListCommand) print(nodes []model.Node) error {
+	return common.PrintList(c.Svc, nodes, func() {
+		common.PrintNodes(c.Svc, nodes)
+	})
 }
 
-func hasPlace(node model.Node, place string) bool {
-	for _, p := range node.Places {
-		if p == place {
-			return true
-		}
+// filter builds the list filter from the type argument and the flags.
+// Tag and place flags accept comma separated values; a node matches if it has any of them.
+func (c *ListCommand) filter(args []string) model.Filter {
+	var filter model.Filter
+	if isType(args) {
+		filter.Type = args[0]
 	}
-	return false
-}
 
-func matchesFilters(node model.Node, filterType, tagFilter, placeFilter, statusFilter string) bool {
-	if filterType != "" && node.Type != filterType {
-		return false
-	}
-
-	if tagFilter != "" && !hasTag(node, tagFilter) {
-		return false
+	if c.tagFilter != "" {
+		filter.Tags = strings.Split(c.tagFilter, ",")
 	}
 
-	if placeFilter != "" && !hasPlace(node, placeFilter) {
-		return false
+	if c.placeFilter != "" {
+		filter.Places = strings.Split(c.placeFilter, ",")
 	}
 
-	if statusFilter != "" && node.Status != statusFilter {
-		return false
-	}
+	filter.Status = c.statusFilter
+	filter.Archived = c.archived
+	return filter
+}
 
-	return true
+// isType reports whether args is a single node type, as in tn list task, rather than a query.
+func isType(args []string) bool {
+	return len(args) == 1 && model.Type.Validate(args[0])
 }
 
-func printNode(node model.Node) string {
-	dueDateStr := "nil"
-	if node.DueDate != nil {
-		dueDateStr = node.DueDate.Format("2006-01-02 15:04:05 -0700 MST")
+// query returns the query args make up, if they are not a type.
+func query(args []string) string {
+	if isType(args) {
+		return ""
 	}
-
-	dateStr := node.Date.Format("2006-01-02 15:04:05 -0700 MST")
-
-	return fmt.Sprintf(
-		"ID: %s\nType: %s\nContent: %s\nTags: %v\nPlaces: %v\nStatus: %s\nLink: %s\nDate: %s\nDueDate: %s\nDraft: %s\n",
-		node.ID,
-		node.Type,
-		node.Content,
-		node.Tags,
-		node.Places,
-		node.Status,
-		node.Link,
-		dateStr,
-		dueDateStr,
-		node.Draft,
-	)
+	return strings.Join(args, " ")
 }
@@ -0,0 +1,271 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand returns the node command group, which works on nodes of any type through the same
+// daemon commands as tn tasks.
+func NewCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "node",
+		Aliases: []string{"nodes"},
+		Short:   "Manage notes, links, drafts and tasks alike",
+	}
+
+	cmd.AddCommand(
+		newListCommand(svc),
+		newShowCommand(svc),
+		newTextCommand(svc),
+		newLabelCommand(svc, "tag"),
+		newLabelCommand(svc, "place"),
+		newConvertCommand(svc),
+	)
+
+	return cmd
+}
+
+func newListCommand(s *svc.Svc) *cobra.Command {
+	var tagFilter, placeFilter, statusFilter string
+	var archived bool
+
+	cmd := &cobra.Command{
+		Use:     "list [type]",
+		Aliases: []string{"ls"},
+		Short:   "List nodes, optionally of one type (note, task, link, draft)",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := model.Filter{Status: statusFilter, Archived: archived}
+			if len(args) == 1 {
+				if !model.Type.Validate(args[0]) {
+					return fmt.Errorf("invalid type '%s', valid types are: %s", args[0], strings.Join(model.Type.Values(), ", "))
+				}
+				filter.Type = args[0]
+			}
+			if tagFilter != "" {
+				filter.Tags = strings.Split(tagFilter, ",")
+			}
+			if placeFilter != "" {
+				filter.Places = strings.Split(placeFilter, ",")
+			}
+
+			var nodes []model.Node
+			err := common.Run(s, func(direct *svc.Svc) error {
+				var err error
+				nodes, err = direct.List(filter)
+				return err
+			}, func() error {
+				params := bkg.ListParams{Type: filter.Type, Tags: filter.Tags, Places: filter.Places, Status: filter.Status, Archived: filter.Archived}
+				return common.Send("list", params, &nodes)
+			})
+			if err != nil {
+				return err
+			}
+
+			return common.PrintList(s, nodes, func() {
+				common.PrintNodes(s, nodes)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&tagFilter, "tag", "t", "", "filter by tag")
+	cmd.Flags().StringVarP(&placeFilter, "place", "p", "", "filter by place")
+	cmd.Flags().StringVarP(&statusFilter, "status", "s", "", "filter by status")
+	cmd.Flags().BoolVar(&archived, "archived", false, "list archived nodes instead")
+
+	return cmd
+}
+
+func newShowCommand(s *svc.Svc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show all the fields of a node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var node model.Node
+			err := common.Run(s, func(direct *svc.Svc) error {
+				var err error
+				node, err = direct.ResolveID(cmd.Context(), args[0])
+				return err
+			}, func() error {
+				return common.Send("get", bkg.GetParams{ID: args[0]}, &node)
+			})
+			if err != nil {
+				return err
+			}
+
+			return common.PrintOne(s, node, func() {
+				printNode(node)
+			})
+		},
+	}
+}
+
+func newTextCommand(s *svc.Svc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "text <id> <new_text>",
+		Short: "Replace the text of a node",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, text := args[0], strings.Join(args[1:], " ")
+
+			return common.Run(s, func(direct *svc.Svc) error {
+				node, err := direct.EditText(cmd.Context(), id, text)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Updated the text of %s %s\n", node.Type, id)
+				return nil
+			}, func() error {
+				return sendMessage("text", bkg.TextParams{ID: id, Text: text})
+			})
+		},
+	}
+}
+
+// newLabelCommand returns the tag or place command with its add, remove and clear subcommands.
+func newLabelCommand(s *svc.Svc, kind string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   kind,
+		Short: fmt.Sprintf("Manage the %ss of a node", kind),
+	}
+
+	for _, op := range []struct {
+		name  string
+		use   string
+		short string
+		args  cobra.PositionalArgs
+	}{
+		{"add", fmt.Sprintf("add <id> <%s>...", kind), fmt.Sprintf("Add %ss to a node", kind), cobra.MinimumNArgs(2)},
+		{"remove", fmt.Sprintf("remove <id> <%s>...", kind), fmt.Sprintf("Remove %ss from a node", kind), cobra.MinimumNArgs(2)},
+		{"clear", "clear <id>", fmt.Sprintf("Remove all the %ss of a node", kind), cobra.ExactArgs(1)},
+	} {
+		cmd.AddCommand(&cobra.Command{
+			Use:   op.use,
+			Short: op.short,
+			Args:  op.args,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return changeLabels(cmd.Context(), s, kind, op.name, args[0], args[1:])
+			},
+		})
+	}
+
+	return cmd
+}
+
+func changeLabels(ctx context.Context, s *svc.Svc, kind, operation, id string, labels []string) error {
+	return common.Run(s, func(direct *svc.Svc) error {
+		change := direct.ChangeTags
+		if kind == "place" {
+			change = direct.ChangePlaces
+		}
+
+		node, err := change(ctx, id, operation, labels)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(bkg.LabelMessage(kind+"s", operation, labels, node.Type, id))
+		return nil
+	}, func() error {
+		if kind == "place" {
+			return sendMessage("place", bkg.PlaceCmdParams{ID: id, Places: labels, Operation: operation})
+		}
+		return sendMessage("tag", bkg.TagCmdParams{ID: id, Tags: labels, Operation: operation})
+	})
+}
+
+func newConvertCommand(s *svc.Svc) *cobra.Command {
+	var draft string
+
+	cmd := &cobra.Command{
+		Use:   "convert <id> <type>",
+		Short: "Change the type of a node, e.g. promote a note into a task",
+		Long: `Change the type of a node to note, task, link or draft. A new task starts as todo; other types
+drop the task status and recurrence, and a task with dependencies must lose them first. A link
+needs a URL in its content, and a draft a name, given with --name unless the node already has one.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, nodeType := args[0], args[1]
+
+			var result bkg.ConvertResult
+			err := common.Run(s, func(direct *svc.Svc) error {
+				change, err := direct.ConvertNode(cmd.Context(), id, nodeType, draft)
+				if err != nil {
+					return err
+				}
+				result = bkg.ConvertResult{ID: change.Node.ID, OriginalType: change.OriginalType, NewType: change.NewType}
+				return nil
+			}, func() error {
+				return common.Send("convert", bkg.ConvertParams{ID: id, Type: nodeType, Draft: draft}, &result)
+			})
+			if err != nil {
+				return err
+			}
+
+			if result.OriginalType == result.NewType {
+				fmt.Printf("%s is already a %s\n", id, result.NewType)
+				return nil
+			}
+
+			fmt.Printf("Converted %s from %s to %s\n", id, result.OriginalType, result.NewType)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&draft, "name", "", "draft name, when converting to a draft")
+
+	return cmd
+}
+
+// sendMessage sends a command whose result is a message to print.
+func sendMessage(command string, params interface{}) error {
+	var result struct {
+		Message string `json:"message"`
+	}
+
+	err := common.Send(command, params, &result)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(result.Message)
+	return nil
+}
+
+func printNode(node model.Node) {
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Printf("%-10s %s\n", name+":", value)
+		}
+	}
+
+	field("ID", node.ID)
+	field("Type", node.Type)
+	// Later lines of the content line up under the first
+	field("Content", strings.ReplaceAll(node.Content, "\n", "\n"+strings.Repeat(" ", 11)))
+	field("Labels", common.FormatLabels(node))
+	field("Status", node.Status)
+	if node.Priority > 0 {
+		field("Priority", model.Priority.Label(node.Priority))
+	}
+	if node.DueDate != nil {
+		field("Due", model.FormatDueDate(node.DueDate.Local()))
+	}
+	field("Recurs", node.Recurrence)
+	field("Link", node.Link)
+	field("Draft", node.Draft)
+	field("Parent", node.ParentID)
+	field("Captured", node.Date.Local().Format("2006-01-02 15:04"))
+	if node.ArchivedAt != nil {
+		field("Archived", node.ArchivedAt.Local().Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+	"github.com/spf13/cobra"
+)
+
+// NewEditCommand returns tn edit, which opens a node in the user's editor.
+func NewEditCommand(s *svc.Svc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit <id>",
+		Short: "Edit a node in $EDITOR",
+		Long: `Open a node in $VISUAL or $EDITOR. The first line holds its #tags, @places, :status, ^due date,
+!priority, *recurrence, +draft name and link URL; the lines below are the content, kept as
+written, line breaks included. On save, the fields that changed are applied to the node. Use
+tn node convert to change its type.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := args[0]
+
+			var node model.Node
+			err := common.Run(s, func(direct *svc.Svc) error {
+				var err error
+				node, err = direct.ResolveID(cmd.Context(), id)
+				return err
+			}, func() error {
+				return common.Send("get", bkg.GetParams{ID: id}, &node)
+			})
+			if err != nil {
+				return err
+			}
+
+			editor, err := common.NewEditor(svc.FormatEditable(node))
+			if err != nil {
+				return err
+			}
+
+			text, err := editor.Edit()
+			if err != nil {
+				editor.Remove()
+				return err
+			}
+
+			var result bkg.EditResult
+			err = common.Run(s, func(direct *svc.Svc) error {
+				var err error
+				result.Node, result.Changed, err = direct.ApplyEdit(cmd.Context(), node.ID, text)
+				return err
+			}, func() error {
+				return common.Send("edit", bkg.EditParams{ID: node.ID, Text: text}, &result)
+			})
+			if err != nil {
+				// Keep the edited text, so it is not lost to a typo in the first line
+				return fmt.Errorf("%w (the edited text is in %s)", err, editor.Path)
+			}
+			editor.Remove()
+
+			if len(result.Changed) == 0 {
+				fmt.Println("Nothing changed.")
+				return nil
+			}
+
+			fmt.Printf("Updated the %s of %s %s\n", strings.Join(result.Changed, ", "), result.Node.Type, id)
+			return nil
+		},
+	}
+}
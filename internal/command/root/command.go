@@ -1,34 +1,110 @@
 package root
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/adrianpk/tyn/internal/bkg"
 	"github.com/adrianpk/tyn/internal/command/capture"
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/command/daemon"
+	"github.com/adrianpk/tyn/internal/command/db"
 	"github.com/adrianpk/tyn/internal/command/list"
+	"github.com/adrianpk/tyn/internal/command/node"
+	"github.com/adrianpk/tyn/internal/command/search"
 	"github.com/adrianpk/tyn/internal/command/tasks"
+	"github.com/adrianpk/tyn/internal/command/transfer"
+	"github.com/adrianpk/tyn/internal/command/trash"
+	"github.com/adrianpk/tyn/internal/command/view"
+	"github.com/adrianpk/tyn/internal/command/watch"
 	"github.com/adrianpk/tyn/internal/config"
+	"github.com/adrianpk/tyn/internal/repo/sqlite"
 	"github.com/adrianpk/tyn/internal/svc"
 	"github.com/spf13/cobra"
 )
 
 func NewCommand(s *svc.Svc, cfg *config.Config) *cobra.Command {
+	var direct, offline bool
+	var output, format string
+
 	rootCmd := &cobra.Command{
 		Use: "tn",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			if cmd.Name() == "serve" {
+			err := common.UseOutput(output, format)
+			if err != nil {
+				return err
+			}
+
+			if !needsDaemon(cmd) {
 				return nil
 			}
-			return bkg.EnsureDaemon()
+
+			if direct || offline {
+				common.UseDirect(openDirect(cfg))
+				return nil
+			}
+
+			err = bkg.EnsureDaemon()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: the daemon is not available, working on the database directly: %v\n", err)
+				common.UseDirect(openDirect(cfg))
+			}
+			return nil
 		},
 	}
 
+	rootCmd.PersistentFlags().BoolVar(&direct, "direct", false, "work on the database directly instead of through the daemon")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "same as --direct")
+	// No -o shorthand: export and db backup have their own --output (-o), the file they write to
+	rootCmd.PersistentFlags().StringVar(&output, "output", common.OutputTable, "print listings as table, plain, json, jsonl or csv")
+	rootCmd.PersistentFlags().StringVar(&format, "format", "", "print each node with a Go text/template, as in '{{.ShortID}} {{.Content}}'")
+
 	rootCmd.AddCommand(capture.NewCommand(s))
 	rootCmd.AddCommand(list.NewCommand(s))
 	rootCmd.AddCommand(tasks.NewCommand(s))
+	rootCmd.AddCommand(node.NewCommand(s))
+	rootCmd.AddCommand(node.NewEditCommand(s))
+	rootCmd.AddCommand(trash.NewRmCommand(s))
+	rootCmd.AddCommand(trash.NewCommand(s))
+	rootCmd.AddCommand(trash.NewArchiveCommand(s))
+	rootCmd.AddCommand(trash.NewUnarchiveCommand(s))
+	rootCmd.AddCommand(search.NewCommand(s))
+	rootCmd.AddCommand(view.NewCommand(s))
+	rootCmd.AddCommand(view.NewRunCommand(s))
+	rootCmd.AddCommand(db.NewCommand(cfg))
+	rootCmd.AddCommand(transfer.NewExportCommand(cfg))
+	rootCmd.AddCommand(transfer.NewImportCommand(cfg))
+	rootCmd.AddCommand(watch.NewCommand())
+	rootCmd.AddCommand(daemon.NewCommand())
 	rootCmd.AddCommand(newServeCommand(cfg))
 
 	return rootCmd
 }
 
+// needsDaemon reports whether cmd talks to the daemon. The serve command is the daemon itself,
+// daemon commands manage it, and db, export and import work on the database file directly.
+func needsDaemon(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "serve", "daemon", "db", "export", "import":
+			return false
+		}
+	}
+	return true
+}
+
+// openDirect returns how commands open the database when they run without the daemon.
+func openDirect(cfg *config.Config) func() (*svc.Svc, error) {
+	return func() (*svc.Svc, error) {
+		repo, err := sqlite.NewTynRepo(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("error opening database: %w", err)
+		}
+
+		return svc.New(repo, cfg), nil
+	}
+}
+
 func newServeCommand(config *config.Config) *cobra.Command {
 	serveCmd := &cobra.Command{
 		Use:    "serve",
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+	"github.com/spf13/cobra"
+)
+
+// defaultLimit is declared at package level, where the svc package is not shadowed by the svc parameter.
+const defaultLimit = svc.DefaultSearchLimit
+
+type SearchCommand struct {
+	common.BaseCommand
+	limit int
+}
+
+func NewCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &SearchCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "search",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:     "search <query>",
+		Aliases: []string{"s", "find"},
+		Short:   "search the content of all nodes",
+		Long: `Search the content, links and drafts of all nodes, most relevant first.
+
+Words match whole words, "quoted text" matches a phrase, and a trailing * matches a prefix (deploy*).
+OR and NOT combine terms. #tag, @place and :status narrow down the results.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			flags := common.ExtractFlagsFromCommand(cobra)
+			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
+		},
+	}
+
+	cobraCmd.Flags().IntVarP(&cmd.limit, "limit", "n", defaultLimit, "maximum number of results")
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *SearchCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	results, err := c.Svc.Search(ctx, strings.Join(args, " "), c.limit)
+	if err != nil {
+		return err
+	}
+
+	return printResults(c.Svc, results)
+}
+
+func (c *SearchCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
+	params := bkg.SearchParams{
+		Query: strings.Join(args, " "),
+		Limit: c.limit,
+	}
+
+	resp, err := bkg.SendCommand("search", params)
+	if err != nil {
+		return fmt.Errorf("error communicating with daemon: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("daemon returned error: %s", resp.Error)
+	}
+
+	var results []model.SearchResult
+	err = json.Unmarshal(resp.Data, &results)
+	if err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return printResults(c.Svc, results)
+}
+
+func printResults(s *svc.Svc, results []model.SearchResult) error {
+	return common.PrintResults(s, results, func() {
+		printResultTable(s, results)
+	})
+}
+
+func printResultTable(s *svc.Svc, results []model.SearchResult) {
+	if len(results) == 0 {
+		fmt.Println("No matches found.")
+		return
+	}
+
+	nodes := make([]model.Node, len(results))
+	for i, r := range results {
+		nodes[i] = r.Node
+	}
+	short := common.ShortIDs(s, nodes)
+	idWidth := common.IDWidth(short)
+
+	start, end := "[", "]"
+	if common.Color() {
+		start, end = "\033[1;33m", "\033[0m"
+	}
+
+	for _, r := range results {
+		node := r.Node
+
+		kind := node.Type
+		if node.Type == model.Type.Task {
+			kind = "[" + node.Status + "]"
+		}
+
+		snippet := strings.Join(strings.Fields(r.Snippet), " ")
+		snippet = strings.NewReplacer(model.HighlightStart, start, model.HighlightEnd, end).Replace(snippet)
+
+		fmt.Printf("%-*s %-10s %s\n", idWidth, short[node.ID], kind, snippet)
+
+		var labels []string
+		for _, tag := range node.Tags {
+			labels = append(labels, "#"+tag)
+		}
+		for _, place := range node.Places {
+			labels = append(labels, "@"+place)
+		}
+		if len(labels) > 0 {
+			fmt.Printf("%-*s %-10s %s\n", idWidth, "", "", strings.Join(labels, " "))
+		}
+	}
+
+	fmt.Printf("\n%d result(s)\n", len(results))
+}
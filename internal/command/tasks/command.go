@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 	"strings"
 	"time"
 
@@ -26,6 +25,7 @@ type (
 		tagFilter    string
 		placeFilter  string
 		statusFilter string
+		query        string
 	}
 
 	TasksStatusCommand struct {
@@ -44,6 +44,42 @@ type (
 		common.BaseCommand
 	}
 
+	TasksAddSubCommand struct {
+		common.BaseCommand
+	}
+
+	TasksPriorityCommand struct {
+		common.BaseCommand
+	}
+
+	TasksPrioritySetCommand struct {
+		common.BaseCommand
+	}
+
+	TasksPriorityUpCommand struct {
+		common.BaseCommand
+	}
+
+	TasksPriorityDownCommand struct {
+		common.BaseCommand
+	}
+
+	TasksDependCommand struct {
+		common.BaseCommand
+	}
+
+	TasksDependAddCommand struct {
+		common.BaseCommand
+	}
+
+	TasksDependRemoveCommand struct {
+		common.BaseCommand
+	}
+
+	TasksDependListCommand struct {
+		common.BaseCommand
+	}
+
 	TasksTextCommand struct {
 		common.BaseCommand
 	}
@@ -96,8 +132,12 @@ func NewCommand(svc *svc.Svc) *cobra.Command {
 	}
 
 	cobraCmd.AddCommand(newListCommand(svc))
+	cobraCmd.AddCommand(newAddSubCommand(svc))
 	cobraCmd.AddCommand(newStatusCommand(svc))
+	cobraCmd.AddCommand(newPriorityCommand(svc))
+	cobraCmd.AddCommand(newDependCommand(svc))
 	cobraCmd.AddCommand(newUpdateCommand(svc))
+	cobraCmd.AddCommand(newTextCommand(svc))
 	cobraCmd.AddCommand(newTagCommand(svc))
 	cobraCmd.AddCommand(newPlaceCommand(svc))
 	cobraCmd.AddCommand(newDateCommand(svc))
@@ -277,22 +317,501 @@ func handleStatusResponse(resp *bkg.Response) error {
 		return fmt.Errorf("daemon returned error: %s", resp.Error)
 	}
 
-	var result struct {
-		OriginalStatus string `json:"original_status"`
-		NewStatus      string `json:"new_status"`
-	}
+	var result bkg.StatusResult
 
 	err := json.Unmarshal(resp.Data, &result)
 	if err != nil {
 		return fmt.Errorf("error parsing response: %w", err)
 	}
 
-	fmt.Printf("Task status updated: '%s' → '%s'\n", result.OriginalStatus, result.NewStatus)
-	displayStatusCycle(result.OriginalStatus, result.NewStatus)
+	printStatusChange(result)
+
+	return nil
+}
+
+func printStatusChange(result bkg.StatusResult) {
+	if len(result.BlockedBy) > 0 {
+		fmt.Printf("Task is '%s' and becomes '%s' once these dependencies are done or canceled:\n", result.NewStatus, result.PriorStatus)
+		for _, dep := range result.BlockedBy {
+			fmt.Printf("  %s\n", dep)
+		}
+	} else {
+		fmt.Printf("Task status updated: '%s' → '%s'\n", result.OriginalStatus, result.NewStatus)
+		displayStatusCycle(result.OriginalStatus, result.NewStatus)
+	}
+
+	if result.NextID != "" {
+		fmt.Printf("Next occurrence created: %s due %s\n", result.NextID, result.NextDueDate)
+	}
+
+	if len(result.OpenSubtasks) > 0 {
+		fmt.Printf("Warning: %d subtask(s) still open:\n", len(result.OpenSubtasks))
+		for _, subtask := range result.OpenSubtasks {
+			fmt.Printf("  %s\n", subtask)
+		}
+	}
+
+	for _, task := range result.Unblocked {
+		fmt.Printf("Unblocked: %s\n", task)
+	}
+
+	for _, task := range result.Blocked {
+		fmt.Printf("Blocked: %s\n", task)
+	}
+}
+
+func newAddSubCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksAddSubCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "add_sub",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:     "add-sub <parent_id> <content>",
+		Aliases: []string{"sub"},
+		Short:   "Add a subtask",
+		Long:    "Capture a subtask of an existing task; the content accepts the same syntax as capture",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			flags := common.ExtractFlagsFromCommand(cobra)
+			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
+		},
+	}
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *TasksAddSubCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	log.Printf("Executing add-sub command directly")
+	node, err := c.Svc.CaptureSub(args[0], strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Subtask %s added to %s\n", common.ShortIDs(c.Svc, []model.Node{node})[node.ID], args[0])
+	return nil
+}
+
+func (c *TasksAddSubCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
+	log.Printf("Executing add-sub command via IPC")
+	params := bkg.CaptureParams{
+		Text:   strings.Join(args[1:], " "),
+		Parent: args[0],
+	}
+
+	resp, err := bkg.SendCommand("capture", params)
+	if err != nil {
+		return fmt.Errorf("error communicating with daemon: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("daemon returned error: %s", resp.Error)
+	}
+
+	var node model.Node
+	err = json.Unmarshal(resp.Data, &node)
+	if err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	fmt.Printf("Subtask %s added to %s\n", common.ShortIDs(c.Svc, []model.Node{node})[node.ID], args[0])
+	return nil
+}
+
+func newPriorityCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksPriorityCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "priority",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:     "priority",
+		Aliases: []string{"pri", "p"},
+		Short:   "Change task priority",
+		Long:    "Change the priority of a task using its ID",
+	}
+
+	cobraCmd.AddCommand(newPrioritySetCommand(svc))
+	cobraCmd.AddCommand(newPriorityUpCommand(svc))
+	cobraCmd.AddCommand(newPriorityDownCommand(svc))
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func newPrioritySetCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksPrioritySetCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "priority_set",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:   "set <id> <priority>",
+		Short: "Set specific priority",
+		Long:  "Set the priority of a task: 1-4 or urgent, high, medium, low; 0 or none clears it",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			flags := common.ExtractFlagsFromCommand(cobra)
+			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
+		},
+	}
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *TasksPrioritySetCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	log.Printf("Executing priority set command directly")
+	return changeTaskPriority(ctx, c.Svc, args[0], args[1], "set")
+}
+
+func (c *TasksPrioritySetCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
+	log.Printf("Executing priority set command via IPC")
+	return sendPriorityCommand(args[0], args[1], "set")
+}
+
+func newPriorityUpCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksPriorityUpCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "priority_up",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:   "up <id>",
+		Short: "Raise priority",
+		Long:  "Raise the priority of a task one step towards urgent",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			flags := common.ExtractFlagsFromCommand(cobra)
+			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
+		},
+	}
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *TasksPriorityUpCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	log.Printf("Executing priority up command directly")
+	return changeTaskPriority(ctx, c.Svc, args[0], "", "up")
+}
+
+func (c *TasksPriorityUpCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
+	log.Printf("Executing priority up command via IPC")
+	return sendPriorityCommand(args[0], "", "up")
+}
+
+func newPriorityDownCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksPriorityDownCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "priority_down",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:   "down <id>",
+		Short: "Lower priority",
+		Long:  "Lower the priority of a task one step towards low, then to none",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			flags := common.ExtractFlagsFromCommand(cobra)
+			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
+		},
+	}
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *TasksPriorityDownCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	log.Printf("Executing priority down command directly")
+	return changeTaskPriority(ctx, c.Svc, args[0], "", "down")
+}
 
+func (c *TasksPriorityDownCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
+	log.Printf("Executing priority down command via IPC")
+	return sendPriorityCommand(args[0], "", "down")
+}
+
+func changeTaskPriority(ctx context.Context, svc *svc.Svc, id, priority, operation string) error {
+	change, err := svc.ChangePriority(ctx, id, operation, priority)
+	if err != nil {
+		return err
+	}
+
+	printPriorityChange(change.OriginalPriority, change.NewPriority)
+	return nil
+}
+
+func sendPriorityCommand(id, priority, operation string) error {
+	params := bkg.PriorityParams{
+		ID:        id,
+		Priority:  priority,
+		Operation: operation,
+	}
+
+	resp, err := bkg.SendCommand("priority", params)
+	if err != nil {
+		return fmt.Errorf("error communicating with daemon: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("daemon returned error: %s", resp.Error)
+	}
+
+	var result bkg.PriorityResult
+	err = json.Unmarshal(resp.Data, &result)
+	if err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+
+	printPriorityChange(result.OriginalPriority, result.NewPriority)
 	return nil
 }
 
+func printPriorityChange(originalPriority, newPriority int) {
+	fmt.Printf("Task priority updated: '%s' → '%s'\n",
+		model.Priority.Label(originalPriority), model.Priority.Label(newPriority))
+}
+
+func priorityDisplay(priority int) string {
+	if priority == model.Priority.None {
+		return "-"
+	}
+	return fmt.Sprintf("P%d", priority)
+}
+
+func newDependCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksDependCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "depend",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:     "depend",
+		Aliases: []string{"dep"},
+		Short:   "Manage task dependencies",
+		Long:    "Manage the tasks a task depends on; a task stays blocked until its dependencies are done or canceled",
+	}
+
+	cobraCmd.AddCommand(newDependAddCommand(svc))
+	cobraCmd.AddCommand(newDependRemoveCommand(svc))
+	cobraCmd.AddCommand(newDependListCommand(svc))
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func newDependAddCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksDependAddCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "depend_add",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:   "add <id> <other_id>",
+		Short: "Add a dependency",
+		Long:  "Make a task depend on another task; the task is blocked until the other one is done or canceled",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			flags := common.ExtractFlagsFromCommand(cobra)
+			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
+		},
+	}
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *TasksDependAddCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	log.Printf("Executing depend add command directly")
+	change, err := c.Svc.AddDependency(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	printDependChange(bkg.NewDependResult(ctx, c.Svc, change), "add")
+	return nil
+}
+
+func (c *TasksDependAddCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
+	log.Printf("Executing depend add command via IPC")
+	result, err := sendDependCommand(args[0], args[1], "add")
+	if err != nil {
+		return err
+	}
+
+	printDependChange(result, "add")
+	return nil
+}
+
+func newDependRemoveCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksDependRemoveCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "depend_remove",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:     "remove <id> <other_id>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a dependency",
+		Long:    "Remove the dependency of a task on another task, unblocking it if nothing else is open",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			flags := common.ExtractFlagsFromCommand(cobra)
+			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
+		},
+	}
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *TasksDependRemoveCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	log.Printf("Executing depend remove command directly")
+	change, err := c.Svc.RemoveDependency(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	printDependChange(bkg.NewDependResult(ctx, c.Svc, change), "remove")
+	return nil
+}
+
+func (c *TasksDependRemoveCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
+	log.Printf("Executing depend remove command via IPC")
+	result, err := sendDependCommand(args[0], args[1], "remove")
+	if err != nil {
+		return err
+	}
+
+	printDependChange(result, "remove")
+	return nil
+}
+
+func newDependListCommand(svc *svc.Svc) *cobra.Command {
+	cmd := &TasksDependListCommand{
+		BaseCommand: common.BaseCommand{
+			Svc:         svc,
+			CommandName: "depend_list",
+		},
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:     "list <id>",
+		Aliases: []string{"ls"},
+		Short:   "List dependencies",
+		Long:    "List the tasks a task depends on and the tasks it blocks",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			flags := common.ExtractFlagsFromCommand(cobra)
+			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
+		},
+	}
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *TasksDependListCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
+	log.Printf("Executing depend list command directly")
+	deps, err := c.Svc.Dependencies(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	printDependencies(c.Svc, deps.Dependencies, deps.Dependents)
+	return nil
+}
+
+func (c *TasksDependListCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
+	log.Printf("Executing depend list command via IPC")
+	result, err := sendDependCommand(args[0], "", "list")
+	if err != nil {
+		return err
+	}
+
+	printDependencies(c.Svc, result.Dependencies, result.Dependents)
+	return nil
+}
+
+func sendDependCommand(id, dependsOn, operation string) (bkg.DependResult, error) {
+	params := bkg.DependParams{
+		ID:        id,
+		DependsOn: dependsOn,
+		Operation: operation,
+	}
+
+	resp, err := bkg.SendCommand("depend", params)
+	if err != nil {
+		return bkg.DependResult{}, fmt.Errorf("error communicating with daemon: %w", err)
+	}
+
+	if !resp.Success {
+		return bkg.DependResult{}, fmt.Errorf("daemon returned error: %s", resp.Error)
+	}
+
+	var result bkg.DependResult
+	err = json.Unmarshal(resp.Data, &result)
+	if err != nil {
+		return bkg.DependResult{}, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return result, nil
+}
+
+func printDependChange(result bkg.DependResult, operation string) {
+	if operation == "add" {
+		fmt.Printf("Task %s now depends on %s\n", result.TaskID, result.DependsOnID)
+	} else {
+		fmt.Printf("Task %s no longer depends on %s\n", result.TaskID, result.DependsOnID)
+	}
+
+	if result.OriginalStatus != result.NewStatus {
+		fmt.Printf("Task status updated: '%s' → '%s'\n", result.OriginalStatus, result.NewStatus)
+	}
+}
+
+func printDependencies(s *svc.Svc, dependencies, dependents []model.Node) {
+	if len(dependencies) == 0 && len(dependents) == 0 {
+		fmt.Println("No dependencies found.")
+		return
+	}
+
+	short := common.ShortIDs(s, append(append([]model.Node{}, dependencies...), dependents...))
+	idWidth := common.IDWidth(short)
+
+	if len(dependencies) > 0 {
+		fmt.Println("Depends on:")
+		for _, task := range dependencies {
+			fmt.Printf("  %-*s %-10s %s\n", idWidth, short[task.ID], "["+task.Status+"]", strings.Join(strings.Fields(task.Content), " "))
+		}
+	}
+
+	if len(dependents) > 0 {
+		fmt.Println("Blocks:")
+		for _, task := range dependents {
+			fmt.Printf("  %-*s %-10s %s\n", idWidth, short[task.ID], "["+task.Status+"]", strings.Join(strings.Fields(task.Content), " "))
+		}
+	}
+}
+
 func newListCommand(svc *svc.Svc) *cobra.Command {
 	cmd := &TasksListCommand{
 		BaseCommand: common.BaseCommand{
@@ -302,20 +821,15 @@ func newListCommand(svc *svc.Svc) *cobra.Command {
 	}
 
 	cobraCmd := &cobra.Command{
-		Use:     "list [status] [#tag] [@place]",
+		Use:     "list [query]",
 		Aliases: []string{"ls", "l"},
 		Short:   "List tasks with optional filtering",
-		Long:    "List tasks with optional filtering by status, tags, and places",
+		Long: `List tasks with optional filtering by status, tags, and places. The arguments are a query, as
+for tn list: ":wip #urgent @office" lists the tasks with all three, and a query such as
+"(#work or #ops) and not :done and due<+7d sort:due" combines and orders them. Without a sort,
+tasks are listed by priority. Several tags must all match; use "#a or #b" for either of them.`,
 		RunE: func(cobra *cobra.Command, args []string) error {
-			for _, arg := range args {
-				if strings.HasPrefix(arg, ":") {
-					cmd.statusFilter = strings.TrimPrefix(arg, ":")
-				} else if strings.HasPrefix(arg, "#") {
-					cmd.tagFilter = strings.TrimPrefix(arg, "#")
-				} else if strings.HasPrefix(arg, "@") {
-					cmd.placeFilter = strings.TrimPrefix(arg, "@")
-				}
-			}
+			cmd.query = strings.Join(args, " ")
 
 			flags := common.ExtractFlagsFromCommand(cobra)
 			return cmd.Execute(cobra.Context(), args, flags, cmd.ExecuteDirect, cmd.ExecuteViaIPC)
@@ -332,29 +846,38 @@ func newListCommand(svc *svc.Svc) *cobra.Command {
 
 func (c *TasksListCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
 	log.Printf("Executing tasks list command directly")
-	nodes, err := c.Svc.Repo.List(context.TODO())
+	filter := c.filter()
+
+	var err error
+	filter.Query, err = svc.ParseQuery(c.query)
 	if err != nil {
 		return err
 	}
 
-	tasks := filterTasks(nodes, c.statusFilter, c.tagFilter, c.placeFilter)
-	printTasks(tasks)
-	return nil
+	tasks, err := c.Svc.List(filter)
+	if err != nil {
+		return err
+	}
+
+	return printTasks(c.Svc, tasks, len(filter.Query.Sort) > 0)
 }
 
 func (c *TasksListCommand) ExecuteViaIPC(args []string, flags map[string]interface{}) error {
 	log.Printf("Executing tasks list command via IPC")
-	params := bkg.ListParams{
-		Type:   "task",
-		Status: c.statusFilter,
-	}
+	filter := c.filter()
 
-	if c.tagFilter != "" {
-		params.Tags = strings.Split(c.tagFilter, ",")
+	// The daemon parses the query itself; parsing it here tells whether it sorts
+	query, err := svc.ParseQuery(c.query)
+	if err != nil {
+		return err
 	}
 
-	if c.placeFilter != "" {
-		params.Places = strings.Split(c.placeFilter, ",")
+	params := bkg.ListParams{
+		Type:   filter.Type,
+		Tags:   filter.Tags,
+		Places: filter.Places,
+		Status: filter.Status,
+		Query:  c.query,
 	}
 
 	resp, err := bkg.SendCommand("list", params)
@@ -366,53 +889,41 @@ func (c *TasksListCommand) ExecuteViaIPC(args []string, flags map[string]interfa
 		return fmt.Errorf("daemon returned error: %s", resp.Error)
 	}
 
-	var nodes []model.Node
-	err = json.Unmarshal(resp.Data, &nodes)
+	var tasks []model.Node
+	err = json.Unmarshal(resp.Data, &tasks)
 	if err != nil {
 		return fmt.Errorf("error parsing response: %w", err)
 	}
 
-	tasks := filterTasks(nodes, c.statusFilter, c.tagFilter, c.placeFilter)
-	printTasks(tasks)
-	return nil
+	return printTasks(c.Svc, tasks, len(query.Sort) > 0)
 }
 
-func changeTaskStatus(svc *svc.Svc, id, targetStatus, operation string) error {
-	if svc != nil {
-		nodes, err := svc.Repo.List(context.TODO())
-		if err != nil {
-			return err
-		}
-
-		targetNode, found := findNodeByShortID(nodes, id)
-		if !found {
-			return fmt.Errorf("task with ID '%s' not found", id)
-		}
+// filter builds the task filter from the flags. Tag and place flags accept comma separated values.
+func (c *TasksListCommand) filter() model.Filter {
+	filter := model.Filter{
+		Type:   model.Type.Task,
+		Status: c.statusFilter,
+	}
 
-		originalStatus := targetNode.Status
-		var newStatus string
+	if c.tagFilter != "" {
+		filter.Tags = strings.Split(c.tagFilter, ",")
+	}
 
-		switch operation {
-		case "set":
-			if !model.ValidStatus(targetStatus) {
-				return fmt.Errorf("invalid status: %s", targetStatus)
-			}
-			newStatus = targetStatus
-		case "next":
-			newStatus = model.NextStatus(targetNode.Status)
-		case "prev":
-			newStatus = model.PreviousStatus(targetNode.Status)
-		}
+	if c.placeFilter != "" {
+		filter.Places = strings.Split(c.placeFilter, ",")
+	}
 
-		targetNode.Status = newStatus
+	return filter
+}
 
-		err = svc.Repo.Update(context.TODO(), targetNode)
+func changeTaskStatus(svc *svc.Svc, id, targetStatus, operation string) error {
+	if svc != nil {
+		change, err := svc.ChangeStatus(context.TODO(), id, operation, targetStatus)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Task status updated: '%s' → '%s'\n", originalStatus, newStatus)
-		displayStatusCycle(originalStatus, newStatus)
+		printStatusChange(bkg.NewStatusResult(context.TODO(), svc, change))
 
 		return nil
 	}
@@ -420,15 +931,6 @@ func changeTaskStatus(svc *svc.Svc, id, targetStatus, operation string) error {
 	return fmt.Errorf("service not available")
 }
 
-func findNodeByShortID(nodes []model.Node, shortID string) (model.Node, bool) {
-	for _, node := range nodes {
-		if strings.HasPrefix(node.ID, shortID) || node.ShortID() == shortID {
-			return node, true
-		}
-	}
-	return model.Node{}, false
-}
-
 func displayStatusCycle(originalStatus, newStatus string) {
 	var statusDisplay string
 
@@ -449,62 +951,44 @@ func displayStatusCycle(originalStatus, newStatus string) {
 	fmt.Println(statusDisplay)
 }
 
-func filterTasks(nodes []model.Node, statusFilter, tagFilter, placeFilter string) []model.Node {
-	var tasks []model.Node
-
-	for _, node := range nodes {
-		if node.Type != "task" {
-			continue
-		}
-
-		if statusFilter != "" && node.Status != statusFilter {
-			continue
-		}
-
-		if tagFilter != "" && !hasTag(node, tagFilter) {
-			continue
-		}
-
-		if placeFilter != "" && !hasPlace(node, placeFilter) {
-			continue
-		}
-
-		tasks = append(tasks, node)
+// printTasks prints tasks with their subtasks under them, by priority unless they are sorted
+// already. Other outputs than the table list the tasks in the same order.
+func printTasks(s *svc.Svc, tasks []model.Node, sorted bool) error {
+	if !sorted {
+		model.SortByPriority(tasks)
 	}
+	items := model.Flatten(tasks)
 
-	return tasks
-}
-
-func hasTag(node model.Node, tag string) bool {
-	for _, t := range node.Tags {
-		if t == tag {
-			return true
-		}
+	listed := make([]model.Node, len(items))
+	for i, item := range items {
+		listed[i] = item.Node
 	}
-	return false
-}
 
-func hasPlace(node model.Node, place string) bool {
-	for _, p := range node.Places {
-		if p == place {
-			return true
-		}
-	}
-	return false
+	return common.PrintList(s, listed, func() {
+		printTaskTable(s, tasks, items)
+	})
 }
 
-func printTasks(tasks []model.Node) {
+func printTaskTable(s *svc.Svc, tasks []model.Node, items []model.TreeNode) {
 	if len(tasks) == 0 {
 		fmt.Println("No tasks found.")
 		return
 	}
 
-	contentWidth := 45
+	progress := model.ChildProgress(tasks)
+
+	short := common.ShortIDs(s, tasks)
+	idWidth := common.IDWidth(short)
+
+	// The content column takes what the other columns leave of the terminal
+	contentWidth := common.ColumnWidth(idWidth+43, 20, 45)
+
+	fmt.Printf("%-*s %-3s %-10s %-*s %-20s %s\n", idWidth, "ID", "P", "STATUS", contentWidth, "CONTENT", "TAGS/PLACES", "!")
+	fmt.Println(strings.Repeat("-", 43+contentWidth+idWidth))
 
-	fmt.Printf("%-6s %-10s %-45s %-20s %s\n", "ID", "STATUS", "CONTENT", "TAGS/PLACES", "!")
-	fmt.Println(strings.Repeat("-", 90))
+	for _, item := range items {
+		task := item.Node
 
-	for _, task := range tasks {
 		var metadata []string
 
 		for _, tag := range task.Tags {
@@ -528,17 +1012,29 @@ func printTasks(tasks []model.Node) {
 			overdueIndicator = "⌛"
 		}
 
+		if task.Recurrence != "" {
+			overdueIndicator += "↻"
+		}
+
 		content := strings.TrimSpace(task.Content)
 		content = strings.Join(strings.Fields(content), " ")
 
-		if len(content) > contentWidth {
-			content = content[:contentWidth-3] + "..."
+		if item.Depth > 0 {
+			content = strings.Repeat("  ", item.Depth-1) + "└ " + content
+		}
+
+		suffix := ""
+		if p, ok := progress[task.ID]; ok {
+			suffix = fmt.Sprintf(" (%s)", p)
 		}
 
-		fmt.Printf("%-6s %-10s %-45s %-20s %s\n",
-			task.ShortID(),
+		content = common.Truncate(content, contentWidth-len(suffix)) + suffix
+
+		fmt.Printf("%-*s %-3s %-10s %-*s %-20s %s\n",
+			idWidth, short[task.ID],
+			priorityDisplay(task.Priority),
 			statusDisplay,
-			content,
+			contentWidth, content,
 			metadataStr,
 			overdueIndicator)
 	}
@@ -571,7 +1067,7 @@ func (c *TasksTextCommand) ExecuteDirect(ctx context.Context, args []string, fla
 	id := args[0]
 	newText := args[1]
 
-	task, err := c.Svc.Repo.GetTaskByID(ctx, id)
+	task, err := c.Svc.ResolveID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("error fetching task: %w", err)
 	}
@@ -640,9 +1136,13 @@ func newTagAddCommand(svc *svc.Svc) *cobra.Command {
 			id := args[0]
 			tag := args[1]
 
-			// Check environment to determine execution mode
-			if os.Getenv("TYN_DEV") == "1" {
-				// Execute via IPC in dev mode
+			direct, err := common.DirectSvc(svc)
+			if err != nil {
+				return err
+			}
+
+			// Without a direct service the change goes through the daemon
+			if direct == nil {
 				params := bkg.TagCmdParams{
 					ID:        id,
 					Tags:      []string{tag},
@@ -671,13 +1171,12 @@ func newTagAddCommand(svc *svc.Svc) *cobra.Command {
 				return nil
 			}
 
-			// Execute directly in non-dev mode
-			task, err := svc.Repo.GetTaskByID(cobra.Context(), id)
+			task, err := direct.ResolveID(cobra.Context(), id)
 			if err != nil {
 				return err
 			}
 			task.Tags = append(task.Tags, tag)
-			err = svc.Repo.Update(cobra.Context(), task)
+			err = direct.Repo.Update(cobra.Context(), task)
 			if err != nil {
 				return err
 			}
@@ -698,9 +1197,13 @@ func newTagRemoveCommand(svc *svc.Svc) *cobra.Command {
 			id := args[0]
 			tag := args[1]
 
-			// Check environment to determine execution mode
-			if os.Getenv("TYN_DEV") == "1" {
-				// Execute via IPC in dev mode
+			direct, err := common.DirectSvc(svc)
+			if err != nil {
+				return err
+			}
+
+			// Without a direct service the change goes through the daemon
+			if direct == nil {
 				params := bkg.TagCmdParams{
 					ID:        id,
 					Tags:      []string{tag},
@@ -729,8 +1232,7 @@ func newTagRemoveCommand(svc *svc.Svc) *cobra.Command {
 				return nil
 			}
 
-			// Execute directly in non-dev mode
-			task, err := svc.Repo.GetTaskByID(cmd.Context(), id)
+			task, err := direct.ResolveID(cmd.Context(), id)
 			if err != nil {
 				return err
 			}
@@ -741,7 +1243,7 @@ func newTagRemoveCommand(svc *svc.Svc) *cobra.Command {
 				}
 			}
 			task.Tags = filteredTags
-			err = svc.Repo.Update(cmd.Context(), task)
+			err = direct.Repo.Update(cmd.Context(), task)
 			if err != nil {
 				return err
 			}
@@ -759,9 +1261,13 @@ func newTagClearCommand(svc *svc.Svc) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			id := args[0]
 
-			// Check environment to determine execution mode
-			if os.Getenv("TYN_DEV") == "1" {
-				// Execute via IPC in dev mode
+			direct, err := common.DirectSvc(svc)
+			if err != nil {
+				return err
+			}
+
+			// Without a direct service the change goes through the daemon
+			if direct == nil {
 				params := bkg.TagCmdParams{
 					ID:        id,
 					Tags:      []string{},
@@ -790,13 +1296,12 @@ func newTagClearCommand(svc *svc.Svc) *cobra.Command {
 				return nil
 			}
 
-			// Execute directly in non-dev mode
-			task, err := svc.Repo.GetTaskByID(cmd.Context(), id)
+			task, err := direct.ResolveID(cmd.Context(), id)
 			if err != nil {
 				return err
 			}
 			task.Tags = []string{}
-			err = svc.Repo.Update(cmd.Context(), task)
+			err = direct.Repo.Update(cmd.Context(), task)
 			if err != nil {
 				return err
 			}
@@ -838,9 +1343,13 @@ func newPlaceAddCommand(svc *svc.Svc) *cobra.Command {
 			id := args[0]
 			place := args[1]
 
-			// Check environment to determine execution mode
-			if os.Getenv("TYN_DEV") == "1" {
-				// Execute via IPC in dev mode
+			direct, err := common.DirectSvc(svc)
+			if err != nil {
+				return err
+			}
+
+			// Without a direct service the change goes through the daemon
+			if direct == nil {
 				params := bkg.PlaceCmdParams{
 					ID:        id,
 					Places:    []string{place},
@@ -869,13 +1378,12 @@ func newPlaceAddCommand(svc *svc.Svc) *cobra.Command {
 				return nil
 			}
 
-			// Execute directly in non-dev mode
-			task, err := svc.Repo.GetTaskByID(cmd.Context(), id)
+			task, err := direct.ResolveID(cmd.Context(), id)
 			if err != nil {
 				return err
 			}
 			task.Places = append(task.Places, place)
-			err = svc.Repo.Update(cmd.Context(), task)
+			err = direct.Repo.Update(cmd.Context(), task)
 			if err != nil {
 				return err
 			}
@@ -894,9 +1402,13 @@ func newPlaceRemoveCommand(svc *svc.Svc) *cobra.Command {
 			id := args[0]
 			place := args[1]
 
-			// Check environment to determine execution mode
-			if os.Getenv("TYN_DEV") == "1" {
-				// Execute via IPC in dev mode
+			direct, err := common.DirectSvc(svc)
+			if err != nil {
+				return err
+			}
+
+			// Without a direct service the change goes through the daemon
+			if direct == nil {
 				params := bkg.PlaceCmdParams{
 					ID:        id,
 					Places:    []string{place},
@@ -925,8 +1437,7 @@ func newPlaceRemoveCommand(svc *svc.Svc) *cobra.Command {
 				return nil
 			}
 
-			// Execute directly in non-dev mode
-			task, err := svc.Repo.GetTaskByID(cmd.Context(), id)
+			task, err := direct.ResolveID(cmd.Context(), id)
 			if err != nil {
 				return err
 			}
@@ -937,7 +1448,7 @@ func newPlaceRemoveCommand(svc *svc.Svc) *cobra.Command {
 				}
 			}
 			task.Places = filteredPlaces
-			err = svc.Repo.Update(cmd.Context(), task)
+			err = direct.Repo.Update(cmd.Context(), task)
 			if err != nil {
 				return err
 			}
@@ -955,9 +1466,13 @@ func newPlaceClearCommand(svc *svc.Svc) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			id := args[0]
 
-			// Check environment to determine execution mode
-			if os.Getenv("TYN_DEV") == "1" {
-				// Execute via IPC in dev mode
+			direct, err := common.DirectSvc(svc)
+			if err != nil {
+				return err
+			}
+
+			// Without a direct service the change goes through the daemon
+			if direct == nil {
 				params := bkg.PlaceCmdParams{
 					ID:        id,
 					Places:    []string{},
@@ -986,13 +1501,12 @@ func newPlaceClearCommand(svc *svc.Svc) *cobra.Command {
 				return nil
 			}
 
-			// Execute directly in non-dev mode
-			task, err := svc.Repo.GetTaskByID(cmd.Context(), id)
+			task, err := direct.ResolveID(cmd.Context(), id)
 			if err != nil {
 				return err
 			}
 			task.Places = []string{}
-			err = svc.Repo.Update(cmd.Context(), task)
+			err = direct.Repo.Update(cmd.Context(), task)
 			if err != nil {
 				return err
 			}
@@ -1033,9 +1547,13 @@ func newDateSetCommand(svc *svc.Svc) *cobra.Command {
 			id := args[0]
 			dateStr := args[1]
 
-			// Check environment to determine execution mode
-			if os.Getenv("TYN_DEV") == "1" {
-				// Execute via IPC in dev mode
+			direct, err := common.DirectSvc(svc)
+			if err != nil {
+				return err
+			}
+
+			// Without a direct service the change goes through the daemon
+			if direct == nil {
 				params := bkg.DateCmdParams{
 					ID:        id,
 					Date:      dateStr,
@@ -1066,21 +1584,20 @@ func newDateSetCommand(svc *svc.Svc) *cobra.Command {
 				return nil
 			}
 
-			// Execute directly in non-dev mode
-			task, err := svc.Repo.GetTaskByID(cmd.Context(), id)
+			task, err := direct.ResolveID(cmd.Context(), id)
 			if err != nil {
 				return err
 			}
-			date, err := time.Parse("2006-01-02", dateStr)
+			date, err := parseDueDate(dateStr)
 			if err != nil {
 				return fmt.Errorf("invalid date format: %v", err)
 			}
 			task.DueDate = &date
-			err = svc.Repo.Update(cmd.Context(), task)
+			err = direct.Repo.Update(cmd.Context(), task)
 			if err != nil {
 				return err
 			}
-			fmt.Printf("Set due date to %s for task %s\n", dateStr, id)
+			fmt.Printf("Set due date to %s for task %s\n", model.FormatDueDate(date), id)
 			return nil
 		},
 	}
@@ -1094,9 +1611,13 @@ func newDateRemoveCommand(svc *svc.Svc) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			id := args[0]
 
-			// Check environment to determine execution mode
-			if os.Getenv("TYN_DEV") == "1" {
-				// Execute via IPC in dev mode
+			direct, err := common.DirectSvc(svc)
+			if err != nil {
+				return err
+			}
+
+			// Without a direct service the change goes through the daemon
+			if direct == nil {
 				params := bkg.DateCmdParams{
 					ID:        id,
 					Operation: "remove",
@@ -1125,13 +1646,12 @@ func newDateRemoveCommand(svc *svc.Svc) *cobra.Command {
 				return nil
 			}
 
-			// Execute directly in non-dev mode
-			task, err := svc.Repo.GetTaskByID(cmd.Context(), id)
+			task, err := direct.ResolveID(cmd.Context(), id)
 			if err != nil {
 				return err
 			}
 			task.DueDate = nil
-			err = svc.Repo.Update(cmd.Context(), task)
+			err = direct.Repo.Update(cmd.Context(), task)
 			if err != nil {
 				return err
 			}
@@ -1141,6 +1661,11 @@ func newDateRemoveCommand(svc *svc.Svc) *cobra.Command {
 	}
 }
 
+// parseDueDate is used where the svc package name is shadowed by a *svc.Svc parameter.
+func parseDueDate(expr string) (time.Time, error) {
+	return svc.ParseDate(expr)
+}
+
 func newUpdateCommand(svc *svc.Svc) *cobra.Command {
 	cmd := &TasksUpdateCommand{
 		BaseCommand: common.BaseCommand{
@@ -1162,7 +1687,7 @@ func newUpdateCommand(svc *svc.Svc) *cobra.Command {
 
 	cobraCmd.Flags().StringSliceVarP(&cmd.tags, "tags", "t", nil, "update tags")
 	cobraCmd.Flags().StringSliceVarP(&cmd.places, "places", "p", nil, "update places")
-	cobraCmd.Flags().StringVarP(&cmd.due, "due", "d", "", "set due date (format: YYYY-MM-DD)")
+	cobraCmd.Flags().StringVarP(&cmd.due, "due", "d", "", "set due date (YYYY-MM-DD or relative: today, tomorrow, fri, +3d, eom)")
 	cobraCmd.Flags().StringVar(&cmd.text, "text", "", "update task text content")
 
 	cmd.CobraCmd = cobraCmd
@@ -1172,7 +1697,7 @@ func newUpdateCommand(svc *svc.Svc) *cobra.Command {
 func (c *TasksUpdateCommand) ExecuteDirect(ctx context.Context, args []string, flags map[string]interface{}) error {
 	log.Printf("Executing update command directly")
 	id := args[0]
-	task, err := c.Svc.Repo.GetTaskByID(ctx, id)
+	task, err := c.Svc.ResolveID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("error fetching task: %w", err)
 	}
@@ -1186,7 +1711,7 @@ func (c *TasksUpdateCommand) ExecuteDirect(ctx context.Context, args []string, f
 	}
 
 	if c.due != "" {
-		dueDate, err := time.Parse("2006-01-02", c.due)
+		dueDate, err := svc.ParseDate(c.due)
 		if err != nil {
 			return fmt.Errorf("invalid due date format: %w", err)
 		}
@@ -1239,7 +1764,10 @@ func NewTasksCommand(svc *svc.Svc) *cobra.Command {
 
 	cmd.AddCommand(
 		newListCommand(svc),
+		newAddSubCommand(svc),
 		newStatusCommand(svc),
+		newPriorityCommand(svc),
+		newDependCommand(svc),
 		newUpdateCommand(svc),
 		newTextCommand(svc),
 		newTagCommand(svc),
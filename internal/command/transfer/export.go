@@ -0,0 +1,106 @@
+// Package transfer holds the export and import commands. Both work on the database file
+// directly: an export or import can be larger than what the daemon takes in one request.
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/adrianpk/tyn/internal/repo/sqlite"
+	"github.com/adrianpk/tyn/internal/svc"
+	"github.com/spf13/cobra"
+)
+
+type ExportCommand struct {
+	CobraCmd *cobra.Command
+	cfg      *config.Config
+	format   string
+	nodeType string
+	since    string
+	output   string
+}
+
+func NewExportCommand(cfg *config.Config) *cobra.Command {
+	cmd := &ExportCommand{cfg: cfg}
+
+	cobraCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export nodes, notifications and dependencies",
+		Long: `Export the knowledge base as JSON, or as JSON lines with one record per line.
+
+Every node is exported, including closed tasks, together with its notifications and the
+dependencies between exported nodes. The output can be read back with tn import.`,
+		Args: cobra.NoArgs,
+		RunE: func(cobra *cobra.Command, args []string) error {
+			return cmd.run(cobra)
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&cmd.format, "format", "f", svc.ExportFormatJSON, "output format: json or jsonl")
+	cobraCmd.Flags().StringVarP(&cmd.nodeType, "type", "t", "", "export only nodes of this type (note, task, link, draft)")
+	cobraCmd.Flags().StringVar(&cmd.since, "since", "", "export only nodes captured on or after this date (e.g. 2025-07-01 or yesterday)")
+	cobraCmd.Flags().StringVarP(&cmd.output, "output", "o", "", "write to this file instead of standard output")
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *ExportCommand) run(cmd *cobra.Command) error {
+	if !svc.ValidExportFormat(c.format) {
+		return fmt.Errorf("invalid format: %s (use json or jsonl)", c.format)
+	}
+
+	opts := svc.ExportOptions{Type: c.nodeType}
+	if c.since != "" {
+		since, err := svc.ParseDate(c.since)
+		if err != nil {
+			return fmt.Errorf("invalid --since date: %w", err)
+		}
+		opts.Since = &since
+	}
+
+	s, closeRepo, err := openSvc(c.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	export, err := s.Export(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if c.output != "" {
+		f, err := os.Create(c.output)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", c.output, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	err = svc.WriteExport(out, export, c.format)
+	if err != nil {
+		return fmt.Errorf("error writing export: %w", err)
+	}
+
+	if c.output != "" {
+		fmt.Printf("Exported %d node(s), %d notification(s) and %d dependency(ies) to %s\n",
+			len(export.Nodes), len(export.Notifications), len(export.Dependencies), c.output)
+	}
+
+	return nil
+}
+
+// openSvc opens the database directly and returns a service on top of it.
+func openSvc(cfg *config.Config) (*svc.Svc, func(), error) {
+	repo, err := sqlite.NewTynRepo(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	return svc.New(repo, cfg), func() { repo.Close() }, nil
+}
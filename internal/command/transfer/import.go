@@ -0,0 +1,143 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/adrianpk/tyn/internal/svc"
+	"github.com/spf13/cobra"
+)
+
+type ImportCommand struct {
+	CobraCmd *cobra.Command
+	cfg      *config.Config
+	conflict string
+	dryRun   bool
+}
+
+func NewImportCommand(cfg *config.Config) *cobra.Command {
+	cmd := &ImportCommand{cfg: cfg}
+
+	cobraCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import nodes from a tn export file",
+		Long: `Import a file written by tn export, in either format. Use - to read standard input.
+
+--conflict decides what happens to a node or notification whose ID is already taken:
+  skip       keep the existing record (default)
+  overwrite  replace the existing record with the imported one
+  new-id     import the record under a new ID; parents, notifications and dependencies follow it
+
+--dry-run lists what the import would change without writing anything.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cobra *cobra.Command, args []string) error {
+			return cmd.run(cobra, args[0])
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&cmd.conflict, "conflict", "c", svc.ConflictSkip, "what to do with existing IDs: skip, overwrite or new-id")
+	cobraCmd.Flags().BoolVar(&cmd.dryRun, "dry-run", false, "show what would change without writing anything")
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *ImportCommand) run(cmd *cobra.Command, path string) error {
+	if !svc.ValidConflictPolicy(c.conflict) {
+		return fmt.Errorf("invalid conflict policy: %s (use skip, overwrite or new-id)", c.conflict)
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %w", path, err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	export, err := svc.ReadExport(in)
+	if err != nil {
+		return err
+	}
+
+	s, closeRepo, err := openSvc(c.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	report, err := s.Import(cmd.Context(), export, svc.ImportOptions{Conflict: c.conflict, DryRun: c.dryRun})
+	if err != nil {
+		return err
+	}
+
+	printReport(report)
+	return nil
+}
+
+func printReport(report svc.ImportReport) {
+	if report.DryRun {
+		fmt.Println("Dry run: nothing was written.")
+		fmt.Println()
+
+		for _, change := range report.Changes {
+			if change.Action == svc.ImportUnchanged {
+				continue
+			}
+			printChange(change)
+		}
+		fmt.Println()
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Would import"
+	}
+
+	fmt.Printf("%s:\n", verb)
+	kinds := []struct{ kind, label string }{
+		{svc.RecordNode, "nodes"},
+		{svc.RecordNotification, "notifications"},
+		{svc.RecordDependency, "dependencies"},
+	}
+
+	for _, k := range kinds {
+		fmt.Printf("  %-14s %d created, %d overwritten, %d unchanged, %d skipped\n", k.label,
+			report.Count(k.kind, svc.ImportCreate),
+			report.Count(k.kind, svc.ImportOverwrite),
+			report.Count(k.kind, svc.ImportUnchanged),
+			report.Count(k.kind, svc.ImportSkip))
+	}
+}
+
+func printChange(change svc.ImportChange) {
+	id := shortIDs(change.ID)
+	if change.NewID != "" {
+		id += " as " + shortIDs(change.NewID)
+	}
+
+	detail := change.Summary
+	if change.Reason != "" {
+		detail = "(" + change.Reason + ")"
+	}
+	detail = strings.ReplaceAll(detail, "\n", " ")
+
+	fmt.Printf("  %-10s %-13s %-20s %s\n", change.Action, change.Kind, id, common.Truncate(detail, 50))
+}
+
+// shortIDs shortens the IDs in a change, which for dependencies reads "task -> depends on".
+func shortIDs(ids string) string {
+	parts := strings.Split(ids, " -> ")
+	for i, id := range parts {
+		if len(id) > 8 {
+			parts[i] = id[:8]
+		}
+	}
+	return strings.Join(parts, " -> ")
+}
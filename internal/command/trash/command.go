@@ -0,0 +1,204 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+	"github.com/spf13/cobra"
+)
+
+// NewRmCommand returns tn rm, which moves nodes to the trash.
+func NewRmCommand(s *svc.Svc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <id>...",
+		Short: "Move nodes to the trash",
+		Long: `Move nodes to the trash, with their subtasks. Tasks that depended on a removed task are
+unblocked. Restore nodes with tn trash restore; the daemon deletes them for good once they have
+been in the trash longer than trash_retention.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nodes, err := change(cmd.Context(), s, "rm", bkg.IDsParams{IDs: args},
+				func(ctx context.Context, direct *svc.Svc) ([]model.Node, error) {
+					return direct.TrashNodes(ctx, args)
+				})
+			if err != nil {
+				return err
+			}
+
+			printChanged(s, "Moved %d %s to the trash:", nodes)
+			return nil
+		},
+	}
+}
+
+// NewCommand returns the trash command group.
+func NewCommand(s *svc.Svc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore and empty the nodes removed with tn rm",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the nodes in the trash, most recently removed first",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nodes, err := change(cmd.Context(), s, "trash", bkg.TrashParams{Operation: "list"},
+				func(ctx context.Context, direct *svc.Svc) ([]model.Node, error) {
+					return direct.Trash(ctx)
+				})
+			if err != nil {
+				return err
+			}
+
+			printTrash(s, nodes)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "restore <id>...",
+		Short: "Take nodes out of the trash, with the subtasks removed along with them",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nodes, err := change(cmd.Context(), s, "trash", bkg.TrashParams{Operation: "restore", IDs: args},
+				func(ctx context.Context, direct *svc.Svc) ([]model.Node, error) {
+					return direct.RestoreNodes(ctx, args)
+				})
+			if err != nil {
+				return err
+			}
+
+			printChanged(s, "Restored %d %s:", nodes)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "empty",
+		Short: "Delete every node in the trash for good",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nodes, err := change(cmd.Context(), s, "trash", bkg.TrashParams{Operation: "empty"},
+				func(ctx context.Context, direct *svc.Svc) ([]model.Node, error) {
+					return direct.EmptyTrash(ctx, time.Time{})
+				})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Deleted %d %s for good.\n", len(nodes), plural(len(nodes)))
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// NewArchiveCommand returns tn archive, which hides nodes from lists and the journal.
+func NewArchiveCommand(s *svc.Svc) *cobra.Command {
+	return newArchiveCommand(s, true)
+}
+
+// NewUnarchiveCommand returns tn unarchive, which brings archived nodes back.
+func NewUnarchiveCommand(s *svc.Svc) *cobra.Command {
+	return newArchiveCommand(s, false)
+}
+
+func newArchiveCommand(s *svc.Svc, archive bool) *cobra.Command {
+	use, short, done := "archive", "Hide nodes and their subtasks from lists and the journal without deleting them", "Archived %d %s:"
+	if !archive {
+		use, short, done = "unarchive", "Bring archived nodes and their subtasks back", "Unarchived %d %s:"
+	}
+
+	return &cobra.Command{
+		Use:   use + " <id>...",
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			nodes, err := change(cmd.Context(), s, use, bkg.IDsParams{IDs: args},
+				func(ctx context.Context, direct *svc.Svc) ([]model.Node, error) {
+					return direct.ArchiveNodes(ctx, args, archive)
+				})
+			if err != nil {
+				return err
+			}
+
+			printChanged(s, done, nodes)
+			return nil
+		},
+	}
+}
+
+// change runs direct on the database, or command on the daemon, and returns the nodes either
+// answers with.
+func change(ctx context.Context, s *svc.Svc, command string, params interface{},
+	direct func(context.Context, *svc.Svc) ([]model.Node, error)) ([]model.Node, error) {
+	var nodes []model.Node
+	err := common.Run(s, func(d *svc.Svc) error {
+		var err error
+		nodes, err = direct(ctx, d)
+		return err
+	}, func() error {
+		return common.Send(command, params, &nodes)
+	})
+
+	return nodes, err
+}
+
+// printChanged prints format, which takes the number of nodes and the word node or nodes,
+// followed by one line per node.
+func printChanged(s *svc.Svc, format string, nodes []model.Node) {
+	if len(nodes) == 0 {
+		fmt.Println("Nothing changed.")
+		return
+	}
+
+	fmt.Printf(format+"\n", len(nodes), plural(len(nodes)))
+
+	short := common.ShortIDs(s, nodes)
+	idWidth := common.IDWidth(short)
+	for _, n := range nodes {
+		fmt.Printf("  %-*s %-6s %s\n", idWidth, short[n.ID], n.Type, content(n))
+	}
+}
+
+func printTrash(s *svc.Svc, nodes []model.Node) {
+	if len(nodes) == 0 {
+		fmt.Println("The trash is empty.")
+		return
+	}
+
+	short := common.ShortIDs(s, nodes)
+	idWidth := common.IDWidth(short)
+
+	fmt.Printf("%-*s %-6s %-16s %s\n", idWidth, "ID", "TYPE", "REMOVED", "CONTENT")
+	fmt.Println(strings.Repeat("-", idWidth+74))
+
+	for _, n := range nodes {
+		fmt.Printf("%-*s %-6s %-16s %s\n", idWidth, short[n.ID], n.Type, n.DeletedAt.Local().Format("2006-01-02 15:04"), content(n))
+	}
+}
+
+// content is the first 50 characters of a node's content, on one line.
+func content(n model.Node) string {
+	runes := []rune(strings.Join(strings.Fields(n.Content), " "))
+	if len(runes) > 50 {
+		return string(runes[:47]) + "..."
+	}
+	return string(runes)
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return "node"
+	}
+	return "nodes"
+}
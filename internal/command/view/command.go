@@ -0,0 +1,165 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/adrianpk/tyn/internal/svc"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand returns the view command group, which manages saved list queries.
+func NewCommand(s *svc.Svc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "view",
+		Aliases: []string{"views"},
+		Short:   "Save list queries under a name and run them with tn v",
+	}
+
+	cmd.AddCommand(newSaveCommand(s), newListCommand(s), newRmCommand(s))
+	return cmd
+}
+
+// NewRunCommand returns tn v, which lists the nodes a saved view matches.
+func NewRunCommand(s *svc.Svc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "v <name>",
+		Short: "List the nodes a saved view matches",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := send(cmd.Context(), s, bkg.ViewParams{Operation: "run", Name: args[0]},
+				func(ctx context.Context, direct *svc.Svc) (bkg.ViewResult, error) {
+					view, nodes, err := direct.RunView(ctx, args[0])
+					return bkg.ViewResult{Views: []model.View{view}, Nodes: nodes}, err
+				})
+			if err != nil {
+				return err
+			}
+
+			return common.PrintList(s, result.Nodes, func() {
+				common.PrintNodes(s, result.Nodes)
+			})
+		},
+	}
+}
+
+func newSaveCommand(s *svc.Svc) *cobra.Command {
+	var section string
+
+	cmd := &cobra.Command{
+		Use:   "save <name> <query>...",
+		Short: "Save a list query under a name, replacing the view saved under it before",
+		Long: `Save a list query under a name. The query is the one tn list takes, such as
+
+  tn view save urgent-office ':wip #urgent @office sort:due'
+
+and is run again each time, so relative dates like due<+7d move with the days. With --journal,
+the daily journal lists what the view matches in a section with that heading.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, query := args[0], strings.Join(args[1:], " ")
+
+			result, err := send(cmd.Context(), s, bkg.ViewParams{Operation: "save", Name: name, Query: query, Section: section},
+				func(ctx context.Context, direct *svc.Svc) (bkg.ViewResult, error) {
+					view, err := direct.SaveView(ctx, name, query, section)
+					return bkg.ViewResult{Views: []model.View{view}}, err
+				})
+			if err != nil {
+				return err
+			}
+
+			view := result.Views[0]
+			fmt.Printf("Saved view %s: %s\n", view.Name, view.Query)
+			if view.Section != "" {
+				fmt.Printf("Listed in the journal under %q.\n", view.Section)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&section, "journal", "j", "", "list the view in the journal under this heading")
+
+	return cmd
+}
+
+func newListCommand(s *svc.Svc) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the saved views",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := send(cmd.Context(), s, bkg.ViewParams{Operation: "list"},
+				func(ctx context.Context, direct *svc.Svc) (bkg.ViewResult, error) {
+					views, err := direct.Views(ctx)
+					return bkg.ViewResult{Views: views}, err
+				})
+			if err != nil {
+				return err
+			}
+
+			printViews(result.Views)
+			return nil
+		},
+	}
+}
+
+func newRmCommand(s *svc.Svc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Delete a saved view",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := send(cmd.Context(), s, bkg.ViewParams{Operation: "rm", Name: args[0]},
+				func(ctx context.Context, direct *svc.Svc) (bkg.ViewResult, error) {
+					view, err := direct.DeleteView(ctx, args[0])
+					return bkg.ViewResult{Views: []model.View{view}}, err
+				})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Deleted view %s.\n", result.Views[0].Name)
+			return nil
+		},
+	}
+}
+
+// send runs direct on the database, or the view command on the daemon, and returns the result
+// either answers with.
+func send(ctx context.Context, s *svc.Svc, params bkg.ViewParams,
+	direct func(context.Context, *svc.Svc) (bkg.ViewResult, error)) (bkg.ViewResult, error) {
+	var result bkg.ViewResult
+	err := common.Run(s, func(d *svc.Svc) error {
+		var err error
+		result, err = direct(ctx, d)
+		return err
+	}, func() error {
+		return common.Send("view", params, &result)
+	})
+
+	return result, err
+}
+
+func printViews(views []model.View) {
+	if len(views) == 0 {
+		fmt.Println("No saved views.")
+		return
+	}
+
+	nameWidth := len("NAME") + 1
+	for _, v := range views {
+		nameWidth = max(nameWidth, len(v.Name)+1)
+	}
+
+	fmt.Printf("%-*s %-50s %s\n", nameWidth, "NAME", "QUERY", "JOURNAL")
+	fmt.Println(strings.Repeat("-", nameWidth+70))
+
+	for _, v := range views {
+		fmt.Printf("%-*s %-50s %s\n", nameWidth, v.Name, common.Truncate(v.Query, 50), v.Section)
+	}
+}
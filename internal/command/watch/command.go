@@ -0,0 +1,105 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/bkg"
+	"github.com/adrianpk/tyn/internal/command/common"
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/spf13/cobra"
+)
+
+type WatchCommand struct {
+	CobraCmd *cobra.Command
+	nodeType string
+	json     bool
+}
+
+// NewCommand returns the watch command, which prints the daemon's events as they happen.
+func NewCommand() *cobra.Command {
+	cmd := &WatchCommand{}
+
+	cobraCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Print changes to nodes as they happen",
+		Long: `Print the daemon's events live: nodes created, updated and deleted, task status changes,
+overdue tasks and journal updates. Changes made with --direct do not go through the daemon and are not shown.`,
+		Args: cobra.NoArgs,
+		RunE: func(cobra *cobra.Command, args []string) error {
+			return cmd.run()
+		},
+	}
+
+	cobraCmd.Flags().StringVarP(&cmd.nodeType, "type", "t", "", "only show events for nodes of this type (note, task, link, draft)")
+	cobraCmd.Flags().BoolVar(&cmd.json, "json", false, "print each event as a line of JSON")
+
+	cmd.CobraCmd = cobraCmd
+	return cobraCmd
+}
+
+func (c *WatchCommand) run() error {
+	if c.nodeType != "" && !model.Type.Validate(c.nodeType) {
+		return fmt.Errorf("invalid type '%s', valid types are: %s", c.nodeType, strings.Join(model.Type.Values(), ", "))
+	}
+
+	if common.IsDirect() {
+		return fmt.Errorf("watch needs the daemon, which is not available")
+	}
+
+	client, err := bkg.Dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	err = client.Subscribe(model.EventFilter{NodeType: c.nodeType})
+	if err != nil {
+		return err
+	}
+
+	if !c.json {
+		fmt.Fprintln(os.Stderr, "Watching for changes, press Ctrl+C to stop.")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		e, err := client.NextEvent()
+		if err == io.EOF {
+			return fmt.Errorf("the daemon ended the event stream")
+		}
+		if err != nil {
+			return fmt.Errorf("error reading event: %w", err)
+		}
+
+		if c.json {
+			enc.Encode(e)
+			continue
+		}
+
+		fmt.Println(formatEvent(e))
+	}
+}
+
+func formatEvent(e model.Event) string {
+	line := fmt.Sprintf("%s  %-20s", e.Time.Local().Format("15:04:05"), e.Type)
+	if e.Node == nil {
+		return line
+	}
+
+	node := e.Node
+
+	kind := node.Type
+	if node.Type == model.Type.Task {
+		kind = "[" + node.Status + "]"
+	}
+	if e.Type == model.EventType.StatusChanged {
+		kind = fmt.Sprintf("[%s → %s]", e.PriorStatus, node.Status)
+	}
+
+	short := common.ShortIDs(nil, []model.Node{*node})[node.ID]
+	return fmt.Sprintf("%s  %-6s %-10s %s", line, short, kind, strings.Join(strings.Fields(node.Content), " "))
+}
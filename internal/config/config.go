@@ -6,26 +6,39 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	DoneTaskListDays      int           `yaml:"done_task_list_days"`
-	DatabaseBackupCount   int           `yaml:"database_backup_count"`
-	NotificationTimeout   time.Duration `yaml:"notification_timeout"`
-	JournalUpdateInterval time.Duration `yaml:"journal_update_interval"`
-	PollInterval          time.Duration `yaml:"poll_interval"`
+	DoneTaskListDays       int           `yaml:"done_task_list_days"`
+	DatabaseBackupCount    int           `yaml:"database_backup_count"`
+	DatabaseBackupInterval time.Duration `yaml:"database_backup_interval"`
+	NotificationTimeout    time.Duration `yaml:"notification_timeout"`
+	JournalUpdateInterval  time.Duration `yaml:"journal_update_interval"`
+	PollInterval           time.Duration `yaml:"poll_interval"`
+	// HTTPAddress is the loopback address of the daemon's HTTP API, such as 127.0.0.1:7531.
+	// The API is off when it is empty.
+	HTTPAddress string `yaml:"http_address"`
+	// TrashRetention is how long nodes stay in the trash before the daemon deletes them for
+	// good. They stay until the trash is emptied when it is 0.
+	TrashRetention time.Duration `yaml:"trash_retention"`
 }
 
+// mu guards every Config that Reload updates: the daemon reloads it while requests read it.
+var mu sync.RWMutex
+
 func DefaultConfig() Config {
 	return Config{
-		DoneTaskListDays:      14,
-		DatabaseBackupCount:   2,
-		NotificationTimeout:   5 * time.Second,
-		JournalUpdateInterval: 1 * time.Minute,
-		PollInterval:          30 * time.Second,
+		DoneTaskListDays:       14,
+		DatabaseBackupCount:    2,
+		DatabaseBackupInterval: 24 * time.Hour,
+		NotificationTimeout:    5 * time.Second,
+		JournalUpdateInterval:  1 * time.Minute,
+		PollInterval:           30 * time.Second,
+		TrashRetention:         30 * 24 * time.Hour,
 	}
 }
 
@@ -44,35 +57,110 @@ func ensureConfigFile(path string, cfg Config) error {
 }
 
 func Load() *Config {
-	cfg := DefaultConfig()
+	cfg := readFile()
 
-	home, err := os.UserHomeDir()
-	if err == nil {
-		configPath := filepath.Join(home, ".config", "tyn", "tyn.yml")
-		err := ensureConfigFile(configPath, cfg)
-		if err == nil {
-			if data, err := ioutil.ReadFile(configPath); err == nil {
-				yaml.Unmarshal(data, &cfg)
-			}
-		}
-	}
 	doneTaskListDays := flag.Int("done-task-list-days", intVal("TYN_DONE_TASK_LIST_DAYS", cfg.DoneTaskListDays), "How many days of done tasks to show in lists (default: 14)")
 	dbBackupCount := flag.Int("db-backup-count", intVal("TYN_DB_BACKUP_COUNT", cfg.DatabaseBackupCount), "How many automatic database backups to keep (default: 2)")
+	dbBackupInterval := flag.Duration("db-backup-interval", durationVal("TYN_DB_BACKUP_INTERVAL", cfg.DatabaseBackupInterval), "How often the daemon backs up the database (e.g. 12h, 24h)")
 	notificationTimeout := flag.Duration("notification-timeout", durationVal("TYN_NOTIFICATION_TIMEOUT", cfg.NotificationTimeout), "Notification timeout (e.g. 5s, 10s)")
 	journalUpdateInterval := flag.Duration("journal-update-interval", durationVal("TYN_JOURNAL_UPDATE_INTERVAL", cfg.JournalUpdateInterval), "How often to update the journal (e.g. 1m, 10m)")
 	pollInterval := flag.Duration("poll-interval", durationVal("TYN_POLL_INTERVAL", cfg.PollInterval), "How often to poll for notifications and periodic tasks (e.g. 30s, 60s)")
+	trashRetention := flag.Duration("trash-retention", durationVal("TYN_TRASH_RETENTION", cfg.TrashRetention), "How long deleted nodes stay in the trash (e.g. 168h, 720h); 0 keeps them")
+	httpAddress := flag.String("http-address", envVal("TYN_HTTP_ADDRESS", cfg.HTTPAddress), "Loopback address for the daemon's HTTP API (e.g. 127.0.0.1:7531); empty turns it off")
 
 	flag.Parse()
 
 	cfg.DoneTaskListDays = *doneTaskListDays
 	cfg.DatabaseBackupCount = *dbBackupCount
+	cfg.DatabaseBackupInterval = *dbBackupInterval
 	cfg.NotificationTimeout = *notificationTimeout
 	cfg.JournalUpdateInterval = *journalUpdateInterval
 	cfg.PollInterval = *pollInterval
+	cfg.TrashRetention = *trashRetention
+	cfg.HTTPAddress = *httpAddress
 
 	return &cfg
 }
 
+// Snapshot returns a copy of cfg that a concurrent Reload does not change. Code that may run
+// while the daemon reloads reads the configuration through it.
+func (cfg *Config) Snapshot() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return *cfg
+}
+
+// Reload reads the configuration file and environment again and updates cfg in place, so
+// everything holding cfg sees the new values. Flags given on the command line still win.
+func Reload(cfg *Config) {
+	fresh := readFile()
+	fresh.DoneTaskListDays = intVal("TYN_DONE_TASK_LIST_DAYS", fresh.DoneTaskListDays)
+	fresh.DatabaseBackupCount = intVal("TYN_DB_BACKUP_COUNT", fresh.DatabaseBackupCount)
+	fresh.DatabaseBackupInterval = durationVal("TYN_DB_BACKUP_INTERVAL", fresh.DatabaseBackupInterval)
+	fresh.NotificationTimeout = durationVal("TYN_NOTIFICATION_TIMEOUT", fresh.NotificationTimeout)
+	fresh.JournalUpdateInterval = durationVal("TYN_JOURNAL_UPDATE_INTERVAL", fresh.JournalUpdateInterval)
+	fresh.PollInterval = durationVal("TYN_POLL_INTERVAL", fresh.PollInterval)
+	fresh.TrashRetention = durationVal("TYN_TRASH_RETENTION", fresh.TrashRetention)
+	fresh.HTTPAddress = envVal("TYN_HTTP_ADDRESS", fresh.HTTPAddress)
+
+	flag.Visit(func(f *flag.Flag) {
+		getter, ok := f.Value.(flag.Getter)
+		if !ok {
+			return
+		}
+
+		switch v := getter.Get().(type) {
+		case string:
+			if f.Name == "http-address" {
+				fresh.HTTPAddress = v
+			}
+		case int:
+			switch f.Name {
+			case "done-task-list-days":
+				fresh.DoneTaskListDays = v
+			case "db-backup-count":
+				fresh.DatabaseBackupCount = v
+			}
+		case time.Duration:
+			switch f.Name {
+			case "db-backup-interval":
+				fresh.DatabaseBackupInterval = v
+			case "notification-timeout":
+				fresh.NotificationTimeout = v
+			case "journal-update-interval":
+				fresh.JournalUpdateInterval = v
+			case "poll-interval":
+				fresh.PollInterval = v
+			case "trash-retention":
+				fresh.TrashRetention = v
+			}
+		}
+	})
+
+	mu.Lock()
+	*cfg = fresh
+	mu.Unlock()
+}
+
+// readFile returns the defaults overridden by ~/.config/tyn/tyn.yml, which is created with
+// the defaults if it does not exist yet.
+func readFile() Config {
+	cfg := DefaultConfig()
+
+	home, err := os.UserHomeDir()
+	if err == nil {
+		configPath := filepath.Join(home, ".config", "tyn", "tyn.yml")
+		err := ensureConfigFile(configPath, cfg)
+		if err == nil {
+			if data, err := ioutil.ReadFile(configPath); err == nil {
+				yaml.Unmarshal(data, &cfg)
+			}
+		}
+	}
+
+	return cfg
+}
+
 func envVal(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
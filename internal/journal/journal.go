@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/adrianpk/tyn/internal/model"
@@ -23,7 +24,8 @@ const (
 )
 
 type Generator struct {
-	repo JournalRepo
+	repo  JournalRepo
+	views ViewSource
 }
 
 type JournalRepo interface {
@@ -32,9 +34,23 @@ type JournalRepo interface {
 	GetNotesAndLinksByDay(day time.Time) ([]model.Node, error)
 }
 
-func New(repo JournalRepo) *Generator {
+// ViewSource lists the saved views and the nodes they match. Views with a section are added
+// to the journal under it.
+type ViewSource interface {
+	Views(ctx context.Context) ([]model.View, error)
+	ViewNodes(ctx context.Context, view model.View) ([]model.Node, error)
+}
+
+// viewSection is a saved view rendered as a journal section.
+type viewSection struct {
+	title string
+	nodes []model.Node
+}
+
+func New(repo JournalRepo, views ViewSource) *Generator {
 	return &Generator{
-		repo: repo,
+		repo:  repo,
+		views: views,
 	}
 }
 
@@ -68,7 +84,12 @@ func (g *Generator) GenerateDaily() error {
 	}
 	log.Printf("Journal: Filtered %d notes and %d links for today", len(notes), len(links))
 
-	content := genMarkdownContent(today, allTasks, notes, links)
+	sections, err := g.viewSections(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching view sections: %w", err)
+	}
+
+	content := genMarkdownContent(today, allTasks, notes, links, sections)
 
 	journalPath, err := saveJournal(today, content)
 	if err != nil {
@@ -84,6 +105,33 @@ func (g *Generator) GenerateDaily() error {
 	return nil
 }
 
+// viewSections runs the saved views that have a journal section. A view whose query no longer
+// parses is logged and left out, so it does not hold back the rest of the journal.
+func (g *Generator) viewSections(ctx context.Context) ([]viewSection, error) {
+	views, err := g.views.Views(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []viewSection
+	for _, view := range views {
+		if view.Section == "" {
+			continue
+		}
+
+		nodes, err := g.views.ViewNodes(ctx, view)
+		if err != nil {
+			log.Printf("Journal: Skipping view %q: %v", view.Name, err)
+			continue
+		}
+
+		sections = append(sections, viewSection{title: view.Section, nodes: nodes})
+	}
+	log.Printf("Journal: Found %d view sections", len(sections))
+
+	return sections, nil
+}
+
 func (g *Generator) UpdateIndex(today time.Time) error {
 	log.Println("Journal: Updating index file...")
 
@@ -203,7 +251,7 @@ func saveIndex(content string) (string, error) {
 	return filePath, nil
 }
 
-func genMarkdownContent(day time.Time, tasks, notes, links []model.Node) string {
+func genMarkdownContent(day time.Time, tasks, notes, links []model.Node, sections []viewSection) string {
 	header := fmt.Sprintf("# %s\n\n", day.Format("060102"))
 
 	tasksSection := "## Tasks\n\n"
@@ -213,8 +261,20 @@ func genMarkdownContent(day time.Time, tasks, notes, links []model.Node) string
 		log.Printf("Journal: StatusCycle[%d] = %q", i, status)
 	}
 
+	present := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		present[task.ID] = true
+	}
+
+	// Subtasks are nested under their parent instead of listed in their own status section
+	children := make(map[string][]model.Node)
 	tasksByStatus := make(map[string][]model.Node)
 	for _, task := range tasks {
+		if task.ParentID != "" && present[task.ParentID] {
+			children[task.ParentID] = append(children[task.ParentID], task)
+			continue
+		}
+
 		status := task.Status
 		if status == "" {
 			status = model.Status.Todo
@@ -227,6 +287,8 @@ func genMarkdownContent(day time.Time, tasks, notes, links []model.Node) string
 		log.Printf("Journal: Status %q has %d tasks", status, len(tasks))
 	}
 
+	progress := model.ChildProgress(tasks)
+
 	if len(tasks) > 0 {
 		for _, statusValue := range model.StatusCycle {
 			statusTasks, exists := tasksByStatus[statusValue]
@@ -240,29 +302,20 @@ func genMarkdownContent(day time.Time, tasks, notes, links []model.Node) string
 				statusValue, len(statusTasks))
 			tasksSection += fmt.Sprintf("### %s\n\n", model.Status.Label(statusValue))
 
-			for _, task := range statusTasks {
-				checkMark := " "
-				if task.Status == model.Status.Done {
-					checkMark = "x"
-				}
-
-				taskLine := fmt.Sprintf("- [%s] %s", checkMark, task.Content)
-
-				if task.IsOverdue() {
-					taskLine += " ⌛️"
-				}
+			model.SortByPriority(statusTasks)
+			grouped := hasPriorities(statusTasks)
+			currentPriority := -1
 
-				if len(task.Tags) > 0 {
-					taskLine += " "
-					for i, tag := range task.Tags {
-						taskLine += fmt.Sprintf("`#%s`", tag)
-						if i < len(task.Tags)-1 {
-							taskLine += " "
-						}
+			for _, task := range statusTasks {
+				if grouped && task.Priority != currentPriority {
+					if currentPriority != -1 {
+						tasksSection += "\n"
 					}
+					currentPriority = task.Priority
+					tasksSection += fmt.Sprintf("#### %s\n\n", priorityHeading(task.Priority))
 				}
 
-				tasksSection += taskLine + "\n"
+				tasksSection += genTaskLines(task, children, progress, 0, map[string]bool{})
 			}
 			tasksSection += "\n"
 		}
@@ -270,10 +323,16 @@ func genMarkdownContent(day time.Time, tasks, notes, links []model.Node) string
 		tasksSection += "No tasks found.\n\n"
 	}
 
+	viewsSection := ""
+	for _, section := range sections {
+		viewsSection += genViewSection(section)
+	}
+
 	notesSection := "## Notes\n\n"
 	if len(notes) > 0 {
 		for _, note := range notes {
-			notesSection += fmt.Sprintf("- %s\n", note.Content)
+			first, rest := splitContent(note.Content, 0)
+			notesSection += fmt.Sprintf("- %s\n%s", first, rest)
 		}
 	} else {
 		notesSection += "No notes recorded today.\n"
@@ -283,13 +342,126 @@ func genMarkdownContent(day time.Time, tasks, notes, links []model.Node) string
 	linksSection := "## Links\n\n"
 	if len(links) > 0 {
 		for _, link := range links {
-			linksSection += fmt.Sprintf("- [%s](%s)\n", link.Content, link.Link)
+			first, rest := splitContent(link.Content, 0)
+			linksSection += fmt.Sprintf("- [%s](%s)\n%s", first, link.Link, rest)
 		}
 	} else {
 		linksSection += "No links recorded today.\n"
 	}
 
-	return header + tasksSection + notesSection + linksSection
+	return header + tasksSection + viewsSection + notesSection + linksSection
+}
+
+// genViewSection renders the nodes of a saved view in the order its query gives them: tasks as
+// checklist items, notes and drafts as list items and links as list items linking to them.
+func genViewSection(section viewSection) string {
+	content := fmt.Sprintf("## %s\n\n", section.title)
+	if len(section.nodes) == 0 {
+		return content + "Nothing matches this view.\n\n"
+	}
+
+	for _, node := range section.nodes {
+		switch node.Type {
+		case model.Type.Task:
+			content += genTaskLines(node, nil, nil, 0, map[string]bool{})
+		case model.Type.Link:
+			first, rest := splitContent(node.Content, 0)
+			content += fmt.Sprintf("- [%s](%s)\n%s", first, node.Link, rest)
+		default:
+			first, rest := splitContent(node.Content, 0)
+			content += fmt.Sprintf("- %s\n%s", first, rest)
+		}
+	}
+
+	return content + "\n"
+}
+
+// genTaskLines renders a task as a checklist item followed by its subtasks as nested items.
+func genTaskLines(task model.Node, children map[string][]model.Node, progress map[string]model.Progress, depth int, seen map[string]bool) string {
+	if seen[task.ID] {
+		return ""
+	}
+	seen[task.ID] = true
+
+	checkMark := " "
+	if task.Status == model.Status.Done {
+		checkMark = "x"
+	}
+
+	first, rest := splitContent(task.Content, depth)
+	taskLine := fmt.Sprintf("%s- [%s] %s", strings.Repeat("  ", depth), checkMark, first)
+
+	if p, ok := progress[task.ID]; ok {
+		taskLine += fmt.Sprintf(" (%s)", p)
+	}
+
+	if depth > 0 && task.Status != model.Status.Done && task.Status != "" && task.Status != model.Status.Todo {
+		taskLine += fmt.Sprintf(" _%s_", model.Status.Label(task.Status))
+	}
+
+	if task.IsOverdue() {
+		taskLine += " ⌛️"
+	}
+
+	if task.Recurrence != "" {
+		taskLine += fmt.Sprintf(" 🔁 `*%s`", task.Recurrence)
+	}
+
+	if len(task.Tags) > 0 {
+		taskLine += " "
+		for i, tag := range task.Tags {
+			taskLine += fmt.Sprintf("`#%s`", tag)
+			if i < len(task.Tags)-1 {
+				taskLine += " "
+			}
+		}
+	}
+
+	lines := taskLine + "\n" + rest
+
+	subtasks := children[task.ID]
+	model.SortByPriority(subtasks)
+	for _, child := range subtasks {
+		lines += genTaskLines(child, children, progress, depth+1, seen)
+	}
+
+	return lines
+}
+
+// splitContent returns the first line of content, for a list item at depth, and the lines after
+// it indented to continue the item, each ending in a newline.
+func splitContent(content string, depth int) (string, string) {
+	lines := strings.Split(content, "\n")
+	indent := strings.Repeat("  ", depth+1)
+
+	var rest strings.Builder
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, " \t\r")
+		if line != "" {
+			rest.WriteString(indent)
+		}
+		rest.WriteString(line)
+		rest.WriteString("\n")
+	}
+
+	return lines[0], rest.String()
+}
+
+// hasPriorities reports whether any task has a priority, so sections without priorities stay flat.
+func hasPriorities(tasks []model.Node) bool {
+	for _, task := range tasks {
+		if task.Priority != model.Priority.None {
+			return true
+		}
+	}
+	return false
+}
+
+func priorityHeading(priority int) string {
+	if priority == model.Priority.None {
+		return "No Priority"
+	}
+	return fmt.Sprintf("P%d %s", priority, model.Priority.Label(priority))
 }
 
 func saveJournal(day time.Time, content string) (string, error) {
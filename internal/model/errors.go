@@ -0,0 +1,35 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of error that callers, such as the daemon's clients, tell apart with errors.Is.
+var (
+	// ErrNotFound means no node has the requested ID.
+	ErrNotFound = errors.New("not found")
+	// ErrAmbiguousID means an ID prefix matches more than one node.
+	ErrAmbiguousID = errors.New("ambiguous ID")
+	// ErrInvalid means the input was rejected: an unknown status, a malformed date, a task depending on itself.
+	ErrInvalid = errors.New("invalid input")
+)
+
+// Errorf formats an error of the given kind. Its message is only the formatted text, so
+// classifying an error does not change what users read.
+func Errorf(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+type eventTypeVal struct {
+	NodeCreated      string
+	NodeUpdated      string
+	NodeDeleted      string
+	StatusChanged    string
+	TaskOverdue      string
+	JournalGenerated string
+}
+
+// EventType lists the changes the daemon reports to subscribers.
+var EventType = eventTypeVal{
+	NodeCreated:      "node.created",
+	NodeUpdated:      "node.updated",
+	NodeDeleted:      "node.deleted",
+	StatusChanged:    "task.status_changed",
+	TaskOverdue:      "task.overdue",
+	JournalGenerated: "journal.generated",
+}
+
+func (e eventTypeVal) Values() []string {
+	return []string{
+		e.NodeCreated,
+		e.NodeUpdated,
+		e.NodeDeleted,
+		e.StatusChanged,
+		e.TaskOverdue,
+		e.JournalGenerated,
+	}
+}
+
+func (e eventTypeVal) Validate(v string) bool {
+	for _, t := range e.Values() {
+		if t == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Event is a change reported by the daemon. Node is the node it affects; journal events have none.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Node *Node     `json:"node,omitempty"`
+	// PriorStatus is the status a task had before a status change.
+	PriorStatus string `json:"prior_status,omitempty"`
+}
+
+// EventFilter selects events. Empty fields match everything.
+type EventFilter struct {
+	Types    []string `json:"types,omitempty"`
+	NodeType string   `json:"node_type,omitempty"`
+}
+
+// Matches reports whether e passes the filter. An event without a node never matches a node type.
+func (f EventFilter) Matches(e Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == e.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.NodeType != "" {
+		return e.Node != nil && e.Node.Type == f.NodeType
+	}
+
+	return true
+}
@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+func TestEventFilterMatches(t *testing.T) {
+	task := &Node{ID: "t", Type: Type.Task}
+	note := &Node{ID: "n", Type: Type.Note}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		event  Event
+		want   bool
+	}{
+		{"empty filter", EventFilter{}, Event{Type: EventType.JournalGenerated}, true},
+		{"type listed", EventFilter{Types: []string{EventType.NodeCreated, EventType.NodeDeleted}}, Event{Type: EventType.NodeDeleted, Node: note}, true},
+		{"type not listed", EventFilter{Types: []string{EventType.NodeCreated}}, Event{Type: EventType.NodeUpdated, Node: note}, false},
+		{"node type", EventFilter{NodeType: Type.Task}, Event{Type: EventType.NodeUpdated, Node: task}, true},
+		{"other node type", EventFilter{NodeType: Type.Task}, Event{Type: EventType.NodeUpdated, Node: note}, false},
+		{"node type without node", EventFilter{NodeType: Type.Task}, Event{Type: EventType.JournalGenerated}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
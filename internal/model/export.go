@@ -0,0 +1,123 @@
+package model
+
+import "time"
+
+// ExportVersion is the version of the export file format written by tn export.
+const ExportVersion = 1
+
+// Dependency records that a task waits for another node to be closed.
+type Dependency struct {
+	TaskID    string `json:"task_id"`
+	DependsOn string `json:"depends_on"`
+}
+
+// Export is a snapshot of nodes, their notifications and their dependencies,
+// as written by tn export and read by tn import.
+type Export struct {
+	Version       int                  `json:"version"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	Nodes         []ExportNode         `json:"nodes"`
+	Notifications []ExportNotification `json:"notifications"`
+	Dependencies  []Dependency         `json:"dependencies"`
+}
+
+// ExportNode is the file representation of a Node. It has its own field names so the
+// export format stays stable when Node changes.
+type ExportNode struct {
+	ID          string     `json:"id"`
+	Type        string     `json:"type"`
+	Content     string     `json:"content"`
+	Link        string     `json:"link,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Places      []string   `json:"places,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Draft       string     `json:"draft,omitempty"`
+	Date        time.Time  `json:"date"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty"`
+	Priority    int        `json:"priority,omitempty"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	PriorStatus string     `json:"prior_status,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ImportBatch is what an import writes: new nodes, nodes that replace existing ones, the IDs of
+// notifications replaced by ones in Notifications, and dependencies. It is written all at once.
+type ImportBatch struct {
+	Create        []Node
+	Overwrite     []Node
+	Replaced      []string
+	Notifications []Notification
+	Dependencies  []Dependency
+}
+
+type ExportNotification struct {
+	ID               string    `json:"id"`
+	NodeID           string    `json:"node_id"`
+	NotificationType string    `json:"notification_type"`
+	LastNotifiedAt   time.Time `json:"last_notified_at"`
+	TimesNotified    int       `json:"times_notified"`
+}
+
+func NewExportNode(n Node) ExportNode {
+	return ExportNode{
+		ID:          n.ID,
+		Type:        n.Type,
+		Content:     n.Content,
+		Link:        n.Link,
+		Tags:        n.Tags,
+		Places:      n.Places,
+		Status:      n.Status,
+		Draft:       n.Draft,
+		Date:        n.Date,
+		DueDate:     n.DueDate,
+		Recurrence:  n.Recurrence,
+		Priority:    n.Priority,
+		ParentID:    n.ParentID,
+		PriorStatus: n.PriorStatus,
+		ArchivedAt:  n.ArchivedAt,
+		DeletedAt:   n.DeletedAt,
+	}
+}
+
+func (e ExportNode) Node() Node {
+	return Node{
+		ID:          e.ID,
+		Type:        e.Type,
+		Content:     e.Content,
+		Link:        e.Link,
+		Tags:        e.Tags,
+		Places:      e.Places,
+		Status:      e.Status,
+		Draft:       e.Draft,
+		Date:        e.Date,
+		DueDate:     e.DueDate,
+		Recurrence:  e.Recurrence,
+		Priority:    e.Priority,
+		ParentID:    e.ParentID,
+		PriorStatus: e.PriorStatus,
+		ArchivedAt:  e.ArchivedAt,
+		DeletedAt:   e.DeletedAt,
+	}
+}
+
+func NewExportNotification(n Notification) ExportNotification {
+	return ExportNotification{
+		ID:               n.ID,
+		NodeID:           n.NodeID,
+		NotificationType: n.NotificationType,
+		LastNotifiedAt:   n.LastNotifiedAt,
+		TimesNotified:    n.TimesNotified,
+	}
+}
+
+func (e ExportNotification) Notification() Notification {
+	return Notification{
+		ID:               e.ID,
+		NodeID:           e.NodeID,
+		NotificationType: e.NotificationType,
+		LastNotifiedAt:   e.LastNotifiedAt,
+		TimesNotified:    e.TimesNotified,
+	}
+}
@@ -19,22 +19,39 @@ var NodeType = struct {
 }
 
 type Node struct {
-	ID      string
-	Type    string
-	Content string
-	Link    string
-	Tags    []string
-	Places  []string
-	Status  string
-	Draft   string
-	Date    time.Time
-	DueDate *time.Time
+	ID         string
+	Type       string
+	Content    string
+	Link       string
+	Tags       []string
+	Places     []string
+	Status     string
+	Draft      string
+	Date       time.Time
+	DueDate    *time.Time
+	Recurrence string
+	Priority   int
+	ParentID   string
+	// PriorStatus is the status a task had before it was blocked by its dependencies.
+	PriorStatus string
+	// ArchivedAt is set while the node is archived, hidden from lists and the journal.
+	ArchivedAt *time.Time
+	// DeletedAt is set while the node is in the trash.
+	DeletedAt *time.Time
 }
 
 func (n *Node) GenID() {
 	n.ID = uuid.NewString()
 }
 
+func (n *Node) IsArchived() bool {
+	return n.ArchivedAt != nil
+}
+
+func (n *Node) IsTrashed() bool {
+	return n.DeletedAt != nil
+}
+
 func (n *Node) IsOverdue() bool {
 	if n.Type != NodeType.Task || n.DueDate == nil {
 		return false
@@ -47,6 +64,14 @@ func (n *Node) IsOverdue() bool {
 	return time.Now().After(*n.DueDate)
 }
 
+// FormatDueDate omits the time of day for dates that resolve to midnight.
+func FormatDueDate(date time.Time) string {
+	if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 {
+		return date.Format("2006-01-02")
+	}
+	return date.Format("2006-01-02 15:04")
+}
+
 func (n *Node) ShortID() string {
 	if len(n.ID) < 4 {
 		return n.ID
@@ -59,4 +84,8 @@ type Filter struct {
 	Tags   []string
 	Places []string
 	Status string
+	// Archived lists archived nodes instead of the others.
+	Archived bool
+	// Query narrows the nodes down further and may set their order.
+	Query Query
 }
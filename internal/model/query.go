@@ -0,0 +1,69 @@
+package model
+
+// Query is a parsed list query: the condition nodes must meet and the order to list them in.
+type Query struct {
+	// Where is nil when any node matches.
+	Where *Cond
+	Sort  []SortKey
+}
+
+// Cond is a node of a query condition tree. CondAnd, CondOr and CondNot combine Args; the
+// comparison operators compare Field with Value.
+type Cond struct {
+	Op    string
+	Args  []*Cond
+	Field string
+	// Value is a name for labels, status and type, a number for priority, a date in
+	// DateTimeFormat (UTC) for due and created, and a substring for text. An empty due date
+	// matches nodes without one.
+	Value string
+}
+
+// SortKey orders query results by Field, descending when Desc is set.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// Condition operators.
+const (
+	CondAnd = "and"
+	CondOr  = "or"
+	CondNot = "not"
+	CondEq  = "="
+	CondLt  = "<"
+	CondLe  = "<="
+	CondGt  = ">"
+	CondGe  = ">="
+)
+
+// Query fields, for conditions and sorting.
+const (
+	FieldType     = "type"
+	FieldStatus   = "status"
+	FieldTag      = "tag"
+	FieldPlace    = "place"
+	FieldPriority = "priority"
+	FieldDue      = "due"
+	FieldCreated  = "created"
+	FieldText     = "text"
+)
+
+// All returns a condition that holds when all of conds do, or nil when there are none.
+func All(conds ...*Cond) *Cond {
+	var args []*Cond
+	for _, c := range conds {
+		if c != nil {
+			args = append(args, c)
+		}
+	}
+
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0]
+	default:
+		return &Cond{Op: CondAnd, Args: args}
+	}
+}
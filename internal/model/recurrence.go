@@ -0,0 +1,148 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is a parsed recurrence rule. Supported rules are daily, weekly, weekly:<weekday>,
+// monthly, monthly:<day>, yearly and every-<n><d|w|m|y>, e.g. every-3d.
+type Recurrence struct {
+	Unit     string
+	Interval int
+	Weekday  *time.Weekday
+	MonthDay int
+}
+
+var RecurrenceUnit = struct {
+	Day   string
+	Week  string
+	Month string
+	Year  string
+}{
+	Day:   "d",
+	Week:  "w",
+	Month: "m",
+	Year:  "y",
+}
+
+var everyPattern = regexp.MustCompile(`^every-(\d+)([dwmy])$`)
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func ParseRecurrence(rule string) (Recurrence, error) {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+	name, arg, hasArg := strings.Cut(rule, ":")
+
+	switch name {
+	case "daily":
+		if hasArg {
+			return Recurrence{}, fmt.Errorf("daily recurrence takes no argument: %s", rule)
+		}
+		return Recurrence{Unit: RecurrenceUnit.Day, Interval: 1}, nil
+
+	case "weekly":
+		r := Recurrence{Unit: RecurrenceUnit.Week, Interval: 1}
+		if hasArg {
+			wd, ok := recurrenceWeekdays[arg]
+			if !ok {
+				return Recurrence{}, fmt.Errorf("invalid weekday in recurrence: %s", rule)
+			}
+			r.Weekday = &wd
+		}
+		return r, nil
+
+	case "monthly":
+		r := Recurrence{Unit: RecurrenceUnit.Month, Interval: 1}
+		if hasArg {
+			day, err := strconv.Atoi(arg)
+			if err != nil || day < 1 || day > 31 {
+				return Recurrence{}, fmt.Errorf("invalid day of month in recurrence: %s", rule)
+			}
+			r.MonthDay = day
+		}
+		return r, nil
+
+	case "yearly":
+		if hasArg {
+			return Recurrence{}, fmt.Errorf("yearly recurrence takes no argument: %s", rule)
+		}
+		return Recurrence{Unit: RecurrenceUnit.Year, Interval: 1}, nil
+	}
+
+	m := everyPattern.FindStringSubmatch(rule)
+	if m == nil {
+		return Recurrence{}, fmt.Errorf("unknown recurrence rule: %s", rule)
+	}
+
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n < 1 {
+		return Recurrence{}, fmt.Errorf("invalid interval in recurrence: %s", rule)
+	}
+
+	return Recurrence{Unit: m[2], Interval: n}, nil
+}
+
+// Next returns the first occurrence strictly after from, keeping its time of day.
+func (r Recurrence) Next(from time.Time) time.Time {
+	switch r.Unit {
+	case RecurrenceUnit.Day:
+		return from.AddDate(0, 0, r.Interval)
+
+	case RecurrenceUnit.Week:
+		if r.Weekday == nil {
+			return from.AddDate(0, 0, 7*r.Interval)
+		}
+		diff := (int(*r.Weekday) - int(from.Weekday()) + 7) % 7
+		if diff == 0 {
+			diff = 7
+		}
+		return from.AddDate(0, 0, diff)
+
+	case RecurrenceUnit.Month:
+		if r.MonthDay == 0 {
+			return addMonths(from, r.Interval, from.Day())
+		}
+		next := addMonths(from, 0, r.MonthDay)
+		if !next.After(from) {
+			next = addMonths(from, r.Interval, r.MonthDay)
+		}
+		return next
+
+	case RecurrenceUnit.Year:
+		return addMonths(from, 12*r.Interval, from.Day())
+	}
+
+	return from
+}
+
+// NextAfter rolls the occurrence forward from due until it is later than now,
+// so completing a long overdue task does not create an occurrence that is already overdue.
+func (r Recurrence) NextAfter(due, now time.Time) time.Time {
+	next := r.Next(due)
+	for !next.After(now) {
+		next = r.Next(next)
+	}
+	return next
+}
+
+// addMonths moves t by n months and sets its day, clamped to the length of the target month.
+func addMonths(t time.Time, n, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		wantErr bool
+	}{
+		{name: "Daily", rule: "daily"},
+		{name: "Weekly", rule: "weekly"},
+		{name: "Weekly on Monday", rule: "weekly:mon"},
+		{name: "Monthly on the 15th", rule: "monthly:15"},
+		{name: "Yearly", rule: "yearly"},
+		{name: "Every three days", rule: "every-3d"},
+		{name: "Invalid weekday", rule: "weekly:xyz", wantErr: true},
+		{name: "Invalid day of month", rule: "monthly:32", wantErr: true},
+		{name: "Zero interval", rule: "every-0d", wantErr: true},
+		{name: "Unknown rule", rule: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRecurrence(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRecurrence(%q) error = %v; wantErr %v", tt.rule, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, 7, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rule     string
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Daily",
+			rule:     "daily",
+			from:     from,
+			expected: time.Date(2025, 7, 3, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Weekly",
+			rule:     "weekly",
+			from:     from,
+			expected: time.Date(2025, 7, 9, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Weekly on Monday",
+			rule:     "weekly:mon",
+			from:     from,
+			expected: time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Weekly on the same weekday",
+			rule:     "weekly:wed",
+			from:     from,
+			expected: time.Date(2025, 7, 9, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly on a later day",
+			rule:     "monthly:15",
+			from:     from,
+			expected: time.Date(2025, 7, 15, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly on an earlier day",
+			rule:     "monthly:1",
+			from:     from,
+			expected: time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Monthly clamps to month length",
+			rule:     "monthly",
+			from:     time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Every three days",
+			rule:     "every-3d",
+			from:     from,
+			expected: time.Date(2025, 7, 5, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Every two weeks",
+			rule:     "every-2w",
+			from:     from,
+			expected: time.Date(2025, 7, 16, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Yearly",
+			rule:     "yearly",
+			from:     from,
+			expected: time.Date(2026, 7, 2, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q) error = %v", tt.rule, err)
+			}
+
+			result := r.Next(tt.from)
+			if !result.Equal(tt.expected) {
+				t.Errorf("Next(%v) = %v; want %v", tt.from, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNextAfter(t *testing.T) {
+	r, err := ParseRecurrence("daily")
+	if err != nil {
+		t.Fatalf("ParseRecurrence() error = %v", err)
+	}
+
+	due := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 7, 5, 12, 0, 0, 0, time.UTC)
+
+	result := r.NextAfter(due, now)
+	expected := time.Date(2025, 7, 6, 9, 0, 0, 0, time.UTC)
+	if !result.Equal(expected) {
+		t.Errorf("NextAfter() = %v; want %v", result, expected)
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+// Markers placed around the matched terms of a search snippet.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// SearchResult is a node matched by a full-text search.
+type SearchResult struct {
+	Node Node
+	// Snippet is an excerpt of the matching text, with matches wrapped in HighlightStart and HighlightEnd.
+	Snippet string
+	// Rank is the bm25 score of the match; lower is more relevant.
+	Rank float64
+}
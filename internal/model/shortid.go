@@ -0,0 +1,44 @@
+package model
+
+import "sort"
+
+// MinShortID is the fewest characters an ID is shortened to for display.
+const MinShortID = 4
+
+// ShortIDs returns, for each of ids, its shortest prefix of at least MinShortID characters that
+// no other ID in all starts with. all normally holds every ID in the database, so the prefixes
+// can be typed back to refer to the nodes.
+func ShortIDs(ids, all []string) map[string]string {
+	sorted := append([]string(nil), all...)
+	sort.Strings(sorted)
+
+	short := make(map[string]string, len(ids))
+	for _, id := range ids {
+		n := MinShortID
+
+		i := sort.SearchStrings(sorted, id)
+		if i > 0 {
+			n = max(n, commonPrefix(sorted[i-1], id)+1)
+		}
+		// Skip id itself, and duplicates of it, to compare with the next different ID
+		j := i
+		for j < len(sorted) && sorted[j] == id {
+			j++
+		}
+		if j < len(sorted) {
+			n = max(n, commonPrefix(sorted[j], id)+1)
+		}
+
+		short[id] = id[:min(n, len(id))]
+	}
+
+	return short
+}
+
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
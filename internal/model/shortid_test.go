@@ -0,0 +1,37 @@
+package model
+
+import "testing"
+
+func TestShortIDs(t *testing.T) {
+	all := []string{
+		"3ca9e7cd-5c7b",
+		"3ca9f012-aa01",
+		"3ca9f0ff-bb02",
+		"7d01aa00-0000",
+		"ab",
+	}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"7d01aa00-0000", "7d01"},
+		{"3ca9e7cd-5c7b", "3ca9e"},
+		{"3ca9f012-aa01", "3ca9f01"},
+		{"3ca9f0ff-bb02", "3ca9f0f"},
+		{"ab", "ab"},
+		{"ffff0000", "ffff"},
+	}
+
+	ids := make([]string, len(tests))
+	for i, tt := range tests {
+		ids[i] = tt.id
+	}
+
+	got := ShortIDs(ids, all)
+	for _, tt := range tests {
+		if got[tt.id] != tt.want {
+			t.Errorf("ShortIDs()[%s] = %s, want %s", tt.id, got[tt.id], tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package model
+
+import "fmt"
+
+// TreeNode is a node placed in a parent/child hierarchy.
+type TreeNode struct {
+	Node  Node
+	Depth int
+}
+
+// Progress counts the completed children of a parent task. Canceled children are not counted.
+type Progress struct {
+	Done  int
+	Total int
+}
+
+func (p Progress) String() string {
+	return fmt.Sprintf("%d/%d done", p.Done, p.Total)
+}
+
+// IsClosed reports whether a task no longer needs work.
+func (n *Node) IsClosed() bool {
+	return n.Status == Status.Done || n.Status == Status.Canceled
+}
+
+// Flatten orders nodes depth-first so each child follows its parent, keeping the given order among siblings.
+// Nodes whose parent is not in the slice are treated as roots.
+func Flatten(nodes []Node) []TreeNode {
+	present := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		present[n.ID] = true
+	}
+
+	children := make(map[string][]Node)
+	var roots []Node
+	for _, n := range nodes {
+		if n.ParentID != "" && n.ParentID != n.ID && present[n.ParentID] {
+			children[n.ParentID] = append(children[n.ParentID], n)
+			continue
+		}
+		roots = append(roots, n)
+	}
+
+	result := make([]TreeNode, 0, len(nodes))
+	visited := make(map[string]bool, len(nodes))
+
+	var walk func(n Node, depth int)
+	walk = func(n Node, depth int) {
+		if visited[n.ID] {
+			return
+		}
+		visited[n.ID] = true
+		result = append(result, TreeNode{Node: n, Depth: depth})
+		for _, child := range children[n.ID] {
+			walk(child, depth+1)
+		}
+	}
+
+	for _, root := range roots {
+		walk(root, 0)
+	}
+
+	// Nodes only reachable through a parent cycle are appended as roots
+	for _, n := range nodes {
+		walk(n, 0)
+	}
+
+	return result
+}
+
+// ChildProgress returns the progress of every node in nodes that has children in nodes.
+func ChildProgress(nodes []Node) map[string]Progress {
+	progress := make(map[string]Progress)
+	for _, n := range nodes {
+		if n.ParentID == "" || n.Status == Status.Canceled {
+			continue
+		}
+		p := progress[n.ParentID]
+		p.Total++
+		if n.Status == Status.Done {
+			p.Done++
+		}
+		progress[n.ParentID] = p
+	}
+	return progress
+}
@@ -0,0 +1,74 @@
+package model
+
+import "testing"
+
+func TestFlatten(t *testing.T) {
+	nodes := []Node{
+		{ID: "c1", ParentID: "p1"},
+		{ID: "p1"},
+		{ID: "g1", ParentID: "c1"},
+		{ID: "p2"},
+		{ID: "c2", ParentID: "p1"},
+		{ID: "o1", ParentID: "missing"},
+	}
+
+	result := Flatten(nodes)
+
+	expected := []struct {
+		id    string
+		depth int
+	}{
+		{"p1", 0},
+		{"c1", 1},
+		{"g1", 2},
+		{"c2", 1},
+		{"p2", 0},
+		{"o1", 0},
+	}
+
+	if len(result) != len(expected) {
+		t.Fatalf("Flatten() returned %d nodes; want %d", len(result), len(expected))
+	}
+
+	for i, e := range expected {
+		if result[i].Node.ID != e.id || result[i].Depth != e.depth {
+			t.Errorf("Flatten()[%d] = %s at depth %d; want %s at depth %d", i, result[i].Node.ID, result[i].Depth, e.id, e.depth)
+		}
+	}
+}
+
+func TestFlattenCycle(t *testing.T) {
+	nodes := []Node{
+		{ID: "a", ParentID: "b"},
+		{ID: "b", ParentID: "a"},
+	}
+
+	result := Flatten(nodes)
+	if len(result) != 2 {
+		t.Errorf("Flatten() returned %d nodes; want 2", len(result))
+	}
+}
+
+func TestChildProgress(t *testing.T) {
+	nodes := []Node{
+		{ID: "p1", Status: Status.Todo},
+		{ID: "c1", ParentID: "p1", Status: Status.Done},
+		{ID: "c2", ParentID: "p1", Status: Status.InProgress},
+		{ID: "c3", ParentID: "p1", Status: Status.Canceled},
+		{ID: "p2", Status: Status.Todo},
+	}
+
+	progress := ChildProgress(nodes)
+
+	if got := progress["p1"]; got.Done != 1 || got.Total != 2 {
+		t.Errorf("ChildProgress()[p1] = %v; want 1/2 done", got)
+	}
+
+	if _, ok := progress["p2"]; ok {
+		t.Errorf("ChildProgress() should not include p2, which has no children")
+	}
+
+	if got := progress["p1"].String(); got != "1/2 done" {
+		t.Errorf("Progress.String() = %q; want %q", got, "1/2 done")
+	}
+}
@@ -1,5 +1,11 @@
 package model
 
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // Common date format constants
 const (
 	DateTimeFormat = "2006-01-02 15:04:05"
@@ -176,3 +182,111 @@ func PreviousStatus(currentStatus string) string {
 	}
 	return StatusCycle[0]
 }
+
+// Priorities go from 1 (most important) to 4; 0 means the task has no priority.
+type priorityVal struct {
+	None   int
+	Urgent int
+	High   int
+	Medium int
+	Low    int
+}
+
+var Priority = priorityVal{
+	None:   0,
+	Urgent: 1,
+	High:   2,
+	Medium: 3,
+	Low:    4,
+}
+
+func (p priorityVal) Values() []int {
+	return []int{
+		p.Urgent,
+		p.High,
+		p.Medium,
+		p.Low,
+	}
+}
+
+func (p priorityVal) Validate(v int) bool {
+	return v >= p.None && v <= p.Low
+}
+
+func (p priorityVal) Label(v int) string {
+	switch v {
+	case p.Urgent:
+		return "Urgent"
+	case p.High:
+		return "High"
+	case p.Medium:
+		return "Medium"
+	case p.Low:
+		return "Low"
+	default:
+		return "None"
+	}
+}
+
+// Parse accepts a numeric priority (0-4) or a name such as urgent, high, medium, med, low or none.
+func (p priorityVal) Parse(v string) (int, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+
+	if n, err := strconv.Atoi(v); err == nil {
+		return n, p.Validate(n)
+	}
+
+	switch v {
+	case "urgent":
+		return p.Urgent, true
+	case "high":
+		return p.High, true
+	case "medium", "med":
+		return p.Medium, true
+	case "low":
+		return p.Low, true
+	case "none":
+		return p.None, true
+	default:
+		return 0, false
+	}
+}
+
+// RaisePriority moves one step towards Urgent; a task without priority becomes Low.
+func RaisePriority(current int) int {
+	switch {
+	case current == Priority.None:
+		return Priority.Low
+	case current > Priority.Urgent:
+		return current - 1
+	default:
+		return Priority.Urgent
+	}
+}
+
+// LowerPriority moves one step towards Low and then to no priority.
+func LowerPriority(current int) int {
+	switch {
+	case current == Priority.None:
+		return Priority.None
+	case current < Priority.Low:
+		return current + 1
+	default:
+		return Priority.None
+	}
+}
+
+// PriorityRank orders priorities with tasks without priority last.
+func PriorityRank(priority int) int {
+	if priority == Priority.None {
+		return Priority.Low + 1
+	}
+	return priority
+}
+
+// SortByPriority sorts nodes by priority, keeping the existing order within each priority.
+func SortByPriority(nodes []Node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return PriorityRank(nodes[i].Priority) < PriorityRank(nodes[j].Priority)
+	})
+}
@@ -137,3 +137,92 @@ func TestPreviousStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestRaisePriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		expected int
+	}{
+		{name: "None to Low", current: Priority.None, expected: Priority.Low},
+		{name: "Low to Medium", current: Priority.Low, expected: Priority.Medium},
+		{name: "High to Urgent", current: Priority.High, expected: Priority.Urgent},
+		{name: "Urgent stays Urgent", current: Priority.Urgent, expected: Priority.Urgent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := RaisePriority(tt.current)
+			if result != tt.expected {
+				t.Errorf("RaisePriority(%d) = %d; want %d", tt.current, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLowerPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		expected int
+	}{
+		{name: "Urgent to High", current: Priority.Urgent, expected: Priority.High},
+		{name: "Low to None", current: Priority.Low, expected: Priority.None},
+		{name: "None stays None", current: Priority.None, expected: Priority.None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := LowerPriority(tt.current)
+			if result != tt.expected {
+				t.Errorf("LowerPriority(%d) = %d; want %d", tt.current, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPriorityParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int
+		ok       bool
+	}{
+		{name: "Number", value: "2", expected: Priority.High, ok: true},
+		{name: "Name", value: "urgent", expected: Priority.Urgent, ok: true},
+		{name: "Short name", value: "med", expected: Priority.Medium, ok: true},
+		{name: "None", value: "none", expected: Priority.None, ok: true},
+		{name: "Out of range", value: "7", ok: false},
+		{name: "Unknown name", value: "whenever", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := Priority.Parse(tt.value)
+			if ok != tt.ok || (ok && result != tt.expected) {
+				t.Errorf("Priority.Parse(%q) = %d, %v; want %d, %v", tt.value, result, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSortByPriority(t *testing.T) {
+	nodes := []Node{
+		{ID: "a", Priority: Priority.None},
+		{ID: "b", Priority: Priority.Low},
+		{ID: "c", Priority: Priority.Urgent},
+		{ID: "d", Priority: Priority.None},
+		{ID: "e", Priority: Priority.Urgent},
+	}
+
+	SortByPriority(nodes)
+
+	var ids string
+	for _, n := range nodes {
+		ids += n.ID
+	}
+
+	if ids != "cebad" {
+		t.Errorf("SortByPriority() order = %q; want %q", ids, "cebad")
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"regexp"
+	"time"
+)
+
+var viewNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// View is a list query saved under a name, run with tn v.
+type View struct {
+	Name  string
+	Query string
+	// Section is the heading the view is listed under in the journal. Views without one are
+	// left out of it.
+	Section   string
+	CreatedAt time.Time
+}
+
+// ValidViewName reports whether name can name a view: letters, digits, '_', '.' and '-', not
+// starting with a punctuation mark, so it reads as a single argument.
+func ValidViewName(name string) bool {
+	return viewNamePattern.MatchString(name)
+}
@@ -0,0 +1,407 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/jmoiron/sqlx"
+)
+
+// Backup reasons, recorded in the backup file name.
+// Every backup except a manual one is automatic and rotated down to DatabaseBackupCount.
+const (
+	BackupManual       = "manual"
+	BackupScheduled    = "scheduled"
+	BackupPreMigration = "pre-migration"
+	BackupPreRestore   = "pre-restore"
+)
+
+const (
+	backupPrefix     = "tyn-"
+	backupExt        = ".db"
+	backupTimeFormat = "20060102-150405"
+)
+
+var backupReasons = []string{BackupManual, BackupScheduled, BackupPreMigration, BackupPreRestore}
+
+// Backup is a copy of the database in the backup directory.
+type Backup struct {
+	Name      string
+	Path      string
+	Reason    string
+	CreatedAt time.Time
+	Size      int64
+}
+
+func (b Backup) Automatic() bool {
+	return b.Reason != BackupManual
+}
+
+// BackupDir is the directory backups are written to, next to the database file.
+func BackupDir() string {
+	return filepath.Join(filepath.Dir(getDBPath()), "backups")
+}
+
+// ListBackups returns the backups in the backup directory, newest first.
+func ListBackups() ([]Backup, error) {
+	dir := BackupDir()
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup directory: %w", err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		backup, ok := parseBackupName(entry.Name())
+		if !ok {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		backup.Path = filepath.Join(dir, entry.Name())
+		backup.Size = info.Size()
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].CreatedAt.After(backups[j].CreatedAt)
+		}
+		return backups[i].Name > backups[j].Name
+	})
+
+	return backups, nil
+}
+
+// LatestAutomaticBackup returns the newest automatic backup, if there is one.
+func LatestAutomaticBackup() (Backup, bool, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return Backup{}, false, err
+	}
+
+	for _, b := range backups {
+		if b.Automatic() {
+			return b, true, nil
+		}
+	}
+
+	return Backup{}, false, nil
+}
+
+// ResolveBackup returns the path of a backup given either a file path or the name of a file
+// in the backup directory.
+func ResolveBackup(nameOrPath string) (string, error) {
+	if _, err := os.Stat(nameOrPath); err == nil {
+		return nameOrPath, nil
+	}
+
+	path := filepath.Join(BackupDir(), nameOrPath)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	return "", fmt.Errorf("backup not found: %s", nameOrPath)
+}
+
+// CreateBackup opens the database and backs it up. Automatic backups are rotated afterwards.
+func CreateBackup(ctx context.Context, cfg *config.Config, reason string) (Backup, error) {
+	db, err := openDB(getDBPath())
+	if err != nil {
+		return Backup{}, fmt.Errorf("error opening database: %w", err)
+	}
+	defer db.Close()
+
+	return createBackup(ctx, db, reason, backupCount(cfg))
+}
+
+// WriteBackup backs up the database to path, which must not exist yet.
+func WriteBackup(ctx context.Context, path string) error {
+	db, err := openDB(getDBPath())
+	if err != nil {
+		return fmt.Errorf("error opening database: %w", err)
+	}
+	defer db.Close()
+
+	return vacuumInto(ctx, db, path)
+}
+
+// Backup takes a backup of the repository database. Automatic backups are rotated afterwards.
+func (r *TynRepo) Backup(ctx context.Context, reason string) (Backup, error) {
+	return createBackup(ctx, r.db, reason, backupCount(r.cfg))
+}
+
+// RestoreBackup replaces the database with the backup at path. The current database, if any,
+// is backed up first and that backup is returned. Nothing else may have the database open;
+// callers stop the daemon before restoring.
+func RestoreBackup(ctx context.Context, cfg *config.Config, path string) (Backup, error) {
+	err := checkBackup(ctx, path)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	dbPath := getDBPath()
+
+	// The backup is copied aside before the pre-restore backup, whose rotation may remove it.
+	tmpPath := dbPath + ".restore"
+	err = copyFile(path, tmpPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return Backup{}, fmt.Errorf("error copying backup: %w", err)
+	}
+
+	var previous Backup
+	if _, err := os.Stat(dbPath); err == nil {
+		previous, err = CreateBackup(ctx, cfg, BackupPreRestore)
+		if err != nil {
+			os.Remove(tmpPath)
+			return Backup{}, fmt.Errorf("error backing up current database: %w", err)
+		}
+	}
+
+	// A leftover write-ahead log belongs to the old database and must not be applied to the restored one.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		err = os.Remove(dbPath + suffix)
+		if err != nil && !os.IsNotExist(err) {
+			os.Remove(tmpPath)
+			return previous, fmt.Errorf("error removing %s: %w", dbPath+suffix, err)
+		}
+	}
+
+	err = os.Rename(tmpPath, dbPath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return previous, fmt.Errorf("error replacing database: %w", err)
+	}
+
+	log.Printf("Restored database from %s", path)
+	return previous, nil
+}
+
+// checkBackup makes sure a file is an intact tyn database this version can open.
+func checkBackup(ctx context.Context, path string) error {
+	db, err := sqlx.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("error opening backup: %w", err)
+	}
+	defer db.Close()
+
+	var result string
+	err = db.GetContext(ctx, &result, Query["integrity_check"])
+	if err != nil {
+		return fmt.Errorf("%s is not a readable database: %w", path, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("%s failed the integrity check: %s", path, result)
+	}
+
+	var tables int
+	err = db.GetContext(ctx, &tables, Query["count_table"], "nodes")
+	if err != nil {
+		return fmt.Errorf("error inspecting backup: %w", err)
+	}
+	if tables == 0 {
+		return fmt.Errorf("%s is not a tyn database", path)
+	}
+
+	err = db.GetContext(ctx, &tables, Query["count_table"], "schema_migrations")
+	if err != nil {
+		return fmt.Errorf("error inspecting backup: %w", err)
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	var version int
+	err = db.GetContext(ctx, &version, Query["get_schema_version"])
+	if err != nil {
+		return fmt.Errorf("error reading backup schema version: %w", err)
+	}
+	if version > LatestSchemaVersion() {
+		return fmt.Errorf("backup schema version %d is newer than the version %d supported by this tyn; please upgrade tyn",
+			version, LatestSchemaVersion())
+	}
+
+	return nil
+}
+
+// createBackup writes a new backup to the backup directory. A keep count of 0 turns rotation off;
+// callers skip scheduled and pre-migration backups in that case, so only pre-restore ones pile up.
+func createBackup(ctx context.Context, db *sqlx.DB, reason string, keep int) (Backup, error) {
+	dir := BackupDir()
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return Backup{}, fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	now := time.Now()
+	// Backups of any reason taken in the same second are numbered, so the newest sorts first.
+	n := 0
+	for stampTaken(dir, now, n) {
+		n++
+	}
+	name := backupName(now, reason, n)
+
+	path := filepath.Join(dir, name)
+	err = vacuumInto(ctx, db, path)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	backup := Backup{Name: name, Path: path, Reason: reason, CreatedAt: now.Truncate(time.Second)}
+	if info, err := os.Stat(path); err == nil {
+		backup.Size = info.Size()
+	}
+
+	log.Printf("Created %s backup %s", reason, path)
+
+	if backup.Automatic() && keep > 0 {
+		err = rotateBackups(keep)
+		if err != nil {
+			return backup, err
+		}
+	}
+
+	return backup, nil
+}
+
+// vacuumInto writes a consistent copy of the database to path while other connections keep working.
+func vacuumInto(ctx context.Context, db *sqlx.DB, path string) error {
+	if fileExists(path) {
+		return fmt.Errorf("backup file already exists: %s", path)
+	}
+
+	_, err := db.ExecContext(ctx, Query["vacuum_into"], path)
+	if err != nil {
+		return fmt.Errorf("error writing backup to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// rotateBackups removes the oldest automatic backups until at most keep remain.
+// Manual backups are never removed.
+func rotateBackups(keep int) error {
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for _, b := range backups {
+		if !b.Automatic() {
+			continue
+		}
+
+		kept++
+		if kept <= keep {
+			continue
+		}
+
+		err = os.Remove(b.Path)
+		if err != nil {
+			return fmt.Errorf("error removing old backup %s: %w", b.Path, err)
+		}
+		log.Printf("Removed old backup %s", b.Path)
+	}
+
+	return nil
+}
+
+func backupCount(cfg *config.Config) int {
+	if cfg == nil {
+		return config.DefaultConfig().DatabaseBackupCount
+	}
+	return cfg.Snapshot().DatabaseBackupCount
+}
+
+// backupName builds tyn-<time>[.<n>]-<reason>.db; n tells apart backups taken in the same second.
+func backupName(t time.Time, reason string, n int) string {
+	stamp := t.Format(backupTimeFormat)
+	if n > 0 {
+		stamp = fmt.Sprintf("%s.%d", stamp, n)
+	}
+	return backupPrefix + stamp + "-" + reason + backupExt
+}
+
+// stampTaken reports whether a backup of any reason already uses the time stamp and number.
+func stampTaken(dir string, t time.Time, n int) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, backupName(t, "*", n)))
+	return len(matches) > 0
+}
+
+func parseBackupName(name string) (Backup, bool) {
+	if !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupExt) {
+		return Backup{}, false
+	}
+
+	rest := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupExt)
+	if len(rest) < len(backupTimeFormat) {
+		return Backup{}, false
+	}
+
+	createdAt, err := time.ParseInLocation(backupTimeFormat, rest[:len(backupTimeFormat)], time.Local)
+	if err != nil {
+		return Backup{}, false
+	}
+
+	for _, reason := range backupReasons {
+		if strings.HasSuffix(rest, "-"+reason) {
+			return Backup{Name: name, Reason: reason, CreatedAt: createdAt}, true
+		}
+	}
+
+	return Backup{}, false
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	err = out.Sync()
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
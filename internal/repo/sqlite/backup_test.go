@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adrianpk/tyn/internal/config"
+)
+
+func TestRestoreOldestBackupWithRotationFull(t *testing.T) {
+	defaults := config.DefaultConfig()
+	cfg := &defaults
+	repo := newTestRepo(t, cfg)
+	ctx := context.Background()
+
+	before := testNote("before the oldest backup")
+	after := testNote("after the oldest backup")
+
+	err := repo.Create(ctx, before)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	oldest, err := repo.Backup(ctx, BackupScheduled)
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	err = repo.Create(ctx, after)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for i := 1; i < cfg.DatabaseBackupCount; i++ {
+		_, err = repo.Backup(ctx, BackupScheduled)
+		if err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+	}
+	repo.Close()
+
+	previous, err := RestoreBackup(ctx, cfg, oldest.Path)
+	if err != nil {
+		t.Fatalf("RestoreBackup(%s) error = %v", oldest.Name, err)
+	}
+
+	if previous.Reason != BackupPreRestore || !fileExists(previous.Path) {
+		t.Errorf("RestoreBackup() previous = %+v, want an existing pre-restore backup", previous)
+	}
+
+	restored, err := NewTynRepo(cfg)
+	if err != nil {
+		t.Fatalf("NewTynRepo() error = %v", err)
+	}
+	defer restored.Close()
+
+	nodes, err := restored.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if len(nodes) != 1 || nodes[0].ID != before.ID {
+		t.Errorf("restored nodes = %v, want only %s", nodes, before.ID)
+	}
+}
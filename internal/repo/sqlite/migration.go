@@ -0,0 +1,486 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// migration is a numbered schema change. Migrations are applied in version order,
+// each in its own transaction, and recorded in schema_migrations.
+// Released migrations must never be edited or renumbered; add a new one instead.
+type migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sqlx.Tx) error
+}
+
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "create nodes and notifications tables",
+		Up:      execQueries("create_nodes_table", "create_notifications_table"),
+	},
+	{
+		Version: 2,
+		Name:    "add task recurrence",
+		Up:      addColumn("nodes", "recurrence", "TEXT DEFAULT ''"),
+	},
+	{
+		Version: 3,
+		Name:    "add task priority",
+		Up:      addColumn("nodes", "priority", "INTEGER DEFAULT 0"),
+	},
+	{
+		Version: 4,
+		Name:    "add subtasks",
+		Up: steps(
+			addColumn("nodes", "parent_id", "TEXT DEFAULT ''"),
+			execQueries("create_nodes_parent_index"),
+		),
+	},
+	{
+		Version: 5,
+		Name:    "add task dependencies",
+		Up: steps(
+			addColumn("nodes", "prior_status", "TEXT DEFAULT ''"),
+			execQueries("create_dependencies_table", "create_dependencies_index"),
+		),
+	},
+	{
+		Version: 6,
+		Name:    "normalize tags and places",
+		Up: steps(
+			execQueries(
+				"create_tags_table", "create_places_table",
+				"create_node_tags_table", "create_node_places_table",
+				"create_node_tags_index", "create_node_places_index",
+			),
+			moveCSVLabels,
+			dropColumn("nodes", "tags"),
+			dropColumn("nodes", "places"),
+		),
+	},
+	{
+		Version: 7,
+		Name:    "add full-text search",
+		Up: execQueries(
+			"create_nodes_fts_table",
+			"create_nodes_fts_insert_trigger",
+			"create_nodes_fts_update_trigger",
+			"create_nodes_fts_delete_trigger",
+			"fill_nodes_fts",
+		),
+	},
+	{
+		Version: 8,
+		Name:    "add archive and trash",
+		Up: steps(
+			addColumn("nodes", "archived_at", "DATETIME"),
+			addColumn("nodes", "deleted_at", "DATETIME"),
+		),
+	},
+	{
+		Version: 9,
+		Name:    "add saved views",
+		Up:      execQueries("create_views_table"),
+	},
+}
+
+// MigrationState is a known migration and when it was applied, if it was.
+type MigrationState struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+func (m MigrationState) Applied() bool {
+	return m.AppliedAt != nil
+}
+
+// LatestSchemaVersion is the schema version this build migrates databases to.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrator applies and reports schema migrations on a database.
+// Unless backups are disabled, an existing database is backed up before migrations are applied.
+type Migrator struct {
+	db          *sqlx.DB
+	backupCount int
+}
+
+func NewMigrator(db *sqlx.DB, cfg *config.Config) *Migrator {
+	return &Migrator{db: db, backupCount: backupCount(cfg)}
+}
+
+// OpenMigrator opens the tyn database without migrating it.
+// A read-only migrator neither creates nor writes the database file; a missing database
+// is reported as a new one with every migration pending.
+func OpenMigrator(cfg *config.Config, readOnly bool) (*Migrator, error) {
+	open := openDB
+	if readOnly {
+		open = openReadOnlyDB
+	}
+
+	db, err := open(getDBPath())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMigrator(db, cfg), nil
+}
+
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// Version returns the highest migration applied to the database, 0 for a new database.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	exists, err := m.hasTable(ctx)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	err = m.db.GetContext(ctx, &version, Query["get_schema_version"])
+	if err != nil {
+		return 0, fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	return int(version.Int64), nil
+}
+
+// Status lists every known migration, with the time it was applied for those already applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationState, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, mig := range migrations {
+		state := MigrationState{Version: mig.Version, Name: mig.Name}
+		if t, ok := applied[mig.Version]; ok {
+			state.AppliedAt = &t
+		}
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// Pending lists the migrations not yet applied, in the order Migrate would apply them.
+// It fails if the database was migrated by a newer version of tyn.
+func (m *Migrator) Pending(ctx context.Context) ([]MigrationState, error) {
+	err := m.checkVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []MigrationState
+	for _, state := range states {
+		if !state.Applied() {
+			pending = append(pending, state)
+		}
+	}
+
+	return pending, nil
+}
+
+// Migrate applies the pending migrations and returns them.
+// It refuses to touch a database migrated by a newer version of tyn.
+func (m *Migrator) Migrate(ctx context.Context) ([]MigrationState, error) {
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) > 0 {
+		err = m.backup(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byVersion := make(map[int]migration, len(migrations))
+	for _, mig := range migrations {
+		byVersion[mig.Version] = mig
+	}
+
+	var applied []MigrationState
+	for _, state := range pending {
+		err = m.apply(ctx, byVersion[state.Version])
+		if err != nil {
+			return applied, err
+		}
+
+		now := time.Now()
+		state.AppliedAt = &now
+		applied = append(applied, state)
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, mig migration) error {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting migration %d: %w", mig.Version, err)
+	}
+	defer tx.Rollback()
+
+	err = mig.Up(tx)
+	if err != nil {
+		return fmt.Errorf("error applying migration %d (%s): %w", mig.Version, mig.Name, err)
+	}
+
+	_, err = tx.ExecContext(ctx, Query["insert_schema_migration"],
+		mig.Version, mig.Name, time.Now().UTC().Format(model.DateTimeFormat))
+	if err != nil {
+		return fmt.Errorf("error recording migration %d: %w", mig.Version, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing migration %d: %w", mig.Version, err)
+	}
+
+	log.Printf("Applied migration %d: %s", mig.Version, mig.Name)
+	return nil
+}
+
+// backup takes a pre-migration backup of a database that already holds nodes.
+// A new database has nothing worth keeping yet.
+func (m *Migrator) backup(ctx context.Context) error {
+	if m.backupCount <= 0 {
+		return nil
+	}
+
+	var tables int
+	err := m.db.GetContext(ctx, &tables, Query["count_table"], "nodes")
+	if err != nil {
+		return fmt.Errorf("error inspecting database: %w", err)
+	}
+
+	if tables == 0 {
+		return nil
+	}
+
+	_, err = createBackup(ctx, m.db, BackupPreMigration, m.backupCount)
+	if err != nil {
+		return fmt.Errorf("error backing up database before migrating: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) checkVersion(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version > LatestSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than the version %d supported by this tyn; please upgrade tyn",
+			version, LatestSchemaVersion())
+	}
+
+	return nil
+}
+
+// applied returns the time each applied migration was applied, keyed by version.
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	applied := make(map[int]time.Time)
+
+	exists, err := m.hasTable(ctx)
+	if err != nil || !exists {
+		return applied, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, Query["list_schema_migrations"])
+	if err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error reading applied migrations: %w", err)
+		}
+		applied[version] = appliedAt.In(time.Local)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// hasTable reports whether schema_migrations exists. The read paths check instead of creating
+// it, so reporting the state of a database never writes to it.
+func (m *Migrator) hasTable(ctx context.Context) (bool, error) {
+	var tables int
+	err := m.db.GetContext(ctx, &tables, Query["count_table"], "schema_migrations")
+	if err != nil {
+		return false, fmt.Errorf("error inspecting database: %w", err)
+	}
+
+	return tables > 0, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, Query["create_schema_migrations_table"])
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations table: %w", err)
+	}
+
+	return nil
+}
+
+func migrate(db *sqlx.DB, cfg *config.Config) error {
+	_, err := NewMigrator(db, cfg).Migrate(context.Background())
+	return err
+}
+
+func execQueries(names ...string) func(tx *sqlx.Tx) error {
+	return func(tx *sqlx.Tx) error {
+		for _, name := range names {
+			_, err := tx.Exec(Query[name])
+			if err != nil {
+				return fmt.Errorf("error running %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// addColumn adds a column unless it already exists. Databases created before schema_migrations
+// was introduced may have some of the later columns already.
+func addColumn(table, column, definition string) func(tx *sqlx.Tx) error {
+	return func(tx *sqlx.Tx) error {
+		var count int
+		err := tx.Get(&count, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column)
+		if err != nil {
+			return fmt.Errorf("error inspecting table %s: %w", table, err)
+		}
+
+		if count > 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+		if err != nil {
+			return fmt.Errorf("error adding column %s.%s: %w", table, column, err)
+		}
+
+		return nil
+	}
+}
+
+// moveCSVLabels copies the comma separated tags and places columns into the label tables.
+func moveCSVLabels(tx *sqlx.Tx) error {
+	type csvLabels struct {
+		ID     string
+		Tags   string
+		Places string
+	}
+
+	rows, err := tx.Query(Query["list_csv_labels"])
+	if err != nil {
+		return fmt.Errorf("error reading tags and places: %w", err)
+	}
+
+	var nodes []csvLabels
+	for rows.Next() {
+		var n csvLabels
+		err = rows.Scan(&n.ID, &n.Tags, &n.Places)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("error reading tags and places: %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, n := range nodes {
+		err = writeLabels(ctx, tx, model.Node{
+			ID:     n.ID,
+			Tags:   csvToStringSlice(n.Tags),
+			Places: csvToStringSlice(n.Places),
+		})
+		if err != nil {
+			return fmt.Errorf("error moving labels of node %s: %w", n.ID, err)
+		}
+	}
+
+	log.Printf("Moved tags and places of %d nodes into label tables", len(nodes))
+	return nil
+}
+
+func dropColumn(table, column string) func(tx *sqlx.Tx) error {
+	return func(tx *sqlx.Tx) error {
+		var count int
+		err := tx.Get(&count, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column)
+		if err != nil {
+			return fmt.Errorf("error inspecting table %s: %w", table, err)
+		}
+
+		if count == 0 {
+			return nil
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column))
+		if err != nil {
+			return fmt.Errorf("error dropping column %s.%s: %w", table, column, err)
+		}
+
+		return nil
+	}
+}
+
+// csvToStringSlice splits the comma separated labels stored before migration 6.
+func csvToStringSlice(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func steps(fns ...func(tx *sqlx.Tx) error) func(tx *sqlx.Tx) error {
+	return func(tx *sqlx.Tx) error {
+		for _, fn := range fns {
+			err := fn(tx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
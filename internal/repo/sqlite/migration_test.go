@@ -0,0 +1,385 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/jmoiron/sqlx"
+)
+
+// openTestDB opens an empty database in a temporary directory without migrating it.
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	t.Setenv("TYN_DB_PATH", filepath.Join(t.TempDir(), "tyn.db"))
+
+	db, err := openDB(getDBPath())
+	if err != nil {
+		t.Fatalf("openDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// createBaseline creates the schema of databases made before schema_migrations existed, with a
+// node whose tags and places are stored comma separated.
+func createBaseline(t *testing.T, db *sqlx.DB, id, tags, places string) {
+	t.Helper()
+
+	for _, name := range []string{"create_nodes_table", "create_notifications_table"} {
+		_, err := db.Exec(Query[name])
+		if err != nil {
+			t.Fatalf("error running %s: %v", name, err)
+		}
+	}
+
+	_, err := db.Exec(`INSERT INTO nodes (id, type, content, link, tags, places, status, draft, date)
+		VALUES (?, 'task', 'Baseline task', '', ?, ?, 'todo', '', '2025-07-01 09:30:00')`, id, tags, places)
+	if err != nil {
+		t.Fatalf("error inserting baseline node: %v", err)
+	}
+}
+
+type schemaMigration struct {
+	Version int
+	Name    string
+}
+
+func listSchemaMigrations(t *testing.T, db *sqlx.DB) []schemaMigration {
+	t.Helper()
+
+	var rows []schemaMigration
+	err := db.Select(&rows, `SELECT version, name FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		t.Fatalf("error reading schema_migrations: %v", err)
+	}
+	return rows
+}
+
+func checkSchemaMigrations(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+
+	got := listSchemaMigrations(t, db)
+	if len(got) != len(migrations) {
+		t.Fatalf("schema_migrations has %d rows, want %d", len(got), len(migrations))
+	}
+
+	for i, mig := range migrations {
+		if got[i].Version != mig.Version || got[i].Name != mig.Name {
+			t.Errorf("schema_migrations row %d = %d %q, want %d %q", i, got[i].Version, got[i].Name, mig.Version, mig.Name)
+		}
+	}
+}
+
+func hasColumn(t *testing.T, db *sqlx.DB, table, column string) bool {
+	t.Helper()
+
+	var count int
+	err := db.Get(&count, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column)
+	if err != nil {
+		t.Fatalf("error inspecting table %s: %v", table, err)
+	}
+	return count > 0
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db, nil)
+
+	applied, err := m.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	if len(applied) != len(migrations) {
+		t.Errorf("Migrate() applied %d migrations, want %d", len(applied), len(migrations))
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("Version() = %d, want %d", version, LatestSchemaVersion())
+	}
+
+	checkSchemaMigrations(t, db)
+
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups() error = %v", err)
+	}
+	if len(backups) != 0 {
+		t.Errorf("ListBackups() = %v, want no backup of a new database", backups)
+	}
+}
+
+func TestPendingDoesNotWrite(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db, nil)
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != len(migrations) {
+		t.Errorf("Pending() = %d migrations, want %d", len(pending), len(migrations))
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if version != 0 {
+		t.Errorf("Version() = %d, want 0", version)
+	}
+
+	var tables int
+	err = db.Get(&tables, Query["count_table"], "schema_migrations")
+	if err != nil {
+		t.Fatalf("error inspecting database: %v", err)
+	}
+	if tables != 0 {
+		t.Error("Pending() created schema_migrations, want the database left untouched")
+	}
+}
+
+func TestOpenReadOnlyMigratorLeavesNoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tyn.db")
+	t.Setenv("TYN_DB_PATH", path)
+
+	m, err := OpenMigrator(nil, true)
+	if err != nil {
+		t.Fatalf("OpenMigrator() error = %v", err)
+	}
+
+	states, err := m.Status(context.Background())
+	m.Close()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(states) != len(migrations) || states[0].Applied() {
+		t.Errorf("Status() = %v, want every migration pending", states)
+	}
+
+	_, err = os.Stat(path)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Stat(%s) error = %v, want the database not to be created", path, err)
+	}
+}
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	createBaseline(t, db, "baseline", "work,urgent", "office")
+
+	defaults := config.DefaultConfig()
+	m := NewMigrator(db, &defaults)
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != len(migrations) {
+		t.Errorf("Pending() = %d migrations, want %d", len(pending), len(migrations))
+	}
+
+	_, err = m.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	checkSchemaMigrations(t, db)
+
+	var content string
+	err = db.Get(&content, `SELECT content FROM nodes WHERE id = ?`, "baseline")
+	if err != nil {
+		t.Fatalf("error reading migrated node: %v", err)
+	}
+	if content != "Baseline task" {
+		t.Errorf("migrated content = %q, want %q", content, "Baseline task")
+	}
+
+	for _, column := range []string{"tags", "places"} {
+		if hasColumn(t, db, "nodes", column) {
+			t.Errorf("nodes.%s still exists after migrating", column)
+		}
+	}
+	for _, column := range []string{"recurrence", "priority", "parent_id", "prior_status", "archived_at", "deleted_at"} {
+		if !hasColumn(t, db, "nodes", column) {
+			t.Errorf("nodes.%s is missing after migrating", column)
+		}
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups() error = %v", err)
+	}
+	if len(backups) != 1 || backups[0].Reason != BackupPreMigration {
+		t.Errorf("ListBackups() = %v, want one pre-migration backup", backups)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	createBaseline(t, db, "baseline", "work", "")
+	m := NewMigrator(db, nil)
+
+	// Stop halfway, as a database last opened by an older tyn would be.
+	err := m.ensureTable(ctx)
+	if err != nil {
+		t.Fatalf("ensureTable() error = %v", err)
+	}
+	for _, mig := range migrations[:5] {
+		err = m.apply(ctx, mig)
+		if err != nil {
+			t.Fatalf("apply(%d) error = %v", mig.Version, err)
+		}
+	}
+
+	applied, err := m.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(applied) != len(migrations)-5 || applied[0].Version != migrations[5].Version {
+		t.Errorf("Migrate() applied %v, want the migrations from %d on", applied, migrations[5].Version)
+	}
+
+	for i := 0; i < 2; i++ {
+		applied, err = m.Migrate(ctx)
+		if err != nil {
+			t.Fatalf("Migrate() again error = %v", err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("Migrate() again applied %v, want nothing", applied)
+		}
+	}
+
+	checkSchemaMigrations(t, db)
+
+	var nodes int
+	err = db.Get(&nodes, `SELECT COUNT(*) FROM nodes`)
+	if err != nil {
+		t.Fatalf("error counting nodes: %v", err)
+	}
+	if nodes != 1 {
+		t.Errorf("nodes after migrating again = %d, want 1", nodes)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	m := NewMigrator(db, nil)
+
+	_, err := m.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	_, err = db.Exec(Query["insert_schema_migration"], LatestSchemaVersion()+1, "from the future", "2099-01-01 00:00:00")
+	if err != nil {
+		t.Fatalf("error recording a newer migration: %v", err)
+	}
+
+	_, err = m.Migrate(ctx)
+	if err == nil {
+		t.Error("Migrate() error = nil, want a newer schema to be refused")
+	}
+}
+
+func TestMoveCSVLabelsKeepsOrder(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	createBaseline(t, db, "first", "zeta,alpha,mid", "office,home")
+	createBaseline(t, db, "second", "alpha,zeta", "")
+
+	_, err := NewMigrator(db, nil).Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var joined []string
+	err = db.Select(&joined, `SELECT t.name FROM node_tags nt JOIN tags t ON t.id = nt.tag_id
+		WHERE nt.node_id = ? ORDER BY nt.position`, "first")
+	if err != nil {
+		t.Fatalf("error reading node_tags: %v", err)
+	}
+	if !equalLabels(joined, []string{"zeta", "alpha", "mid"}) {
+		t.Errorf("node_tags of first = %v, want [zeta alpha mid]", joined)
+	}
+
+	var tags int
+	err = db.Get(&tags, `SELECT COUNT(*) FROM tags`)
+	if err != nil {
+		t.Fatalf("error counting tags: %v", err)
+	}
+	if tags != 3 {
+		t.Errorf("tags has %d rows, want 3 shared by both nodes", tags)
+	}
+
+	repo, err := NewTynRepo(nil)
+	if err != nil {
+		t.Fatalf("NewTynRepo() error = %v", err)
+	}
+	defer repo.Close()
+
+	tests := []struct {
+		id         string
+		wantTags   []string
+		wantPlaces []string
+	}{
+		{id: "first", wantTags: []string{"zeta", "alpha", "mid"}, wantPlaces: []string{"office", "home"}},
+		{id: "second", wantTags: []string{"alpha", "zeta"}},
+	}
+
+	for _, tt := range tests {
+		node, err := repo.Get(ctx, tt.id)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", tt.id, err)
+		}
+		if !equalLabels(node.Tags, tt.wantTags) || !equalLabels(node.Places, tt.wantPlaces) {
+			t.Errorf("Get(%s) = tags %v places %v, want %v %v", tt.id, node.Tags, node.Places, tt.wantTags, tt.wantPlaces)
+		}
+	}
+
+	// Labels are read back split on char(31), so a comma no longer splits them.
+	node, err := repo.Get(ctx, "first")
+	if err != nil {
+		t.Fatalf("Get(first) error = %v", err)
+	}
+	node.Tags = []string{"mid", "a,b", "zeta"}
+
+	err = repo.Update(ctx, node)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	node, err = repo.Get(ctx, "first")
+	if err != nil {
+		t.Fatalf("Get(first) error = %v", err)
+	}
+	if !equalLabels(node.Tags, []string{"mid", "a,b", "zeta"}) {
+		t.Errorf("Get(first) after Update tags = %v, want [mid a,b zeta]", node.Tags)
+	}
+}
+
+func equalLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,43 @@
 package sqlite
 
+// nodeFields are the columns stored in the nodes table itself.
+const nodeFields = `id, type, content, link, status, draft, date, due_date, recurrence, priority, parent_id, prior_status, archived_at, deleted_at`
+
+// tagsColumn and placesColumn collect the labels of a node, in capture order, separated by labelSep.
+const (
+	tagsColumn = `(SELECT group_concat(t.name, char(31) ORDER BY nt.position)
+		FROM node_tags nt JOIN tags t ON t.id = nt.tag_id WHERE nt.node_id = nodes.id)`
+	placesColumn = `(SELECT group_concat(p.name, char(31) ORDER BY np.position)
+		FROM node_places np JOIN places p ON p.id = np.place_id WHERE np.node_id = nodes.id)`
+)
+
+// nodeColumns is the column list every node query selects, in the order scanNode expects.
+// Queries using it must not alias the nodes table.
+const nodeColumns = `id, type, content, link, ` + tagsColumn + `, ` + placesColumn + `, status, draft, date, due_date, recurrence, priority, parent_id, prior_status, archived_at, deleted_at`
+
+// live selects the nodes that are not in the trash, and visible those that are not archived either.
+const (
+	live    = `deleted_at IS NULL`
+	visible = `deleted_at IS NULL AND archived_at IS NULL`
+)
+
+// Table definitions are applied by the numbered migrations in migration.go.
+// Columns added later are not part of create_nodes_table; they come from their own migrations.
 var Query = map[string]string{
+	"create_schema_migrations_table": `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	);`,
+	"get_schema_version":      `SELECT MAX(version) FROM schema_migrations`,
+	"list_schema_migrations":  `SELECT version, applied_at FROM schema_migrations ORDER BY version`,
+	"insert_schema_migration": `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+	"count_table":             `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+
+	// Backup queries
+	"vacuum_into":     `VACUUM INTO ?`,
+	"integrity_check": `PRAGMA integrity_check`,
+
 	"create_nodes_table": `CREATE TABLE IF NOT EXISTS nodes (
 		id TEXT PRIMARY KEY,
 		type TEXT,
@@ -13,6 +50,60 @@ var Query = map[string]string{
 		date DATETIME,
 		due_date DATETIME
 	);`,
+	"create_nodes_parent_index": `CREATE INDEX IF NOT EXISTS idx_nodes_parent_id ON nodes (parent_id);`,
+	"create_dependencies_table": `CREATE TABLE IF NOT EXISTS dependencies (
+		task_id TEXT NOT NULL,
+		depends_on TEXT NOT NULL,
+		PRIMARY KEY (task_id, depends_on),
+		FOREIGN KEY (task_id) REFERENCES nodes (id) ON DELETE CASCADE,
+		FOREIGN KEY (depends_on) REFERENCES nodes (id) ON DELETE CASCADE
+	);`,
+	"create_dependencies_index": `CREATE INDEX IF NOT EXISTS idx_dependencies_depends_on ON dependencies (depends_on);`,
+	"create_tags_table": `CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE
+	);`,
+	"create_places_table": `CREATE TABLE IF NOT EXISTS places (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE
+	);`,
+	"create_node_tags_table": `CREATE TABLE IF NOT EXISTS node_tags (
+		node_id TEXT NOT NULL,
+		tag_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (node_id, tag_id),
+		FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+		FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+	);`,
+	"create_node_places_table": `CREATE TABLE IF NOT EXISTS node_places (
+		node_id TEXT NOT NULL,
+		place_id INTEGER NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (node_id, place_id),
+		FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+		FOREIGN KEY (place_id) REFERENCES places (id) ON DELETE CASCADE
+	);`,
+	"create_node_tags_index":   `CREATE INDEX IF NOT EXISTS idx_node_tags_tag_id ON node_tags (tag_id);`,
+	"create_node_places_index": `CREATE INDEX IF NOT EXISTS idx_node_places_place_id ON node_places (place_id);`,
+	// nodes_fts keeps its own copy of the searchable text, keyed by node id. Triggers keep it in sync
+	// with every insert, update and delete on nodes, including the ones made by TynRepo.
+	"create_nodes_fts_table": `CREATE VIRTUAL TABLE IF NOT EXISTS nodes_fts USING fts5(
+		node_id UNINDEXED,
+		content,
+		link,
+		draft,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);`,
+	"create_nodes_fts_insert_trigger": `CREATE TRIGGER IF NOT EXISTS nodes_fts_insert AFTER INSERT ON nodes BEGIN
+		INSERT INTO nodes_fts (node_id, content, link, draft) VALUES (new.id, new.content, new.link, new.draft);
+	END;`,
+	"create_nodes_fts_update_trigger": `CREATE TRIGGER IF NOT EXISTS nodes_fts_update AFTER UPDATE OF content, link, draft ON nodes BEGIN
+		UPDATE nodes_fts SET content = new.content, link = new.link, draft = new.draft WHERE node_id = old.id;
+	END;`,
+	"create_nodes_fts_delete_trigger": `CREATE TRIGGER IF NOT EXISTS nodes_fts_delete AFTER DELETE ON nodes BEGIN
+		DELETE FROM nodes_fts WHERE node_id = old.id;
+	END;`,
+	"fill_nodes_fts": `INSERT INTO nodes_fts (node_id, content, link, draft) SELECT id, content, link, draft FROM nodes`,
 	"create_notifications_table": `CREATE TABLE IF NOT EXISTS notifications (
 		id TEXT PRIMARY KEY,
 		node_id TEXT NOT NULL,
@@ -21,20 +112,93 @@ var Query = map[string]string{
 		times_notified INTEGER DEFAULT 1,
 		FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE
 	);`,
+	"create_views_table": `CREATE TABLE IF NOT EXISTS views (
+		name TEXT PRIMARY KEY,
+		query TEXT NOT NULL,
+		section TEXT DEFAULT '',
+		created_at DATETIME NOT NULL
+	);`,
 
 	// Node queries
-	"create":            `INSERT INTO nodes (id, type, content, link, tags, places, status, draft, date, due_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-	"get":               `SELECT id, type, content, link, tags, places, status, draft, date, due_date FROM nodes WHERE id = ?`,
-	"get_by_partial_id": `SELECT id, type, content, link, tags, places, status, draft, date, due_date FROM nodes WHERE id LIKE ? || '%'`,
-	"update":            `UPDATE nodes SET type=?, content=?, link=?, tags=?, places=?, status=?, draft=?, date=?, due_date=? WHERE id=?`,
+	"create":            `INSERT INTO nodes (` + nodeFields + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	"get":               `SELECT ` + nodeColumns + ` FROM nodes WHERE id = ?`,
+	"get_by_partial_id": `SELECT ` + nodeColumns + ` FROM nodes WHERE lower(substr(id, 1, length(?1))) = lower(?1) ORDER BY id`,
+	"list_ids":          `SELECT id FROM nodes`,
+	"list_trash":        `SELECT ` + nodeColumns + ` FROM nodes WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`,
+	"update":            `UPDATE nodes SET type=?, content=?, link=?, status=?, draft=?, date=?, due_date=?, recurrence=?, priority=?, parent_id=?, prior_status=?, archived_at=?, deleted_at=? WHERE id=?`,
 	"delete":            `DELETE FROM nodes WHERE id = ?`,
-	"list":              `SELECT id, type, content, link, tags, places, status, draft, date, due_date FROM nodes`,
-	"list_by_day": `SELECT id, type, content, link, tags, places, status, draft, date, due_date FROM nodes 
-		WHERE date >= ? AND date < ?`,
-	"list_notes_and_links_by_day": `SELECT id, type, content, link, tags, places, status, draft, date, due_date FROM nodes 
-		WHERE (type = 'note' OR type = 'link') AND date >= ? AND date < ?`,
-	"list_all_tasks": `SELECT id, type, content, link, tags, places, status, draft, date, due_date FROM nodes
-		WHERE type = 'task' ORDER BY date`,
+	"list_children":     `SELECT ` + nodeColumns + ` FROM nodes WHERE parent_id = ? AND ` + live + ` ORDER BY date`,
+	"list":              `SELECT ` + nodeColumns + ` FROM nodes`,
+	"list_all":          `SELECT ` + nodeColumns + ` FROM nodes WHERE ` + live + ` ORDER BY date, id`,
+	"list_by_day": `SELECT ` + nodeColumns + ` FROM nodes 
+		WHERE date >= ? AND date < ? AND ` + visible,
+	"list_notes_and_links_by_day": `SELECT ` + nodeColumns + ` FROM nodes 
+		WHERE (type = 'note' OR type = 'link') AND date >= ? AND date < ? AND ` + visible,
+	"list_all_tasks": `SELECT ` + nodeColumns + ` FROM nodes
+		WHERE type = 'task' AND ` + visible + ` ORDER BY CASE WHEN priority > 0 THEN priority ELSE 5 END, date`,
+	"list_recent": `SELECT ` + nodeColumns + ` FROM nodes
+		WHERE ` + live + ` AND (
+			type != 'task'
+			OR (
+				type = 'task' AND (
+					status NOT IN ('done', 'canceled')
+					OR (status IN ('done', 'canceled') AND date >= ?)
+					OR parent_id IN (SELECT id FROM nodes WHERE status NOT IN ('done', 'canceled'))
+				)
+			)
+		)`,
+	// Filters appended to list_recent by ListFiltered; the IN lists are expanded to one placeholder per value
+	"filter_type":       ` AND type = ?`,
+	"filter_status":     ` AND status = ?`,
+	"filter_archived":   ` AND archived_at IS NOT NULL`,
+	"filter_unarchived": ` AND archived_at IS NULL`,
+	"filter_tags": ` AND id IN (SELECT nt.node_id FROM node_tags nt JOIN tags t ON t.id = nt.tag_id
+		WHERE t.name IN (%s))`,
+	"filter_places": ` AND id IN (SELECT np.node_id FROM node_places np JOIN places p ON p.id = np.place_id
+		WHERE p.name IN (%s))`,
+	// Conditions of list queries, see compileCond
+	"query_tag": `id IN (SELECT nt.node_id FROM node_tags nt JOIN tags t ON t.id = nt.tag_id
+		WHERE t.name = ?)`,
+	"query_place": `id IN (SELECT np.node_id FROM node_places np JOIN places p ON p.id = np.place_id
+		WHERE p.name = ?)`,
+	"query_text": `content LIKE ? ESCAPE '\'`,
+
+	// search matches nodes_fts and is narrowed down with the filter_* queries before search_order
+	"search": `SELECT ` + nodeColumns + `, m.snippet, m.rank FROM nodes
+		JOIN (
+			SELECT node_id,
+				snippet(nodes_fts, -1, char(2), char(3), '…', 12) AS snippet,
+				bm25(nodes_fts) AS rank
+			FROM nodes_fts WHERE nodes_fts MATCH ?
+		) m ON m.node_id = nodes.id
+		WHERE ` + live,
+	"search_order": ` ORDER BY m.rank LIMIT ?`,
+
+	// Tag and place queries
+	"insert_tag":         `INSERT OR IGNORE INTO tags (name) VALUES (?)`,
+	"link_node_tag":      `INSERT OR IGNORE INTO node_tags (node_id, tag_id, position) SELECT ?, id, ? FROM tags WHERE name = ?`,
+	"unlink_node_tags":   `DELETE FROM node_tags WHERE node_id = ?`,
+	"insert_place":       `INSERT OR IGNORE INTO places (name) VALUES (?)`,
+	"link_node_place":    `INSERT OR IGNORE INTO node_places (node_id, place_id, position) SELECT ?, id, ? FROM places WHERE name = ?`,
+	"unlink_node_places": `DELETE FROM node_places WHERE node_id = ?`,
+	"list_csv_labels":    `SELECT id, COALESCE(tags, ''), COALESCE(places, '') FROM nodes`,
+
+	// Dependency queries
+	"add_dependency":           `INSERT OR IGNORE INTO dependencies (task_id, depends_on) VALUES (?, ?)`,
+	"remove_dependency":        `DELETE FROM dependencies WHERE task_id = ? AND depends_on = ?`,
+	"delete_node_dependencies": `DELETE FROM dependencies WHERE task_id = ? OR depends_on = ?`,
+	"list_dependencies": `SELECT ` + nodeColumns + ` FROM nodes
+		WHERE id IN (SELECT depends_on FROM dependencies WHERE task_id = ?) AND ` + live + ` ORDER BY date`,
+	"list_dependents": `SELECT ` + nodeColumns + ` FROM nodes
+		WHERE id IN (SELECT task_id FROM dependencies WHERE depends_on = ?) AND ` + live + ` ORDER BY date`,
+	"list_all_dependencies": `SELECT task_id, depends_on FROM dependencies ORDER BY task_id, depends_on`,
+
+	// View queries
+	"save_view": `INSERT INTO views (name, query, section, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET query = excluded.query, section = excluded.section`,
+	"get_view":    `SELECT name, query, section, created_at FROM views WHERE name = ?`,
+	"list_views":  `SELECT name, query, section, created_at FROM views ORDER BY name`,
+	"delete_view": `DELETE FROM views WHERE name = ?`,
 
 	// Notification queries
 	"create_notification": `INSERT INTO notifications (id, node_id, notification_type, last_notified_at, times_notified) 
@@ -52,15 +216,16 @@ var Query = map[string]string{
 	"delete_notification_by_node": `DELETE FROM notifications WHERE node_id = ?`,
 	"list_notifications": `SELECT id, node_id, notification_type, last_notified_at, times_notified 
 		FROM notifications`,
-	"get_overdue_tasks": `SELECT n.id, n.type, n.content, n.link, n.tags, n.places, n.status, n.draft, n.date, n.due_date
-		FROM nodes n
-		WHERE n.type = 'task'
-		AND n.due_date IS NOT NULL
-		AND strftime('%s', n.due_date) < strftime('%s', 'now')
-		AND (n.status IS NULL OR n.status != 'done')
+	"get_overdue_tasks": `SELECT ` + nodeColumns + `
+		FROM nodes
+		WHERE nodes.type = 'task'
+		AND nodes.due_date IS NOT NULL
+		AND strftime('%s', nodes.due_date) < strftime('%s', 'now')
+		AND (nodes.status IS NULL OR nodes.status != 'done')
+		AND nodes.deleted_at IS NULL AND nodes.archived_at IS NULL
 		AND NOT EXISTS (
 			SELECT 1 FROM notifications nt
-			WHERE nt.node_id = n.id
+			WHERE nt.node_id = nodes.id
 			AND nt.notification_type = ?
 			AND nt.last_notified_at >= ? AND nt.last_notified_at < ?
 		)`,
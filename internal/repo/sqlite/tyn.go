@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -17,23 +18,53 @@ import (
 )
 
 type TynRepo struct {
-	db  *sqlx.DB
-	cfg *config.Config
+	db   *sqlx.DB
+	cfg  *config.Config
+	path string
 }
 
 func NewTynRepo(cfg *config.Config) (*TynRepo, error) {
-	dbPath := getDBPath()
-	db, err := sqlx.Open("sqlite", dbPath)
+	path := getDBPath()
+	db, err := openDB(path)
 	if err != nil {
 		return nil, err
 	}
 
-	err = migrate(db)
+	err = migrate(db, cfg)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	return &TynRepo{db: db, cfg: cfg}, nil
+	return &TynRepo{db: db, cfg: cfg, path: path}, nil
+}
+
+// Path is the database file the repository works on.
+func (r *TynRepo) Path() string {
+	return r.path
+}
+
+func (r *TynRepo) Close() error {
+	return r.db.Close()
+}
+
+// openDB opens the database at path. Besides the daemon, direct commands such as tn import
+// and commands run with --direct write to it, so a writer waits for the others instead of
+// failing right away. WAL lets readers go on while one of them writes, and transactions take
+// the write lock when they begin, so two read-then-write transactions cannot deadlock.
+func openDB(path string) (*sqlx.DB, error) {
+	return sqlx.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate")
+}
+
+// openReadOnlyDB opens the database at path for reading only. A missing database is opened
+// as an empty in-memory one, so that nothing is created on disk.
+func openReadOnlyDB(path string) (*sqlx.DB, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sqlx.Open("sqlite", ":memory:")
+	}
+
+	return sqlx.Open("sqlite", "file:"+path+"?mode=ro&_pragma=busy_timeout(5000)")
 }
 
 func getDBPath() string {
@@ -68,126 +99,453 @@ func getDBPath() string {
 func (r *TynRepo) Create(ctx context.Context, node model.Node) error {
 	log.Printf("Repository - Before writing to DB: DueDate = %v", node.DueDate)
 
+	return r.CreateAll(ctx, []model.Node{node})
+}
+
+// CreateAll stores nodes in a single transaction: either all of them are created or none is.
+func (r *TynRepo) CreateAll(ctx context.Context, nodes []model.Node) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, node := range nodes {
+		err = insertNode(ctx, tx, node)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertNode(ctx context.Context, db execer, node model.Node) error {
 	var dueDateStr interface{} = nil
 	if node.DueDate != nil {
 		utcDueDate := node.DueDate.UTC()
 		dueDateStr = utcDueDate.Format(model.DateTimeFormat)
-		log.Printf("Repository - Formatted DueDate for DB (UTC): %v", dueDateStr)
 	}
 
-	_, err := r.db.ExecContext(ctx, Query["create"],
-		node.ID, node.Type, node.Content, node.Link,
-		stringSliceToCSV(node.Tags), stringSliceToCSV(node.Places), node.Status,
-		node.Draft, node.Date.UTC().Format(model.DateTimeFormat), dueDateStr,
+	_, err := db.ExecContext(ctx, Query["create"],
+		node.ID, node.Type, node.Content, node.Link, node.Status,
+		node.Draft, node.Date.UTC().Format(model.DateTimeFormat), dueDateStr, node.Recurrence, node.Priority, node.ParentID, node.PriorStatus,
+		formatTime(node.ArchivedAt), formatTime(node.DeletedAt),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return writeLabels(ctx, db, node)
 }
 
 func (r *TynRepo) Get(ctx context.Context, id string) (model.Node, error) {
-	var node model.Node
-	var tags, places string
-	var dueDate sql.NullTime
-
 	row := r.db.QueryRowContext(ctx, Query["get"], id)
-	err := row.Scan(&node.ID, &node.Type, &node.Content, &node.Link, &tags, &places, &node.Status, &node.Draft, &node.Date, &dueDate)
+	return scanNode(row)
+}
+
+func (r *TynRepo) Update(ctx context.Context, node model.Node) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return node, err
+		return err
 	}
+	defer tx.Rollback()
 
-	node.Tags = csvToStringSlice(tags)
-	node.Places = csvToStringSlice(places)
-	if dueDate.Valid {
-		localTime := dueDate.Time.In(time.Local)
-		node.DueDate = &localTime
+	err = updateNode(ctx, tx, node)
+	if err != nil {
+		return err
 	}
-	return node, nil
+
+	return tx.Commit()
 }
 
-func (r *TynRepo) Update(ctx context.Context, node model.Node) error {
+func updateNode(ctx context.Context, db execer, node model.Node) error {
 	var dueDateStr interface{} = nil
 	if node.DueDate != nil {
 		utcDueDate := node.DueDate.UTC()
 		dueDateStr = utcDueDate.Format(model.DateTimeFormat)
 	}
 
-	_, err := r.db.ExecContext(ctx, Query["update"],
-		node.Type, node.Content, node.Link,
-		stringSliceToCSV(node.Tags), stringSliceToCSV(node.Places), node.Status,
-		node.Draft, node.Date.UTC().Format(model.DateTimeFormat), dueDateStr, node.ID,
+	_, err := db.ExecContext(ctx, Query["update"],
+		node.Type, node.Content, node.Link, node.Status,
+		node.Draft, node.Date.UTC().Format(model.DateTimeFormat), dueDateStr, node.Recurrence, node.Priority, node.ParentID, node.PriorStatus,
+		formatTime(node.ArchivedAt), formatTime(node.DeletedAt), node.ID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return writeLabels(ctx, db, node)
 }
 
-func (r *TynRepo) UpdateTask(ctx context.Context, node model.Node) error {
-	var dueDateStr interface{} = nil
-	if node.DueDate != nil {
-		utcDueDate := node.DueDate.UTC()
-		dueDateStr = utcDueDate.Format(model.DateTimeFormat)
+// Import writes the records of an import in a single transaction: either all of them are
+// written or none is.
+func (r *TynRepo) Import(ctx context.Context, batch model.ImportBatch) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	_, err := r.db.ExecContext(ctx, Query["update"],
-		node.Type, node.Content, node.Link,
-		stringSliceToCSV(node.Tags), stringSliceToCSV(node.Places), node.Status,
-		node.Draft, node.Date.UTC().Format(model.DateTimeFormat), dueDateStr, node.ID,
-	)
-	return err
+	for _, node := range batch.Create {
+		err = insertNode(ctx, tx, node)
+		if err != nil {
+			return fmt.Errorf("error importing node %s: %w", node.ID, err)
+		}
+	}
+
+	for _, node := range batch.Overwrite {
+		err = updateNode(ctx, tx, node)
+		if err != nil {
+			return fmt.Errorf("error overwriting node %s: %w", node.ID, err)
+		}
+	}
+
+	for _, id := range batch.Replaced {
+		_, err = tx.ExecContext(ctx, Query["delete_notification"], id)
+		if err != nil {
+			return fmt.Errorf("error overwriting notification %s: %w", id, err)
+		}
+	}
+
+	for _, notification := range batch.Notifications {
+		err = insertNotification(ctx, tx, notification)
+		if err != nil {
+			return fmt.Errorf("error importing notification %s: %w", notification.ID, err)
+		}
+	}
+
+	for _, dep := range batch.Dependencies {
+		_, err = tx.ExecContext(ctx, Query["add_dependency"], dep.TaskID, dep.DependsOn)
+		if err != nil {
+			return fmt.Errorf("error importing dependency %s -> %s: %w", dep.TaskID, dep.DependsOn, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *TynRepo) GetChildren(ctx context.Context, parentID string) ([]model.Node, error) {
+	rows, err := r.db.QueryContext(ctx, Query["list_children"], parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNodes(rows)
 }
 
 func (r *TynRepo) Delete(ctx context.Context, id string) error {
-	_, err := r.db.ExecContext(ctx, Query["delete"], id)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, Query["delete_node_dependencies"], id, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, Query["unlink_node_tags"], id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, Query["unlink_node_places"], id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, Query["delete"], id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TynRepo) AddDependency(ctx context.Context, taskID, dependsOn string) error {
+	_, err := r.db.ExecContext(ctx, Query["add_dependency"], taskID, dependsOn)
+	return err
+}
+
+func (r *TynRepo) RemoveDependency(ctx context.Context, taskID, dependsOn string) error {
+	_, err := r.db.ExecContext(ctx, Query["remove_dependency"], taskID, dependsOn)
 	return err
 }
 
+// GetDependencies returns the tasks taskID depends on.
+func (r *TynRepo) GetDependencies(ctx context.Context, taskID string) ([]model.Node, error) {
+	rows, err := r.db.QueryContext(ctx, Query["list_dependencies"], taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNodes(rows)
+}
+
+// GetDependents returns the tasks that depend on taskID.
+func (r *TynRepo) GetDependents(ctx context.Context, taskID string) ([]model.Node, error) {
+	rows, err := r.db.QueryContext(ctx, Query["list_dependents"], taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNodes(rows)
+}
+
+// ListAllDependencies lists every dependency between nodes.
+func (r *TynRepo) ListAllDependencies(ctx context.Context) ([]model.Dependency, error) {
+	rows, err := r.db.QueryContext(ctx, Query["list_all_dependencies"])
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deps []model.Dependency
+	for rows.Next() {
+		var dep model.Dependency
+		err = rows.Scan(&dep.TaskID, &dep.DependsOn)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deps, nil
+}
+
 func (r *TynRepo) List(ctx context.Context) ([]model.Node, error) {
+	return r.ListFiltered(ctx, model.Filter{})
+}
+
+// ListAll lists every node, including closed tasks List leaves out, oldest first.
+func (r *TynRepo) ListAll(ctx context.Context) ([]model.Node, error) {
+	rows, err := r.db.QueryContext(ctx, Query["list_all"])
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNodes(rows)
+}
+
+// ListFiltered lists the same nodes as List, narrowed down by filter in the query itself.
+// A node matches the tag filter if it has any of the tags, and the place filter likewise.
+func (r *TynRepo) ListFiltered(ctx context.Context, filter model.Filter) ([]model.Node, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	daysLimit := r.cfg.DoneTaskListDays
+	daysLimit := r.cfg.Snapshot().DoneTaskListDays
 	cutoff := time.Now().AddDate(0, 0, -daysLimit)
 	cutoffStr := cutoff.Format(model.DateTimeFormat)
 
-	query := `SELECT id, type, content, link, tags, places, status, draft, date, due_date FROM nodes
-		WHERE type != 'task'
-		   OR (
-			   type = 'task' AND (
-				   status NOT IN ('done', 'canceled')
-				   OR (status IN ('done', 'canceled') AND date >= ?)
-			   )
-		   )`
+	query, args, err := appendFilter(Query["list_recent"], []interface{}{cutoffStr}, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Archived {
+		query += Query["filter_archived"]
+	} else {
+		query += Query["filter_unarchived"]
+	}
+
+	if len(filter.Query.Sort) > 0 {
+		order, err := orderBy(filter.Query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		query += order
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNodes(rows)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, cutoffStr)
+// Search runs an FTS5 match expression over the content, link and draft of every node,
+// narrowed down by filter, and returns up to limit results, most relevant first.
+func (r *TynRepo) Search(ctx context.Context, match string, filter model.Filter, limit int) ([]model.SearchResult, error) {
+	query, args, err := appendFilter(Query["search"], []interface{}{match}, filter)
+	if err != nil {
+		return nil, err
+	}
+	query += Query["search_order"]
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var nodes []model.Node
+	var results []model.SearchResult
 	for rows.Next() {
-		var node model.Node
-		var tags, places string
-		var dueDate sql.NullTime
-
-		err := rows.Scan(&node.ID, &node.Type, &node.Content, &node.Link, &tags, &places, &node.Status, &node.Draft, &node.Date, &dueDate)
+		var result model.SearchResult
+		result.Node, err = scanNode(extraScanner{row: rows, extra: []interface{}{&result.Snippet, &result.Rank}})
 		if err != nil {
 			return nil, err
 		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// appendFilter adds the conditions of filter, its query included, to a query ending in a WHERE clause.
+func appendFilter(query string, args []interface{}, filter model.Filter) (string, []interface{}, error) {
+	if filter.Type != "" {
+		query += Query["filter_type"]
+		args = append(args, filter.Type)
+	}
 
-		node.Tags = csvToStringSlice(tags)
-		node.Places = csvToStringSlice(places)
+	if filter.Status != "" {
+		query += Query["filter_status"]
+		args = append(args, filter.Status)
+	}
 
-		if dueDate.Valid {
-			localTime := dueDate.Time.In(time.Local)
-			node.DueDate = &localTime
+	if len(filter.Tags) > 0 {
+		query += fmt.Sprintf(Query["filter_tags"], placeholders(len(filter.Tags)))
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
 		}
+	}
 
-		nodes = append(nodes, node)
+	if len(filter.Places) > 0 {
+		query += fmt.Sprintf(Query["filter_places"], placeholders(len(filter.Places)))
+		for _, place := range filter.Places {
+			args = append(args, place)
+		}
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+	if filter.Query.Where != nil {
+		where, whereArgs, err := compileCond(filter.Query.Where)
+		if err != nil {
+			return "", nil, err
+		}
+		query += " AND " + where
+		args = append(args, whereArgs...)
 	}
 
-	return nodes, nil
+	return query, args, nil
+}
+
+// compileCond turns a query condition into an SQL expression over the nodes table and its
+// arguments.
+func compileCond(c *model.Cond) (string, []interface{}, error) {
+	switch c.Op {
+	case model.CondAnd, model.CondOr:
+		var parts []string
+		var args []interface{}
+		for _, arg := range c.Args {
+			part, partArgs, err := compileCond(arg)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, part)
+			args = append(args, partArgs...)
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(c.Op)+" ") + ")", args, nil
+
+	case model.CondNot:
+		if len(c.Args) != 1 {
+			return "", nil, fmt.Errorf("not takes one condition, got %d", len(c.Args))
+		}
+		part, args, err := compileCond(c.Args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT " + part, args, nil
+
+	case model.CondEq, model.CondLt, model.CondLe, model.CondGt, model.CondGe:
+		return compileComparison(c)
+
+	default:
+		return "", nil, fmt.Errorf("unknown query operator: %s", c.Op)
+	}
+}
+
+func compileComparison(c *model.Cond) (string, []interface{}, error) {
+	if c.Op != model.CondEq && c.Field != model.FieldDue && c.Field != model.FieldCreated {
+		return "", nil, fmt.Errorf("%s cannot be compared with %s", c.Field, c.Op)
+	}
+
+	switch c.Field {
+	case model.FieldType:
+		return "type = ?", []interface{}{c.Value}, nil
+	case model.FieldStatus:
+		return "status = ?", []interface{}{c.Value}, nil
+	case model.FieldPriority:
+		return "priority = ?", []interface{}{c.Value}, nil
+	case model.FieldTag:
+		return Query["query_tag"], []interface{}{c.Value}, nil
+	case model.FieldPlace:
+		return Query["query_place"], []interface{}{c.Value}, nil
+	case model.FieldText:
+		return Query["query_text"], []interface{}{"%" + escapeLike(c.Value) + "%"}, nil
+	case model.FieldDue:
+		if c.Value == "" {
+			return "due_date IS NULL", nil, nil
+		}
+		// Spelled out so that not due<... also holds for nodes without a due date
+		return "(due_date IS NOT NULL AND due_date " + c.Op + " ?)", []interface{}{c.Value}, nil
+	case model.FieldCreated:
+		return "date " + c.Op + " ?", []interface{}{c.Value}, nil
+	default:
+		return "", nil, fmt.Errorf("unknown query field: %s", c.Field)
+	}
+}
+
+// orderBy returns the ORDER BY clause for sort, with the creation date and ID breaking ties.
+func orderBy(sort []model.SortKey) (string, error) {
+	var keys []string
+	for _, key := range sort {
+		var exprs []string
+		switch key.Field {
+		case model.FieldDue:
+			exprs = []string{"due_date"}
+		case model.FieldCreated:
+			exprs = []string{"date"}
+		case model.FieldPriority:
+			exprs = []string{"CASE WHEN priority > 0 THEN priority ELSE 5 END"}
+		case model.FieldStatus:
+			exprs = []string{"status"}
+		case model.FieldType:
+			exprs = []string{"type"}
+		case model.FieldText:
+			exprs = []string{"content COLLATE NOCASE"}
+		default:
+			return "", fmt.Errorf("cannot sort by %s", key.Field)
+		}
+
+		if key.Desc {
+			exprs[0] += " DESC"
+		}
+		// Nodes without a due date go last either way
+		if key.Field == model.FieldDue {
+			exprs = append([]string{"due_date IS NULL"}, exprs...)
+		}
+
+		keys = append(keys, exprs...)
+	}
+
+	return " ORDER BY " + strings.Join(append(keys, "date", "id"), ", "), nil
+}
+
+// escapeLike escapes the LIKE wildcards in s, for the ESCAPE '\' clause of query_text.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 func (r *TynRepo) GetNodesByDay(day time.Time) ([]model.Node, error) {
@@ -204,30 +562,10 @@ func (r *TynRepo) GetNodesByDay(day time.Time) ([]model.Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var nodes []model.Node
-	for rows.Next() {
-		var node model.Node
-		var tags, places string
-		var dueDate sql.NullTime
-
-		err := rows.Scan(
-			&node.ID, &node.Type, &node.Content, &node.Link,
-			&tags, &places, &node.Status, &node.Draft, &node.Date, &dueDate,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		node.Tags = csvToStringSlice(tags)
-		node.Places = csvToStringSlice(places)
-		if dueDate.Valid {
-			localTime := dueDate.Time.In(time.Local)
-			node.DueDate = &localTime
-		}
 
-		nodes = append(nodes, node)
+	nodes, err := scanNodes(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	log.Printf("GetNodesByDay - Found %d nodes for date %s", len(nodes), day.Format("2006-01-02"))
@@ -248,38 +586,77 @@ func (r *TynRepo) GetNotesAndLinksByDay(day time.Time) ([]model.Node, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	nodes, err := scanNodes(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("GetNotesAndLinksByDay - Found %d notes and links for date %s", len(nodes), day.Format("2006-01-02"))
+	return nodes, nil
+}
+
+// SaveView stores a view, replacing the query and section of one saved under the same name.
+func (r *TynRepo) SaveView(ctx context.Context, view model.View) error {
+	_, err := r.db.ExecContext(ctx, Query["save_view"],
+		view.Name, view.Query, view.Section, view.CreatedAt.UTC().Format(model.DateTimeFormat))
+	return err
+}
+
+// GetView returns the view saved under name, or sql.ErrNoRows.
+func (r *TynRepo) GetView(ctx context.Context, name string) (model.View, error) {
+	return scanView(r.db.QueryRowContext(ctx, Query["get_view"], name))
+}
+
+// ListViews returns the saved views by name.
+func (r *TynRepo) ListViews(ctx context.Context) ([]model.View, error) {
+	rows, err := r.db.QueryContext(ctx, Query["list_views"])
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	var nodes []model.Node
+	var views []model.View
 	for rows.Next() {
-		var node model.Node
-		var tags, places string
-		var dueDate sql.NullTime
-
-		err := rows.Scan(
-			&node.ID, &node.Type, &node.Content, &node.Link,
-			&tags, &places, &node.Status, &node.Draft, &node.Date, &dueDate,
-		)
+		view, err := scanView(rows)
 		if err != nil {
 			return nil, err
 		}
+		views = append(views, view)
+	}
 
-		node.Tags = csvToStringSlice(tags)
-		node.Places = csvToStringSlice(places)
-		if dueDate.Valid {
-			localTime := dueDate.Time.In(time.Local)
-			node.DueDate = &localTime
-		}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		nodes = append(nodes, node)
+	return views, nil
+}
+
+func (r *TynRepo) DeleteView(ctx context.Context, name string) error {
+	_, err := r.db.ExecContext(ctx, Query["delete_view"], name)
+	return err
+}
+
+func scanView(row rowScanner) (model.View, error) {
+	var view model.View
+	var section sql.NullString
+
+	err := row.Scan(&view.Name, &view.Query, &section, &view.CreatedAt)
+	if err != nil {
+		return model.View{}, err
 	}
 
-	log.Printf("GetNotesAndLinksByDay - Found %d notes and links for date %s", len(nodes), day.Format("2006-01-02"))
-	return nodes, nil
+	view.Section = section.String
+	view.CreatedAt = view.CreatedAt.In(time.Local)
+	return view, nil
 }
 
 func (r *TynRepo) CreateNotification(ctx context.Context, notification model.Notification) error {
-	_, err := r.db.ExecContext(ctx, Query["create_notification"],
+	return insertNotification(ctx, r.db, notification)
+}
+
+func insertNotification(ctx context.Context, db execer, notification model.Notification) error {
+	_, err := db.ExecContext(ctx, Query["create_notification"],
 		notification.ID,
 		notification.NodeID,
 		notification.NotificationType,
@@ -385,39 +762,13 @@ func (r *TynRepo) GetOverdueTasks(ctx context.Context, notificationType string)
 		log.Printf("Error executing query: %v", err)
 		return nil, err
 	}
-	defer rows.Close()
-
-	var nodes []model.Node
-	for rows.Next() {
-		var node model.Node
-		var tags, places string
-		var dueDate sql.NullTime
-
-		err := rows.Scan(
-			&node.ID, &node.Type, &node.Content, &node.Link,
-			&tags, &places, &node.Status, &node.Draft, &node.Date, &dueDate,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		node.Tags = csvToStringSlice(tags)
-		node.Places = csvToStringSlice(places)
-		if dueDate.Valid {
-			localTime := dueDate.Time.In(time.Local)
-			node.DueDate = &localTime
-			log.Printf("Found overdue task with due date: %v", *node.DueDate)
-		}
 
-		nodes = append(nodes, node)
-	}
-
-	log.Printf("Found %d overdue tasks", len(nodes))
-
-	if err = rows.Err(); err != nil {
+	nodes, err := scanNodes(rows)
+	if err != nil {
 		return nil, err
 	}
 
+	log.Printf("Found %d overdue tasks", len(nodes))
 	return nodes, nil
 }
 
@@ -430,120 +781,196 @@ func (r *TynRepo) GetAllTasks(ctx context.Context) ([]model.Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var tasks []model.Node
-	for rows.Next() {
-		var task model.Node
-		var tags, places string
-		var dueDate sql.NullTime
+	return scanNodes(rows)
+}
 
-		err := rows.Scan(
-			&task.ID, &task.Type, &task.Content, &task.Link,
-			&tags, &places, &task.Status, &task.Draft, &task.Date, &dueDate,
-		)
-		if err != nil {
-			return nil, err
-		}
+// ListByIDPrefix returns the nodes whose ID starts with prefix, ignoring case.
+func (r *TynRepo) ListByIDPrefix(ctx context.Context, prefix string) ([]model.Node, error) {
+	rows, err := r.db.QueryContext(ctx, Query["get_by_partial_id"], prefix)
+	if err != nil {
+		return nil, err
+	}
 
-		task.Tags = csvToStringSlice(tags)
-		task.Places = csvToStringSlice(places)
-		if dueDate.Valid {
-			localTime := dueDate.Time.In(time.Local)
-			task.DueDate = &localTime
-		}
+	return scanNodes(rows)
+}
 
-		tasks = append(tasks, task)
+// ListTrash returns the nodes in the trash, most recently deleted first.
+func (r *TynRepo) ListTrash(ctx context.Context) ([]model.Node, error) {
+	rows, err := r.db.QueryContext(ctx, Query["list_trash"])
+	if err != nil {
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
+	return scanNodes(rows)
+}
+
+// ListIDs returns the IDs of all nodes, including those in the trash.
+func (r *TynRepo) ListIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.SelectContext(ctx, &ids, Query["list_ids"])
+	if err != nil {
 		return nil, err
 	}
 
-	return tasks, nil
+	return ids, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *TynRepo) GetTaskByID(ctx context.Context, id string) (model.Node, error) {
+// scanNode reads a row selected with nodeColumns.
+func scanNode(row rowScanner) (model.Node, error) {
 	var node model.Node
-	var tags, places string
+	var tags, places sql.NullString
 	var dueDate sql.NullTime
+	var recurrence sql.NullString
+	var priority sql.NullInt64
+	var parentID sql.NullString
+	var priorStatus sql.NullString
+	var archivedAt, deletedAt sql.NullTime
 
-	row := r.db.QueryRowContext(ctx, Query["get"], id)
-	err := row.Scan(&node.ID, &node.Type, &node.Content, &node.Link, &tags, &places, &node.Status, &node.Draft, &node.Date, &dueDate)
-	if err == nil {
-		node.Tags = csvToStringSlice(tags)
-		node.Places = csvToStringSlice(places)
-		if dueDate.Valid {
-			localTime := dueDate.Time.In(time.Local)
-			node.DueDate = &localTime
-		}
-		return node, nil
+	err := row.Scan(
+		&node.ID, &node.Type, &node.Content, &node.Link,
+		&tags, &places, &node.Status, &node.Draft, &node.Date, &dueDate, &recurrence, &priority, &parentID, &priorStatus,
+		&archivedAt, &deletedAt,
+	)
+	if err != nil {
+		return model.Node{}, err
 	}
 
-	rows, err := r.db.QueryContext(ctx, Query["get_by_partial_id"], id)
-	if err != nil {
-		return model.Node{}, fmt.Errorf("task with ID '%s' not found: %v", id, err)
+	node.Tags = splitLabels(tags.String)
+	node.Places = splitLabels(places.String)
+	node.Recurrence = recurrence.String
+	node.Priority = int(priority.Int64)
+	node.ParentID = parentID.String
+	node.PriorStatus = priorStatus.String
+	if dueDate.Valid {
+		localTime := dueDate.Time.In(time.Local)
+		node.DueDate = &localTime
 	}
+	node.ArchivedAt = nullableTime(archivedAt)
+	node.DeletedAt = nullableTime(deletedAt)
+
+	return node, nil
+}
+
+// nullableTime returns a nullable column as a local time, nil for NULL.
+func nullableTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	local := t.Time.In(time.Local)
+	return &local
+}
+
+// formatTime returns t as stored in the database, nil for a nil time.
+func formatTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(model.DateTimeFormat)
+}
+
+// extraScanner scans the node columns followed by extra columns selected after them.
+type extraScanner struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (s extraScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// scanNodes reads all rows selected with nodeColumns and closes them.
+func scanNodes(rows *sql.Rows) ([]model.Node, error) {
 	defer rows.Close()
 
 	var nodes []model.Node
 	for rows.Next() {
-		var n model.Node
-		var t, p string
-		var dd sql.NullTime
-
-		err := rows.Scan(&n.ID, &n.Type, &n.Content, &n.Link, &t, &p, &n.Status, &n.Draft, &n.Date, &dd)
+		node, err := scanNode(rows)
 		if err != nil {
-			return model.Node{}, fmt.Errorf("error scanning task: %v", err)
-		}
-
-		n.Tags = csvToStringSlice(t)
-		n.Places = csvToStringSlice(p)
-		if dd.Valid {
-			localTime := dd.Time.In(time.Local)
-			n.DueDate = &localTime
+			return nil, err
 		}
-
-		nodes = append(nodes, n)
-	}
-
-	if len(nodes) == 0 {
-		return model.Node{}, fmt.Errorf("task with ID '%s' not found", id)
+		nodes = append(nodes, node)
 	}
 
-	if len(nodes) > 1 {
-		for _, n := range nodes {
-			if strings.HasPrefix(n.ID, id) {
-				return n, nil
-			}
-		}
-		log.Printf("Warning: Multiple tasks found with ID prefix '%s', using first match", id)
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return nodes[0], nil
+	return nodes, nil
 }
 
-func stringSliceToCSV(s []string) string {
-	return strings.Join(s, ",")
-}
+// labelSep separates the labels collected by tagsColumn and placesColumn. Unlike a comma,
+// it cannot be typed in a tag or place name.
+const labelSep = "\x1f"
 
-func csvToStringSlice(s string) []string {
+func splitLabels(s string) []string {
 	if s == "" {
 		return nil
 	}
-	return strings.Split(s, ",")
+	return strings.Split(s, labelSep)
+}
+
+// labelQueries names the queries that store one kind of node label.
+type labelQueries struct {
+	insert string
+	link   string
+	unlink string
 }
 
-func migrate(db *sqlx.DB) error {
-	_, err := db.Exec(Query["create_nodes_table"])
+var (
+	tagQueries   = labelQueries{insert: "insert_tag", link: "link_node_tag", unlink: "unlink_node_tags"}
+	placeQueries = labelQueries{insert: "insert_place", link: "link_node_place", unlink: "unlink_node_places"}
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// writeLabels replaces the tags and places of a node with the ones it carries.
+func writeLabels(ctx context.Context, db execer, node model.Node) error {
+	err := replaceLabels(ctx, db, tagQueries, node.ID, node.Tags)
 	if err != nil {
-		return err
+		return fmt.Errorf("error saving tags: %w", err)
 	}
 
-	_, err = db.Exec(Query["create_notifications_table"])
+	err = replaceLabels(ctx, db, placeQueries, node.ID, node.Places)
+	if err != nil {
+		return fmt.Errorf("error saving places: %w", err)
+	}
+
+	return nil
+}
+
+func replaceLabels(ctx context.Context, db execer, q labelQueries, nodeID string, names []string) error {
+	_, err := db.ExecContext(ctx, Query[q.unlink], nodeID)
 	if err != nil {
 		return err
 	}
 
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		_, err = db.ExecContext(ctx, Query[q.insert], name)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, Query[q.link], nodeID, i, name)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,168 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// newTestRepo opens a fresh database in a temporary directory, through TYN_DB_PATH so that
+// package functions working on the database path see the same file.
+func newTestRepo(t *testing.T, cfg *config.Config) *TynRepo {
+	t.Helper()
+
+	t.Setenv("TYN_DB_PATH", filepath.Join(t.TempDir(), "tyn.db"))
+
+	repo, err := NewTynRepo(cfg)
+	if err != nil {
+		t.Fatalf("NewTynRepo() error = %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	return repo
+}
+
+func testNote(content string, tags ...string) model.Node {
+	node := model.Node{
+		Type:    model.Type.Note,
+		Content: content,
+		Tags:    tags,
+		Date:    time.Now(),
+	}
+	node.GenID()
+	return node
+}
+
+func TestImportRollsBackOnFailure(t *testing.T) {
+	repo := newTestRepo(t, nil)
+	ctx := context.Background()
+
+	existing := testNote("existing", "before")
+	err := repo.Create(ctx, existing)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	imported := testNote("imported")
+	overwritten := existing
+	overwritten.Content = "overwritten"
+	overwritten.Tags = []string{"after"}
+	notification := model.Notification{ID: "n1", NodeID: imported.ID, NotificationType: model.NotificationType.DueDate, LastNotifiedAt: time.Now()}
+
+	// The second notification reuses the ID of the first, so the import fails halfway through.
+	batch := model.ImportBatch{
+		Create:        []model.Node{imported},
+		Overwrite:     []model.Node{overwritten},
+		Notifications: []model.Notification{notification, notification},
+	}
+
+	err = repo.Import(ctx, batch)
+	if err == nil {
+		t.Fatal("Import() error = nil, want the duplicate notification to fail")
+	}
+
+	_, err = repo.Get(ctx, imported.ID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Get(imported) error = %v, want %v", err, sql.ErrNoRows)
+	}
+
+	got, err := repo.Get(ctx, existing.ID)
+	if err != nil {
+		t.Fatalf("Get(existing) error = %v", err)
+	}
+	if got.Content != existing.Content || len(got.Tags) != 1 || got.Tags[0] != "before" {
+		t.Errorf("Get(existing) = %q %v, want the node as it was before the import", got.Content, got.Tags)
+	}
+
+	notifications, err := repo.ListNotifications(ctx)
+	if err != nil {
+		t.Fatalf("ListNotifications() error = %v", err)
+	}
+	if len(notifications) != 0 {
+		t.Errorf("ListNotifications() = %v, want none", notifications)
+	}
+
+	err = repo.Import(ctx, model.ImportBatch{Create: []model.Node{imported}, Notifications: []model.Notification{notification}})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	_, err = repo.Get(ctx, imported.ID)
+	if err != nil {
+		t.Errorf("Get(imported) after a successful import error = %v", err)
+	}
+}
+
+func TestSearchIndexFollowsChanges(t *testing.T) {
+	repo := newTestRepo(t, nil)
+	ctx := context.Background()
+
+	search := func(match string) []model.SearchResult {
+		t.Helper()
+		results, err := repo.Search(ctx, match, model.Filter{}, 10)
+		if err != nil {
+			t.Fatalf("Search(%q) error = %v", match, err)
+		}
+		return results
+	}
+
+	indexed := func() int {
+		t.Helper()
+		var rows int
+		err := repo.db.Get(&rows, `SELECT COUNT(*) FROM nodes_fts`)
+		if err != nil {
+			t.Fatalf("error counting nodes_fts: %v", err)
+		}
+		return rows
+	}
+
+	node := testNote("quarterly report")
+	node.Link = "https://example.com/ledger"
+	err := repo.Create(ctx, node)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for _, match := range []string{"quarterly", "ledger"} {
+		if results := search(match); len(results) != 1 || results[0].Node.ID != node.ID {
+			t.Errorf("Search(%q) after insert = %v, want %s", match, results, node.ID)
+		}
+	}
+
+	node.Content = "annual summary"
+	node.Draft = "first outline"
+	err = repo.Update(ctx, node)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if results := search("quarterly"); len(results) != 0 {
+		t.Errorf("Search(quarterly) after update = %v, want none", results)
+	}
+	for _, match := range []string{"annual", "outline"} {
+		if results := search(match); len(results) != 1 {
+			t.Errorf("Search(%q) after update = %v, want %s", match, results, node.ID)
+		}
+	}
+	if rows := indexed(); rows != 1 {
+		t.Errorf("nodes_fts has %d rows after update, want 1", rows)
+	}
+
+	err = repo.Delete(ctx, node.ID)
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if results := search("annual"); len(results) != 0 {
+		t.Errorf("Search(annual) after delete = %v, want none", results)
+	}
+	if rows := indexed(); rows != 0 {
+		t.Errorf("nodes_fts has %d rows after delete, want 0", rows)
+	}
+}
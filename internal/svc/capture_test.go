@@ -0,0 +1,98 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// batchRepo records the batches stored with CreateAll.
+type batchRepo struct {
+	*depRepo
+	batches [][]model.Node
+}
+
+func (r *batchRepo) CreateAll(ctx context.Context, nodes []model.Node) error {
+	for _, n := range nodes {
+		r.nodes[n.ID] = n
+	}
+	r.batches = append(r.batches, nodes)
+	return nil
+}
+
+func TestCaptureAll(t *testing.T) {
+	parent := task("abcd1234", model.Status.Todo)
+
+	tests := []struct {
+		name      string
+		parentRef string
+		texts     []string
+		wantTypes []string
+		wantErr   string
+	}{
+		{
+			name:      "one node per text",
+			texts:     []string{"fix login :todo #changelog", "", "  ", "https://go.dev #changelog"},
+			wantTypes: []string{model.Type.Task, model.Type.Link},
+		},
+		{
+			name:      "under a parent",
+			parentRef: "abcd",
+			texts:     []string{"book flights", "pack"},
+			wantTypes: []string{model.Type.Task, model.Type.Task},
+		},
+		{
+			name:    "a bad line stores nothing",
+			texts:   []string{"fine", "due ^2025-13-45", "also fine"},
+			wantErr: "line 2",
+		},
+		{
+			name:  "only blank lines",
+			texts: []string{"", "\t"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &batchRepo{depRepo: newDepRepo(parent)}
+			s := &Svc{Repo: repo, Parser: Parse}
+
+			nodes, err := s.CaptureAll(context.Background(), tt.parentRef, tt.texts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.Is(err, model.ErrInvalid) {
+					t.Errorf("CaptureAll() error = %v, want a validation error for %s", err, tt.wantErr)
+				}
+				if len(repo.batches) > 0 {
+					t.Errorf("CaptureAll() stored %d batches although a line failed", len(repo.batches))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CaptureAll() error = %v", err)
+			}
+
+			if len(nodes) != len(tt.wantTypes) {
+				t.Fatalf("CaptureAll() created %d nodes, want %d", len(nodes), len(tt.wantTypes))
+			}
+			for i, n := range nodes {
+				if n.Type != tt.wantTypes[i] {
+					t.Errorf("node %d type = %s, want %s", i, n.Type, tt.wantTypes[i])
+				}
+				if tt.parentRef != "" && n.ParentID != parent.ID {
+					t.Errorf("node %d parent = %q, want %q", i, n.ParentID, parent.ID)
+				}
+			}
+
+			wantBatches := 1
+			if len(tt.wantTypes) == 0 {
+				wantBatches = 0
+			}
+			if len(repo.batches) != wantBatches {
+				t.Errorf("CaptureAll() stored %d batches, want %d", len(repo.batches), wantBatches)
+			}
+		})
+	}
+}
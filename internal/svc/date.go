@@ -0,0 +1,138 @@
+package svc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+var dateFormats = []string{
+	"2006-01-02-15-04-05",
+	"2006-01-02T15:04:05",
+	"2006-01-02_15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02-15:04",
+	"2006-01-02",
+}
+
+var (
+	offsetPattern   = regexp.MustCompile(`^\+(\d+)([dwmy])$`)
+	timeSuffPattern = regexp.MustCompile(`^(.+)-(\d{1,2}):(\d{2})$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseDate resolves an absolute or relative date expression against the local clock.
+// See parseDateAt for the accepted forms.
+func ParseDate(expr string) (time.Time, error) {
+	return parseDateAt(expr, time.Now())
+}
+
+// parseDateAt resolves expr relative to now. Absolute layouts (2006-01-02, 2006-01-02T15:04:05, ...)
+// are tried first. Otherwise expr is a relative expression such as today, tomorrow, yesterday,
+// a weekday (fri, friday), an offset (+3d, +2w, +1m, +1y), next-week, next-month, next-year,
+// eow, eom or eoy, optionally followed by a time suffix like -17:00.
+// Relative dates without a time suffix resolve to midnight, as absolute dates do.
+func parseDateAt(expr string, now time.Time) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return time.Time{}, model.Errorf(model.ErrInvalid, "empty date")
+	}
+
+	for _, format := range dateFormats {
+		date, err := time.ParseInLocation(format, expr, time.Local)
+		if err == nil {
+			return date, nil
+		}
+	}
+
+	expr = strings.ToLower(expr)
+
+	hour, minute := 0, 0
+	if m := timeSuffPattern.FindStringSubmatch(expr); m != nil {
+		h, _ := strconv.Atoi(m[2])
+		mi, _ := strconv.Atoi(m[3])
+		if h > 23 || mi > 59 {
+			return time.Time{}, model.Errorf(model.ErrInvalid, "invalid time in date: %s", expr)
+		}
+		expr, hour, minute = m[1], h, mi
+	}
+
+	now = now.In(time.Local)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	day, ok := resolveRelativeDay(expr, today)
+	if !ok {
+		return time.Time{}, model.Errorf(model.ErrInvalid, "unable to parse date: %s", expr)
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.Local), nil
+}
+
+func resolveRelativeDay(expr string, today time.Time) (time.Time, bool) {
+	switch expr {
+	case "today", "tod":
+		return today, true
+	case "tomorrow", "tmr":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "next-week":
+		return nextWeekday(today, time.Monday), true
+	case "next-month":
+		return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, time.Local), true
+	case "next-year":
+		return time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, time.Local), true
+	case "eow":
+		return today.AddDate(0, 0, (7-int(today.Weekday()))%7), true
+	case "eom":
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, time.Local), true
+	case "eoy":
+		return time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, time.Local), true
+	}
+
+	if wd, ok := weekdays[expr]; ok {
+		return nextWeekday(today, wd), true
+	}
+
+	if m := offsetPattern.FindStringSubmatch(expr); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		switch m[2] {
+		case "d":
+			return today.AddDate(0, 0, n), true
+		case "w":
+			return today.AddDate(0, 0, 7*n), true
+		case "m":
+			return today.AddDate(0, n, 0), true
+		case "y":
+			return today.AddDate(n, 0, 0), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// nextWeekday returns the next occurrence of wd strictly after day,
+// so ^fri captured on a Friday means the following Friday.
+func nextWeekday(day time.Time, wd time.Weekday) time.Time {
+	diff := (int(wd) - int(day.Weekday()) + 7) % 7
+	if diff == 0 {
+		diff = 7
+	}
+	return day.AddDate(0, 0, diff)
+}
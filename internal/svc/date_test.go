@@ -0,0 +1,151 @@
+package svc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateAt(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 7, 2, 10, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "absolute date",
+			expr:     "2025-07-04",
+			expected: time.Date(2025, 7, 4, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "absolute date and time",
+			expr:     "2025-07-04T15:04:05",
+			expected: time.Date(2025, 7, 4, 15, 4, 5, 0, time.Local),
+		},
+		{
+			name:     "absolute date with time suffix",
+			expr:     "2025-07-04-17:00",
+			expected: time.Date(2025, 7, 4, 17, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "today",
+			expr:     "today",
+			expected: time.Date(2025, 7, 2, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "tomorrow",
+			expr:     "tomorrow",
+			expected: time.Date(2025, 7, 3, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "tomorrow with time",
+			expr:     "tomorrow-17:00",
+			expected: time.Date(2025, 7, 3, 17, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "short weekday",
+			expr:     "fri",
+			expected: time.Date(2025, 7, 4, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "same weekday is next week",
+			expr:     "wednesday",
+			expected: time.Date(2025, 7, 9, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "days offset",
+			expr:     "+3d",
+			expected: time.Date(2025, 7, 5, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "weeks offset",
+			expr:     "+2w",
+			expected: time.Date(2025, 7, 16, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "next week",
+			expr:     "next-week",
+			expected: time.Date(2025, 7, 7, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "next month",
+			expr:     "next-month",
+			expected: time.Date(2025, 8, 1, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "end of month",
+			expr:     "eom",
+			expected: time.Date(2025, 7, 31, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "end of week",
+			expr:     "eow",
+			expected: time.Date(2025, 7, 6, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "case insensitive",
+			expr:     "Tomorrow",
+			expected: time.Date(2025, 7, 3, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:    "invalid time",
+			expr:    "today-25:00",
+			wantErr: true,
+		},
+		{
+			name:    "unknown expression",
+			expr:    "someday",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseDateAt(tt.expr, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDateAt(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !result.Equal(tt.expected) {
+				t.Errorf("parseDateAt(%q) = %v; want %v", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRelativeDueDate(t *testing.T) {
+	node, err := Parse("Send release notes :todo ^tomorrow-17:00 #release")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if node.Status != "todo" {
+		t.Errorf("Parse() Status = %v, want %v", node.Status, "todo")
+	}
+
+	if node.DueDate == nil {
+		t.Fatal("Expected DueDate to be set, but it was nil")
+	}
+
+	if node.DueDate.Hour() != 17 || node.DueDate.Minute() != 0 {
+		t.Errorf("Parse() DueDate = %v, want 17:00", node.DueDate)
+	}
+
+	if node.Content != "Send release notes" {
+		t.Errorf("Parse() Content = %v, want %v", node.Content, "Send release notes")
+	}
+
+	node, err = Parse("Pay invoice ^+3d")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if node.Draft != "" || node.DueDate == nil {
+		t.Errorf("Parse() Draft = %q, DueDate = %v; want no draft and a due date", node.Draft, node.DueDate)
+	}
+}
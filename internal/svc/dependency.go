@@ -0,0 +1,232 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// DependencyChange describes the outcome of adding or removing a dependency between tasks.
+type DependencyChange struct {
+	Task           model.Node
+	DependsOn      model.Node
+	OriginalStatus string
+	NewStatus      string
+}
+
+// TaskDependencies lists what a task depends on and what depends on it.
+type TaskDependencies struct {
+	Task         model.Node
+	Dependencies []model.Node
+	Dependents   []model.Node
+}
+
+// AddDependency records that the task id depends on dependsOnID.
+// The task is blocked while the dependency is open. Circular dependencies are rejected.
+func (s *Svc) AddDependency(ctx context.Context, id, dependsOnID string) (DependencyChange, error) {
+	task, dependsOn, err := s.dependencyPair(ctx, id, dependsOnID)
+	if err != nil {
+		return DependencyChange{}, err
+	}
+
+	if task.ID == dependsOn.ID {
+		return DependencyChange{}, model.Errorf(model.ErrInvalid, "task '%s' cannot depend on itself", s.NodeShortIDs(ctx, task)[task.ID])
+	}
+
+	circular, err := s.dependsOn(ctx, dependsOn.ID, task.ID)
+	if err != nil {
+		return DependencyChange{}, fmt.Errorf("error checking dependencies: %w", err)
+	}
+	if circular {
+		short := s.NodeShortIDs(ctx, task, dependsOn)
+		return DependencyChange{}, model.Errorf(model.ErrInvalid, "circular dependency: task '%s' already depends on '%s'", short[dependsOn.ID], short[task.ID])
+	}
+
+	err = s.Repo.AddDependency(ctx, task.ID, dependsOn.ID)
+	if err != nil {
+		return DependencyChange{}, fmt.Errorf("error adding dependency: %w", err)
+	}
+
+	change := DependencyChange{DependsOn: dependsOn, OriginalStatus: task.Status}
+
+	task, _, err = s.refreshBlocked(ctx, task)
+	if err != nil {
+		return DependencyChange{}, err
+	}
+
+	change.Task = task
+	change.NewStatus = task.Status
+	return change, nil
+}
+
+// RemoveDependency deletes the dependency of the task id on dependsOnID,
+// unblocking the task if it has no open dependencies left.
+func (s *Svc) RemoveDependency(ctx context.Context, id, dependsOnID string) (DependencyChange, error) {
+	task, dependsOn, err := s.dependencyPair(ctx, id, dependsOnID)
+	if err != nil {
+		return DependencyChange{}, err
+	}
+
+	err = s.Repo.RemoveDependency(ctx, task.ID, dependsOn.ID)
+	if err != nil {
+		return DependencyChange{}, fmt.Errorf("error removing dependency: %w", err)
+	}
+
+	change := DependencyChange{DependsOn: dependsOn, OriginalStatus: task.Status}
+
+	task, _, err = s.refreshBlocked(ctx, task)
+	if err != nil {
+		return DependencyChange{}, err
+	}
+
+	change.Task = task
+	change.NewStatus = task.Status
+	return change, nil
+}
+
+// Dependencies returns the tasks the task id depends on and the tasks depending on it.
+func (s *Svc) Dependencies(ctx context.Context, id string) (TaskDependencies, error) {
+	task, err := s.ResolveID(ctx, id)
+	if err != nil {
+		return TaskDependencies{}, err
+	}
+
+	deps, err := s.Repo.GetDependencies(ctx, task.ID)
+	if err != nil {
+		return TaskDependencies{}, fmt.Errorf("error retrieving dependencies: %w", err)
+	}
+
+	dependents, err := s.Repo.GetDependents(ctx, task.ID)
+	if err != nil {
+		return TaskDependencies{}, fmt.Errorf("error retrieving dependents: %w", err)
+	}
+
+	return TaskDependencies{Task: task, Dependencies: deps, Dependents: dependents}, nil
+}
+
+func (s *Svc) dependencyPair(ctx context.Context, id, dependsOnID string) (model.Node, model.Node, error) {
+	task, err := s.ResolveID(ctx, id)
+	if err != nil {
+		return model.Node{}, model.Node{}, err
+	}
+
+	if task.Type != model.Type.Task {
+		return model.Node{}, model.Node{}, model.Errorf(model.ErrInvalid, "node with ID '%s' is not a task", id)
+	}
+
+	dependsOn, err := s.ResolveID(ctx, dependsOnID)
+	if err != nil {
+		return model.Node{}, model.Node{}, err
+	}
+
+	if dependsOn.Type != model.Type.Task {
+		return model.Node{}, model.Node{}, model.Errorf(model.ErrInvalid, "node with ID '%s' is not a task", dependsOnID)
+	}
+
+	return task, dependsOn, nil
+}
+
+// dependsOn reports whether from depends on target, directly or through other tasks.
+func (s *Svc) dependsOn(ctx context.Context, from, target string) (bool, error) {
+	visited := map[string]bool{}
+	stack := []string{from}
+
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if id == target {
+			return true, nil
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		deps, err := s.Repo.GetDependencies(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		for _, dep := range deps {
+			stack = append(stack, dep.ID)
+		}
+	}
+
+	return false, nil
+}
+
+// refreshBlocked blocks a task that has open dependencies, remembering its status,
+// and restores that status once none are left. Closed tasks and tasks blocked by hand are left alone.
+func (s *Svc) refreshBlocked(ctx context.Context, task model.Node) (model.Node, bool, error) {
+	if task.IsClosed() {
+		return task, false, nil
+	}
+
+	deps, err := s.openDependencies(ctx, task.ID)
+	if err != nil {
+		return task, false, err
+	}
+	open := len(deps) > 0
+
+	switch {
+	case open && task.Status != model.Status.Blocked:
+		task.PriorStatus = task.Status
+		task.Status = model.Status.Blocked
+	case !open && task.Status == model.Status.Blocked && task.PriorStatus != "":
+		task.Status = task.PriorStatus
+		task.PriorStatus = ""
+	default:
+		return task, false, nil
+	}
+
+	err = s.Repo.Update(ctx, task)
+	if err != nil {
+		return task, false, fmt.Errorf("error updating task: %w", err)
+	}
+
+	log.Printf("Task %s is now '%s' by its dependencies", task.ID, task.Status)
+	return task, true, nil
+}
+
+// openDependencies returns the dependencies of the task id that are neither done nor canceled.
+func (s *Svc) openDependencies(ctx context.Context, id string) ([]model.Node, error) {
+	deps, err := s.Repo.GetDependencies(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving dependencies: %w", err)
+	}
+
+	var open []model.Node
+	for _, dep := range deps {
+		if !dep.IsClosed() {
+			open = append(open, dep)
+		}
+	}
+	return open, nil
+}
+
+// refreshDependents re-evaluates the tasks depending on task after its status changed.
+func (s *Svc) refreshDependents(ctx context.Context, task model.Node) (blocked, unblocked []model.Node, err error) {
+	dependents, err := s.Repo.GetDependents(ctx, task.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving dependents: %w", err)
+	}
+
+	for _, dependent := range dependents {
+		updated, changed, err := s.refreshBlocked(ctx, dependent)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !changed {
+			continue
+		}
+		if updated.Status == model.Status.Blocked {
+			blocked = append(blocked, updated)
+		} else {
+			unblocked = append(unblocked, updated)
+		}
+	}
+
+	return blocked, unblocked, nil
+}
@@ -0,0 +1,253 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// depRepo is an in-memory Repo covering the methods used by dependency handling.
+type depRepo struct {
+	Repo
+	nodes map[string]model.Node
+	deps  map[string][]string
+}
+
+func newDepRepo(nodes ...model.Node) *depRepo {
+	r := &depRepo{nodes: map[string]model.Node{}, deps: map[string][]string{}}
+	for _, n := range nodes {
+		r.nodes[n.ID] = n
+	}
+	return r
+}
+
+func (r *depRepo) ListByIDPrefix(ctx context.Context, prefix string) ([]model.Node, error) {
+	var nodes []model.Node
+	for id, n := range r.nodes {
+		if strings.HasPrefix(id, prefix) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+func (r *depRepo) ListIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	for id := range r.nodes {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *depRepo) Update(ctx context.Context, node model.Node) error {
+	r.nodes[node.ID] = node
+	return nil
+}
+
+func (r *depRepo) GetChildren(ctx context.Context, parentID string) ([]model.Node, error) {
+	return nil, nil
+}
+
+func (r *depRepo) AddDependency(ctx context.Context, taskID, dependsOn string) error {
+	r.deps[taskID] = append(r.deps[taskID], dependsOn)
+	return nil
+}
+
+func (r *depRepo) RemoveDependency(ctx context.Context, taskID, dependsOn string) error {
+	var kept []string
+	for _, id := range r.deps[taskID] {
+		if id != dependsOn {
+			kept = append(kept, id)
+		}
+	}
+	r.deps[taskID] = kept
+	return nil
+}
+
+func (r *depRepo) GetDependencies(ctx context.Context, taskID string) ([]model.Node, error) {
+	var nodes []model.Node
+	for _, id := range r.deps[taskID] {
+		nodes = append(nodes, r.nodes[id])
+	}
+	return nodes, nil
+}
+
+func (r *depRepo) GetDependents(ctx context.Context, taskID string) ([]model.Node, error) {
+	var nodes []model.Node
+	for id, deps := range r.deps {
+		for _, dep := range deps {
+			if dep == taskID {
+				nodes = append(nodes, r.nodes[id])
+			}
+		}
+	}
+	return nodes, nil
+}
+
+func task(id, status string) model.Node {
+	return model.Node{ID: id, Type: model.Type.Task, Status: status}
+}
+
+func TestAddDependencyBlocksTask(t *testing.T) {
+	repo := newDepRepo(task("a", model.Status.Todo), task("b", model.Status.InProgress))
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	change, err := s.AddDependency(ctx, "b", "a")
+	if err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	if change.NewStatus != model.Status.Blocked {
+		t.Errorf("AddDependency() NewStatus = %v, want %v", change.NewStatus, model.Status.Blocked)
+	}
+
+	if got := repo.nodes["b"].PriorStatus; got != model.Status.InProgress {
+		t.Errorf("PriorStatus = %v, want %v", got, model.Status.InProgress)
+	}
+
+	status, err := s.ChangeStatus(ctx, "a", "set", model.Status.Done)
+	if err != nil {
+		t.Fatalf("ChangeStatus() error = %v", err)
+	}
+
+	if len(status.Unblocked) != 1 || status.Unblocked[0].ID != "b" {
+		t.Fatalf("ChangeStatus() Unblocked = %v, want [b]", status.Unblocked)
+	}
+
+	if got := repo.nodes["b"].Status; got != model.Status.InProgress {
+		t.Errorf("Status after unblock = %v, want %v", got, model.Status.InProgress)
+	}
+
+	status, err = s.ChangeStatus(ctx, "a", "set", model.Status.Todo)
+	if err != nil {
+		t.Fatalf("ChangeStatus() error = %v", err)
+	}
+
+	if len(status.Blocked) != 1 || repo.nodes["b"].Status != model.Status.Blocked {
+		t.Errorf("reopening a dependency should block b again, got status %v", repo.nodes["b"].Status)
+	}
+}
+
+func TestAddDependencyKeepsPartialBlock(t *testing.T) {
+	repo := newDepRepo(task("a", model.Status.Todo), task("b", model.Status.Todo), task("c", model.Status.Todo))
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	for _, dep := range []string{"a", "b"} {
+		if _, err := s.AddDependency(ctx, "c", dep); err != nil {
+			t.Fatalf("AddDependency() error = %v", err)
+		}
+	}
+
+	if _, err := s.ChangeStatus(ctx, "a", "set", model.Status.Canceled); err != nil {
+		t.Fatalf("ChangeStatus() error = %v", err)
+	}
+
+	if got := repo.nodes["c"].Status; got != model.Status.Blocked {
+		t.Errorf("Status with one open dependency = %v, want %v", got, model.Status.Blocked)
+	}
+
+	if _, err := s.RemoveDependency(ctx, "c", "b"); err != nil {
+		t.Fatalf("RemoveDependency() error = %v", err)
+	}
+
+	if got := repo.nodes["c"].Status; got != model.Status.Todo {
+		t.Errorf("Status after removing last open dependency = %v, want %v", got, model.Status.Todo)
+	}
+}
+
+func TestAddDependencyRejectsCycles(t *testing.T) {
+	repo := newDepRepo(task("a", model.Status.Todo), task("b", model.Status.Todo), task("c", model.Status.Todo))
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	if _, err := s.AddDependency(ctx, "b", "a"); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+	if _, err := s.AddDependency(ctx, "c", "b"); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		id        string
+		dependsOn string
+	}{
+		{name: "self dependency", id: "a", dependsOn: "a"},
+		{name: "direct cycle", id: "a", dependsOn: "b"},
+		{name: "transitive cycle", id: "a", dependsOn: "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.AddDependency(ctx, tt.id, tt.dependsOn)
+			if !errors.Is(err, model.ErrInvalid) {
+				t.Errorf("AddDependency(%s, %s) error = %v, want a validation error", tt.id, tt.dependsOn, err)
+			}
+		})
+	}
+}
+
+func TestDependencyErrorsUseUniqueShortIDs(t *testing.T) {
+	repo := newDepRepo(task("abcde111", model.Status.Todo), task("abcde222", model.Status.Todo))
+	s := &Svc{Repo: repo}
+
+	_, err := s.AddDependency(context.Background(), "abcde1", "abcde1")
+	if err == nil || !strings.Contains(err.Error(), "'abcde1'") {
+		t.Errorf("AddDependency() error = %v, want it to name task 'abcde1'", err)
+	}
+}
+
+func TestChangeStatusKeepsDependentBlocked(t *testing.T) {
+	repo := newDepRepo(task("a", model.Status.Todo), task("b", model.Status.Todo), task("c", model.Status.Done))
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	if _, err := s.AddDependency(ctx, "b", "a"); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+	repo.deps["c"] = []string{"a"}
+
+	tests := []struct {
+		name       string
+		id         string
+		status     string
+		wantStatus string
+		wantPrior  string
+		wantBy     int
+	}{
+		{name: "open status", id: "b", status: model.Status.InProgress, wantStatus: model.Status.Blocked, wantPrior: model.Status.InProgress, wantBy: 1},
+		{name: "reopened task", id: "c", status: model.Status.Todo, wantStatus: model.Status.Blocked, wantPrior: model.Status.Todo, wantBy: 1},
+		{name: "canceled", id: "b", status: model.Status.Canceled, wantStatus: model.Status.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, err := s.ChangeStatus(ctx, tt.id, "set", tt.status)
+			if err != nil {
+				t.Fatalf("ChangeStatus() error = %v", err)
+			}
+
+			got := repo.nodes[tt.id]
+			if change.NewStatus != tt.wantStatus || got.Status != tt.wantStatus || got.PriorStatus != tt.wantPrior {
+				t.Errorf("ChangeStatus(%s, %s) = %s, stored %s prior %q, want %s prior %q",
+					tt.id, tt.status, change.NewStatus, got.Status, got.PriorStatus, tt.wantStatus, tt.wantPrior)
+			}
+			if len(change.BlockedBy) != tt.wantBy {
+				t.Errorf("ChangeStatus(%s, %s) BlockedBy = %v, want %d", tt.id, tt.status, change.BlockedBy, tt.wantBy)
+			}
+		})
+	}
+
+	if _, err := s.ChangeStatus(ctx, "a", "set", model.Status.Done); err != nil {
+		t.Fatalf("ChangeStatus() error = %v", err)
+	}
+
+	if got := repo.nodes["c"].Status; got != model.Status.Todo {
+		t.Errorf("Status of c after its dependency is done = %v, want %v", got, model.Status.Todo)
+	}
+}
@@ -0,0 +1,215 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// commentPrefix starts the lines of an edited text that are left out, such as the help the
+// editor templates carry.
+const commentPrefix = ";"
+
+// CaptureTemplate is the text tn capture -e opens the editor with.
+const CaptureTemplate = `
+
+; Write the node above, on as many lines as needed. Sigils such as #tag, @place, :todo,
+; ^tomorrow, !high, *weekly, +draft and <parent work anywhere, as with tn capture.
+; Lines starting with ; are left out. Leave the text empty to capture nothing.
+`
+
+const editHelp = `
+; The first line holds the #tags, @places, :status, ^due date, !priority, *recurrence,
+; +draft name and link URL of the node; change or remove them there. The lines below it
+; are the content, kept as written. Lines starting with ; are left out.
+`
+
+// StripComments removes the lines of text that start with ;.
+func StripComments(text string) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), commentPrefix) {
+			kept = append(kept, line)
+		}
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+// FormatEditable renders a node as tn edit opens it: a header line with the sigils for its
+// fields, the content below it and a few comment lines explaining both.
+func FormatEditable(node model.Node) string {
+	var header []string
+	for _, tag := range node.Tags {
+		header = append(header, "#"+tag)
+	}
+	for _, place := range node.Places {
+		header = append(header, "@"+place)
+	}
+	if node.Status != "" {
+		header = append(header, ":"+node.Status)
+	}
+	if node.DueDate != nil {
+		header = append(header, "^"+formatEditableDate(node.DueDate.Local()))
+	}
+	if node.Priority > 0 {
+		header = append(header, "!"+strings.ToLower(model.Priority.Label(node.Priority)))
+	}
+	if node.Recurrence != "" {
+		header = append(header, "*"+node.Recurrence)
+	}
+	if node.Draft != "" {
+		header = append(header, "+"+node.Draft)
+	}
+	if node.Link != "" {
+		header = append(header, node.Link)
+	}
+
+	return strings.Join(header, " ") + "\n" + node.Content + "\n" + editHelp
+}
+
+// formatEditableDate writes a due date in a form ^ reads back to the same instant.
+func formatEditableDate(date time.Time) string {
+	switch {
+	case date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0:
+		return date.Format("2006-01-02")
+	case date.Second() == 0:
+		return date.Format("2006-01-02T15:04")
+	default:
+		return date.Format("2006-01-02T15:04:05")
+	}
+}
+
+// ApplyEdit updates the node id refers to from text in the form FormatEditable renders, and
+// returns the node with the names of the fields that changed. The type of the node stays as it
+// is: a status or recurrence on a node that is not a task, or a draft name on one that is not a
+// draft, is an error, as is removing the URL of a link or the name of a draft.
+func (s *Svc) ApplyEdit(ctx context.Context, id, text string) (model.Node, []string, error) {
+	node, err := s.ResolveID(ctx, id)
+	if err != nil {
+		return model.Node{}, nil, err
+	}
+
+	header, content, _ := strings.Cut(StripComments(text), "\n")
+
+	fields, err := parseSigils(header)
+	if err != nil {
+		return model.Node{}, nil, err
+	}
+
+	if fields.Content != "" || fields.ParentID != "" {
+		return model.Node{}, nil, model.Errorf(model.ErrInvalid,
+			"the first line takes only #tags, @places, :status, ^due, !priority, *recurrence, +draft and a URL, not '%s'", strings.TrimSpace(header))
+	}
+
+	content = tidyContent(content, content)
+	if content == "" {
+		return model.Node{}, nil, model.Errorf(model.ErrInvalid, "the content is empty")
+	}
+
+	err = checkEditable(node, fields)
+	if err != nil {
+		return model.Node{}, nil, err
+	}
+
+	var changed []string
+	if content != node.Content {
+		node.Content = content
+		changed = append(changed, "content")
+	}
+	if !sameLabels(fields.Tags, node.Tags) {
+		node.Tags = append([]string{}, fields.Tags...)
+		changed = append(changed, "tags")
+	}
+	if !sameLabels(fields.Places, node.Places) {
+		node.Places = append([]string{}, fields.Places...)
+		changed = append(changed, "places")
+	}
+	if !sameDate(fields.DueDate, node.DueDate) {
+		node.DueDate = fields.DueDate
+		changed = append(changed, "due date")
+	}
+	if fields.Priority != node.Priority {
+		node.Priority = fields.Priority
+		changed = append(changed, "priority")
+	}
+	if fields.Recurrence != node.Recurrence {
+		node.Recurrence = fields.Recurrence
+		changed = append(changed, "recurrence")
+	}
+	if fields.Draft != node.Draft {
+		node.Draft = fields.Draft
+		changed = append(changed, "draft")
+	}
+	if fields.Link != node.Link {
+		node.Link = fields.Link
+		changed = append(changed, "link")
+	}
+
+	if len(changed) > 0 {
+		err = s.Repo.Update(ctx, node)
+		if err != nil {
+			return model.Node{}, nil, fmt.Errorf("error updating node: %w", err)
+		}
+	}
+
+	// A status goes through ChangeStatus, so dependents and recurring tasks follow it
+	if fields.Status != "" && fields.Status != node.Status {
+		change, err := s.ChangeStatus(ctx, node.ID, "set", fields.Status)
+		if err != nil {
+			return model.Node{}, nil, err
+		}
+
+		node = change.Task
+		changed = append(changed, "status")
+	}
+
+	return node, changed, nil
+}
+
+// checkEditable reports the fields of an edit the type of node does not take.
+func checkEditable(node, fields model.Node) error {
+	if node.Type != model.Type.Task && (fields.Status != "" || fields.Recurrence != "") {
+		return model.Errorf(model.ErrInvalid, "only tasks take a status or recurrence, convert the %s with tn node convert first", node.Type)
+	}
+
+	if fields.Status != "" && !model.ValidStatus(fields.Status) {
+		return model.Errorf(model.ErrInvalid, "invalid status: %s", fields.Status)
+	}
+
+	if node.Type != model.Type.Draft && fields.Draft != "" {
+		return model.Errorf(model.ErrInvalid, "only drafts take a name, convert the %s with tn node convert first", node.Type)
+	}
+
+	if node.Type == model.Type.Draft && fields.Draft == "" {
+		return model.Errorf(model.ErrInvalid, "a draft needs a name")
+	}
+
+	if node.Type == model.Type.Link && fields.Link == "" {
+		return model.Errorf(model.ErrInvalid, "a link needs a URL")
+	}
+
+	return nil
+}
+
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
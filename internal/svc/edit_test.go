@@ -0,0 +1,132 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+func TestParseKeepsLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"sigils at line ends", "Plan the trip #travel\n- book flights !high\n- pack ^tomorrow", "Plan the trip\n- book flights\n- pack"},
+		{"sigils at line starts", "Shopping\n!high milk\n<abcd1234 bread", "Shopping\nmilk\nbread"},
+		{"blank lines around", "\n\nFirst\n\nSecond\n\n", "First\n\nSecond"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Content != tt.want {
+				t.Errorf("Parse() content = %q, want %q", got.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatEditableRoundTrip(t *testing.T) {
+	due := time.Date(2026, 3, 14, 17, 30, 0, 0, time.Local)
+	nodes := []model.Node{
+		{ID: "t", Type: model.Type.Task, Content: "Write the report\n\n- intro\n- results", Status: model.Status.InProgress,
+			Tags: []string{"work"}, Places: []string{"office"}, DueDate: &due, Priority: model.Priority.High, Recurrence: "weekly:fri"},
+		{ID: "l", Type: model.Type.Link, Content: "Go spec", Link: "https://go.dev/ref/spec"},
+		{ID: "d", Type: model.Type.Draft, Content: "Opening lines", Draft: "essay"},
+	}
+
+	for _, n := range nodes {
+		t.Run(n.Type, func(t *testing.T) {
+			s := &Svc{Repo: newTrashRepo(n)}
+
+			got, changed, err := s.ApplyEdit(context.Background(), n.ID, FormatEditable(n))
+			if err != nil {
+				t.Fatalf("ApplyEdit() error = %v", err)
+			}
+			if len(changed) > 0 {
+				t.Errorf("ApplyEdit() of the unchanged text changed %v", changed)
+			}
+			if !sameNode(got, n) {
+				t.Errorf("ApplyEdit() = %+v, want %+v", got, n)
+			}
+		})
+	}
+}
+
+func TestApplyEdit(t *testing.T) {
+	original := model.Node{ID: "t", Type: model.Type.Task, Content: "old", Status: model.Status.Todo, Tags: []string{"a"}, Priority: model.Priority.Low}
+
+	tests := []struct {
+		name        string
+		text        string
+		wantContent string
+		wantTags    []string
+		wantStatus  string
+		wantChanged []string
+		wantErr     error
+	}{
+		{
+			name:        "content and labels",
+			text:        "#a #b :todo !low\nfirst line\n  indented second\n; a comment\n",
+			wantContent: "first line\n  indented second",
+			wantTags:    []string{"a", "b"},
+			wantStatus:  model.Status.Todo,
+			wantChanged: []string{"content", "tags"},
+		},
+		{
+			name:        "status and cleared fields",
+			text:        ":done\nold",
+			wantContent: "old",
+			wantStatus:  model.Status.Done,
+			wantChanged: []string{"tags", "priority", "status"},
+		},
+		{name: "words in the header", text: "#a urgent\nold", wantErr: model.ErrInvalid},
+		{name: "empty content", text: "#a :todo\n\n; nothing left", wantErr: model.ErrInvalid},
+		{name: "invalid status", text: ":someday\nold", wantErr: model.ErrInvalid},
+		{name: "draft name on a task", text: "+essay\nold", wantErr: model.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTrashRepo(original)
+			s := &Svc{Repo: repo}
+
+			got, changed, err := s.ApplyEdit(context.Background(), "t", tt.text)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ApplyEdit() error = %v, want %v", err, tt.wantErr)
+				}
+				if !sameNode(repo.nodes["t"], original) {
+					t.Errorf("ApplyEdit() stored %+v although it failed", repo.nodes["t"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyEdit() error = %v", err)
+			}
+
+			if got.Content != tt.wantContent {
+				t.Errorf("ApplyEdit() content = %q, want %q", got.Content, tt.wantContent)
+			}
+			if !sliceEqual(got.Tags, tt.wantTags) {
+				t.Errorf("ApplyEdit() tags = %v, want %v", got.Tags, tt.wantTags)
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("ApplyEdit() status = %s, want %s", got.Status, tt.wantStatus)
+			}
+			if !sliceEqual(changed, tt.wantChanged) {
+				t.Errorf("ApplyEdit() changed %v, want %v", changed, tt.wantChanged)
+			}
+			if !sameNode(repo.nodes["t"], got) {
+				t.Errorf("stored node = %+v, want %+v", repo.nodes["t"], got)
+			}
+		})
+	}
+}
@@ -0,0 +1,679 @@
+package svc
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+	"github.com/google/uuid"
+)
+
+// Export file formats: one indented JSON document, or one JSON record per line.
+const (
+	ExportFormatJSON  = "json"
+	ExportFormatJSONL = "jsonl"
+)
+
+// Import conflict policies, applied when an imported node or notification has the ID of one
+// already in the database.
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictNewID     = "new-id"
+)
+
+// Import actions, as reported for every imported record.
+const (
+	ImportCreate    = "create"
+	ImportOverwrite = "overwrite"
+	ImportUnchanged = "unchanged"
+	ImportSkip      = "skip"
+)
+
+// Kinds of imported records.
+const (
+	RecordNode         = "node"
+	RecordNotification = "notification"
+	RecordDependency   = "dependency"
+)
+
+// ExportOptions narrows down the nodes exported. Notifications and dependencies follow their nodes.
+type ExportOptions struct {
+	Type  string
+	Since *time.Time
+}
+
+type ImportOptions struct {
+	Conflict string
+	DryRun   bool
+}
+
+// ImportChange is what an import does, or would do, with one record of the file.
+type ImportChange struct {
+	Kind    string
+	ID      string
+	NewID   string
+	Action  string
+	Reason  string
+	Summary string
+}
+
+type ImportReport struct {
+	DryRun  bool
+	Changes []ImportChange
+}
+
+// Count returns how many records of a kind got an action.
+func (r ImportReport) Count(kind, action string) int {
+	count := 0
+	for _, c := range r.Changes {
+		if c.Kind == kind && c.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+func ValidExportFormat(format string) bool {
+	return format == ExportFormatJSON || format == ExportFormatJSONL
+}
+
+func ValidConflictPolicy(policy string) bool {
+	return policy == ConflictSkip || policy == ConflictOverwrite || policy == ConflictNewID
+}
+
+// Export collects every node matching opts, with the notifications and dependencies among them.
+func (s *Svc) Export(ctx context.Context, opts ExportOptions) (model.Export, error) {
+	if opts.Type != "" && !model.Type.Validate(opts.Type) {
+		return model.Export{}, fmt.Errorf("invalid node type: %s", opts.Type)
+	}
+
+	nodes, err := s.Repo.ListAll(ctx)
+	if err != nil {
+		return model.Export{}, fmt.Errorf("error listing nodes: %w", err)
+	}
+
+	export := model.Export{
+		Version:       model.ExportVersion,
+		ExportedAt:    time.Now().UTC().Truncate(time.Second),
+		Nodes:         []model.ExportNode{},
+		Notifications: []model.ExportNotification{},
+		Dependencies:  []model.Dependency{},
+	}
+
+	included := make(map[string]bool)
+	for _, n := range nodes {
+		if opts.Type != "" && n.Type != opts.Type {
+			continue
+		}
+		if opts.Since != nil && n.Date.Before(*opts.Since) {
+			continue
+		}
+
+		included[n.ID] = true
+		export.Nodes = append(export.Nodes, model.NewExportNode(n))
+	}
+
+	notifications, err := s.Repo.ListNotifications(ctx)
+	if err != nil {
+		return model.Export{}, fmt.Errorf("error listing notifications: %w", err)
+	}
+
+	for _, n := range notifications {
+		if included[n.NodeID] {
+			export.Notifications = append(export.Notifications, model.NewExportNotification(n))
+		}
+	}
+
+	deps, err := s.Repo.ListAllDependencies(ctx)
+	if err != nil {
+		return model.Export{}, fmt.Errorf("error listing dependencies: %w", err)
+	}
+
+	for _, d := range deps {
+		if included[d.TaskID] && included[d.DependsOn] {
+			export.Dependencies = append(export.Dependencies, d)
+		}
+	}
+
+	return export, nil
+}
+
+// exportRecord is one line of a JSONL export. The first line is a header with the format version.
+type exportRecord struct {
+	Kind         string                    `json:"kind"`
+	Version      int                       `json:"version,omitempty"`
+	ExportedAt   *time.Time                `json:"exported_at,omitempty"`
+	Node         *model.ExportNode         `json:"node,omitempty"`
+	Notification *model.ExportNotification `json:"notification,omitempty"`
+	Dependency   *model.Dependency         `json:"dependency,omitempty"`
+}
+
+const recordHeader = "header"
+
+// WriteExport encodes an export in the given format.
+func WriteExport(w io.Writer, export model.Export, format string) error {
+	switch format {
+	case ExportFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(export)
+
+	case ExportFormatJSONL:
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+
+		err := enc.Encode(exportRecord{Kind: recordHeader, Version: export.Version, ExportedAt: &export.ExportedAt})
+		if err != nil {
+			return err
+		}
+
+		for i := range export.Nodes {
+			err = enc.Encode(exportRecord{Kind: RecordNode, Node: &export.Nodes[i]})
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range export.Notifications {
+			err = enc.Encode(exportRecord{Kind: RecordNotification, Notification: &export.Notifications[i]})
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range export.Dependencies {
+			err = enc.Encode(exportRecord{Kind: RecordDependency, Dependency: &export.Dependencies[i]})
+			if err != nil {
+				return err
+			}
+		}
+
+		return bw.Flush()
+
+	default:
+		return fmt.Errorf("invalid export format: %s", format)
+	}
+}
+
+// ReadExport decodes an export written in either format; JSONL is recognized by its kind fields.
+func ReadExport(r io.Reader) (model.Export, error) {
+	dec := json.NewDecoder(r)
+
+	var first json.RawMessage
+	err := dec.Decode(&first)
+	if err == io.EOF {
+		return model.Export{}, fmt.Errorf("export file is empty")
+	}
+	if err != nil {
+		return model.Export{}, fmt.Errorf("error reading export: %w", err)
+	}
+
+	var probe struct {
+		Kind string `json:"kind"`
+	}
+	_ = json.Unmarshal(first, &probe)
+
+	var export model.Export
+	if probe.Kind == "" {
+		err = json.Unmarshal(first, &export)
+		if err != nil {
+			return model.Export{}, fmt.Errorf("error reading export: %w", err)
+		}
+		return export, checkExportVersion(export)
+	}
+
+	raw := first
+	for n := 1; ; n++ {
+		var rec exportRecord
+		err = json.Unmarshal(raw, &rec)
+		if err != nil {
+			return model.Export{}, fmt.Errorf("error reading record %d: %w", n, err)
+		}
+
+		switch {
+		case rec.Kind == recordHeader:
+			export.Version = rec.Version
+			if rec.ExportedAt != nil {
+				export.ExportedAt = *rec.ExportedAt
+			}
+		case rec.Kind == RecordNode && rec.Node != nil:
+			export.Nodes = append(export.Nodes, *rec.Node)
+		case rec.Kind == RecordNotification && rec.Notification != nil:
+			export.Notifications = append(export.Notifications, *rec.Notification)
+		case rec.Kind == RecordDependency && rec.Dependency != nil:
+			export.Dependencies = append(export.Dependencies, *rec.Dependency)
+		default:
+			return model.Export{}, fmt.Errorf("invalid record %d: unknown or empty %q record", n, rec.Kind)
+		}
+
+		raw = nil
+		err = dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return model.Export{}, fmt.Errorf("error reading record %d: %w", n+1, err)
+		}
+	}
+
+	return export, checkExportVersion(export)
+}
+
+func checkExportVersion(export model.Export) error {
+	if export.Version > model.ExportVersion {
+		return fmt.Errorf("export format version %d is newer than the version %d supported by this tyn; please upgrade tyn",
+			export.Version, model.ExportVersion)
+	}
+	return nil
+}
+
+// Import writes the records of an export to the database, resolving ID conflicts with opts.Conflict.
+// With new-id, conflicting nodes get a new ID and the parents, notifications and dependencies that
+// refer to them in the file follow. Dependencies that would form a cycle are rejected, and imported
+// tasks are blocked while their dependencies are open. With opts.DryRun nothing is written; the
+// report tells what would be.
+func (s *Svc) Import(ctx context.Context, export model.Export, opts ImportOptions) (ImportReport, error) {
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictSkip
+	}
+	if !ValidConflictPolicy(opts.Conflict) {
+		return ImportReport{}, fmt.Errorf("invalid conflict policy: %s", opts.Conflict)
+	}
+
+	err := validateExport(export)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	plan, err := s.planImport(ctx, export, opts.Conflict)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	report := ImportReport{DryRun: opts.DryRun, Changes: plan.changes}
+	if opts.DryRun {
+		return report, nil
+	}
+
+	// The records are written in one transaction, so a failing import leaves the database as it was.
+	err = s.Repo.Import(ctx, plan.batch)
+	if err != nil {
+		return report, err
+	}
+
+	err = s.refreshImported(ctx, plan.batch)
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// refreshImported blocks or unblocks the tasks an import touched, as adding a dependency or
+// changing a status would: the imported tasks, the tasks given new dependencies and the tasks
+// depending on an overwritten node.
+func (s *Svc) refreshImported(ctx context.Context, batch model.ImportBatch) error {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, n := range batch.Create {
+		add(n.ID)
+	}
+	for _, n := range batch.Overwrite {
+		add(n.ID)
+
+		dependents, err := s.Repo.GetDependents(ctx, n.ID)
+		if err != nil {
+			return fmt.Errorf("error retrieving dependents: %w", err)
+		}
+		for _, d := range dependents {
+			add(d.ID)
+		}
+	}
+	for _, d := range batch.Dependencies {
+		add(d.TaskID)
+	}
+
+	for _, id := range ids {
+		task, err := s.Repo.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("error retrieving task %s: %w", id, err)
+		}
+		if task.Type != model.Type.Task {
+			continue
+		}
+
+		_, _, err = s.refreshBlocked(ctx, task)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateExport(export model.Export) error {
+	for i, n := range export.Nodes {
+		if n.ID == "" {
+			return fmt.Errorf("node %d has no id", i+1)
+		}
+		if !model.Type.Validate(n.Type) {
+			return fmt.Errorf("node %s has an invalid type: %q", n.ID, n.Type)
+		}
+		if n.Status != "" && !model.ValidStatus(n.Status) {
+			return fmt.Errorf("node %s has an invalid status: %q", n.ID, n.Status)
+		}
+	}
+
+	for i, n := range export.Notifications {
+		if n.ID == "" || n.NodeID == "" {
+			return fmt.Errorf("notification %d needs an id and a node_id", i+1)
+		}
+	}
+
+	for i, d := range export.Dependencies {
+		if d.TaskID == "" || d.DependsOn == "" {
+			return fmt.Errorf("dependency %d needs a task_id and a depends_on", i+1)
+		}
+	}
+
+	if id := dependencyCycle(export.Dependencies); id != "" {
+		return fmt.Errorf("circular dependency: task %s depends on itself", id)
+	}
+
+	return nil
+}
+
+// dependencyCycle returns a task that depends on itself through deps, directly or through other
+// tasks, or "" if there is no such task.
+func dependencyCycle(deps []model.Dependency) string {
+	graph := make(map[string][]string)
+	for _, d := range deps {
+		graph[d.TaskID] = append(graph[d.TaskID], d.DependsOn)
+	}
+
+	// visiting holds the tasks on the current path; done those known to lead to no cycle.
+	visiting := make(map[string]bool)
+	done := make(map[string]bool)
+
+	var visit func(id string) string
+	visit = func(id string) string {
+		if visiting[id] {
+			return id
+		}
+		if done[id] {
+			return ""
+		}
+
+		visiting[id] = true
+		for _, next := range graph[id] {
+			if cycle := visit(next); cycle != "" {
+				return cycle
+			}
+		}
+		visiting[id] = false
+		done[id] = true
+
+		return ""
+	}
+
+	for _, d := range deps {
+		if cycle := visit(d.TaskID); cycle != "" {
+			return cycle
+		}
+	}
+
+	return ""
+}
+
+// importPlan holds the records to write, with IDs already resolved.
+type importPlan struct {
+	changes []ImportChange
+	batch   model.ImportBatch
+}
+
+func (s *Svc) planImport(ctx context.Context, export model.Export, conflict string) (importPlan, error) {
+	var plan importPlan
+
+	// ids maps every node ID in the file to the ID it ends up with in the database.
+	ids := make(map[string]string, len(export.Nodes))
+	existing := make(map[string]model.Node)
+	actions := make(map[string]string, len(export.Nodes))
+
+	for _, n := range export.Nodes {
+		ids[n.ID] = n.ID
+		actions[n.ID] = ImportCreate
+
+		current, found, err := s.findNode(ctx, n.ID)
+		if err != nil {
+			return plan, err
+		}
+		if !found {
+			continue
+		}
+
+		existing[n.ID] = current
+		switch conflict {
+		case ConflictSkip:
+			actions[n.ID] = ImportSkip
+		case ConflictOverwrite:
+			actions[n.ID] = ImportOverwrite
+		case ConflictNewID:
+			ids[n.ID] = uuid.NewString()
+		}
+	}
+
+	for _, n := range export.Nodes {
+		node := n.Node()
+		node.ID = ids[n.ID]
+		if parentID, ok := ids[node.ParentID]; ok {
+			node.ParentID = parentID
+		}
+
+		change := ImportChange{Kind: RecordNode, ID: n.ID, Action: actions[n.ID], Summary: node.Content}
+		if node.ID != n.ID {
+			change.NewID = node.ID
+		}
+
+		switch change.Action {
+		case ImportCreate:
+			plan.batch.Create = append(plan.batch.Create, node)
+		case ImportSkip:
+			change.Reason = "id already exists"
+		case ImportOverwrite:
+			if sameNode(existing[n.ID], node) {
+				change.Action = ImportUnchanged
+			} else {
+				plan.batch.Overwrite = append(plan.batch.Overwrite, node)
+			}
+		}
+
+		plan.changes = append(plan.changes, change)
+	}
+
+	for _, n := range export.Notifications {
+		notification := n.Notification()
+		change := ImportChange{Kind: RecordNotification, ID: n.ID, Action: ImportCreate, Summary: n.NotificationType}
+
+		nodeID, known, err := s.resolveNodeID(ctx, ids, n.NodeID)
+		if err != nil {
+			return plan, err
+		}
+		if !known {
+			change.Action, change.Reason = ImportSkip, "node "+n.NodeID+" not found"
+			plan.changes = append(plan.changes, change)
+			continue
+		}
+		notification.NodeID = nodeID
+
+		current, err := s.Repo.GetNotification(ctx, n.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return plan, fmt.Errorf("error looking up notification %s: %w", n.ID, err)
+		}
+
+		if err == nil {
+			switch conflict {
+			case ConflictSkip:
+				change.Action, change.Reason = ImportSkip, "id already exists"
+			case ConflictOverwrite:
+				change.Action = ImportOverwrite
+				if sameNotification(current, notification) {
+					change.Action = ImportUnchanged
+				} else {
+					plan.batch.Replaced = append(plan.batch.Replaced, n.ID)
+				}
+			case ConflictNewID:
+				notification.ID = uuid.NewString()
+				change.NewID = notification.ID
+			}
+		}
+
+		if change.Action == ImportCreate || change.Action == ImportOverwrite {
+			plan.batch.Notifications = append(plan.batch.Notifications, notification)
+		}
+		plan.changes = append(plan.changes, change)
+	}
+
+	for _, d := range export.Dependencies {
+		change := ImportChange{Kind: RecordDependency, ID: d.TaskID + " -> " + d.DependsOn, Action: ImportCreate}
+
+		taskID, taskKnown, err := s.resolveNodeID(ctx, ids, d.TaskID)
+		if err != nil {
+			return plan, err
+		}
+		dependsOn, depKnown, err := s.resolveNodeID(ctx, ids, d.DependsOn)
+		if err != nil {
+			return plan, err
+		}
+
+		if !taskKnown || !depKnown {
+			change.Action, change.Reason = ImportSkip, "node not found"
+			plan.changes = append(plan.changes, change)
+			continue
+		}
+
+		dep := model.Dependency{TaskID: taskID, DependsOn: dependsOn}
+		if taskID != d.TaskID || dependsOn != d.DependsOn {
+			change.NewID = dep.TaskID + " -> " + dep.DependsOn
+		}
+
+		exists, err := s.hasDependency(ctx, dep)
+		if err != nil {
+			return plan, err
+		}
+
+		if exists {
+			change.Action = ImportUnchanged
+		} else {
+			plan.batch.Dependencies = append(plan.batch.Dependencies, dep)
+		}
+		plan.changes = append(plan.changes, change)
+	}
+
+	// The file alone may be free of cycles and still close one with the dependencies in the database.
+	if len(plan.batch.Dependencies) > 0 {
+		deps, err := s.Repo.ListAllDependencies(ctx)
+		if err != nil {
+			return plan, fmt.Errorf("error listing dependencies: %w", err)
+		}
+
+		if id := dependencyCycle(append(deps, plan.batch.Dependencies...)); id != "" {
+			return plan, fmt.Errorf("circular dependency: the imported dependencies make task %s depend on itself", id)
+		}
+	}
+
+	return plan, nil
+}
+
+// findNode looks a node up by its full ID.
+func (s *Svc) findNode(ctx context.Context, id string) (model.Node, bool, error) {
+	node, err := s.Repo.Get(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Node{}, false, nil
+	}
+	if err != nil {
+		return model.Node{}, false, fmt.Errorf("error looking up node %s: %w", id, err)
+	}
+
+	return node, true, nil
+}
+
+// resolveNodeID returns the ID a reference points to after the import: the resolved ID of a node
+// in the file, or the ID itself if the node is already in the database.
+func (s *Svc) resolveNodeID(ctx context.Context, ids map[string]string, id string) (string, bool, error) {
+	if resolved, ok := ids[id]; ok {
+		return resolved, true, nil
+	}
+
+	_, found, err := s.findNode(ctx, id)
+	if err != nil {
+		return "", false, err
+	}
+
+	return id, found, nil
+}
+
+func (s *Svc) hasDependency(ctx context.Context, dep model.Dependency) (bool, error) {
+	deps, err := s.Repo.GetDependencies(ctx, dep.TaskID)
+	if err != nil {
+		return false, fmt.Errorf("error looking up dependencies of %s: %w", dep.TaskID, err)
+	}
+
+	for _, d := range deps {
+		if d.ID == dep.DependsOn {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// sameNode compares nodes as they are stored: times to the second, and no labels equal to empty labels.
+func sameNode(a, b model.Node) bool {
+	return reflect.DeepEqual(normalizeNode(a), normalizeNode(b))
+}
+
+func normalizeNode(n model.Node) model.Node {
+	n.Date = n.Date.UTC().Truncate(time.Second)
+	n.DueDate = normalizeTime(n.DueDate)
+	n.ArchivedAt = normalizeTime(n.ArchivedAt)
+	n.DeletedAt = normalizeTime(n.DeletedAt)
+	if len(n.Tags) == 0 {
+		n.Tags = nil
+	}
+	if len(n.Places) == 0 {
+		n.Places = nil
+	}
+	return n
+}
+
+// normalizeTime drops the location and the sub-second part, which a database read or an
+// export file does not keep.
+func normalizeTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC().Truncate(time.Second)
+	return &utc
+}
+
+func sameNotification(a, b model.Notification) bool {
+	return a.NodeID == b.NodeID &&
+		a.NotificationType == b.NotificationType &&
+		a.LastNotifiedAt.Equal(b.LastNotifiedAt) &&
+		a.TimesNotified == b.TimesNotified
+}
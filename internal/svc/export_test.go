@@ -0,0 +1,449 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// importRepo is an in-memory Repo covering the methods used by export and import.
+type importRepo struct {
+	Repo
+	nodes         map[string]model.Node
+	notifications map[string]model.Notification
+	deps          []model.Dependency
+}
+
+func newImportRepo(nodes ...model.Node) *importRepo {
+	r := &importRepo{nodes: map[string]model.Node{}, notifications: map[string]model.Notification{}}
+	for _, n := range nodes {
+		r.nodes[n.ID] = n
+	}
+	return r
+}
+
+func (r *importRepo) Get(ctx context.Context, id string) (model.Node, error) {
+	n, ok := r.nodes[id]
+	if !ok {
+		return model.Node{}, sql.ErrNoRows
+	}
+	return n, nil
+}
+
+func (r *importRepo) Create(ctx context.Context, node model.Node) error {
+	r.nodes[node.ID] = node
+	return nil
+}
+
+func (r *importRepo) Update(ctx context.Context, node model.Node) error {
+	r.nodes[node.ID] = node
+	return nil
+}
+
+func (r *importRepo) Import(ctx context.Context, batch model.ImportBatch) error {
+	for _, n := range append(batch.Create, batch.Overwrite...) {
+		r.nodes[n.ID] = n
+	}
+	for _, id := range batch.Replaced {
+		delete(r.notifications, id)
+	}
+	for _, n := range batch.Notifications {
+		r.notifications[n.ID] = n
+	}
+	r.deps = append(r.deps, batch.Dependencies...)
+	return nil
+}
+
+func (r *importRepo) ListAll(ctx context.Context) ([]model.Node, error) {
+	var nodes []model.Node
+	for _, n := range r.nodes {
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+func (r *importRepo) GetNotification(ctx context.Context, id string) (model.Notification, error) {
+	n, ok := r.notifications[id]
+	if !ok {
+		return model.Notification{}, sql.ErrNoRows
+	}
+	return n, nil
+}
+
+func (r *importRepo) CreateNotification(ctx context.Context, n model.Notification) error {
+	r.notifications[n.ID] = n
+	return nil
+}
+
+func (r *importRepo) DeleteNotification(ctx context.Context, id string) error {
+	delete(r.notifications, id)
+	return nil
+}
+
+func (r *importRepo) ListNotifications(ctx context.Context) ([]model.Notification, error) {
+	var notifications []model.Notification
+	for _, n := range r.notifications {
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+func (r *importRepo) AddDependency(ctx context.Context, taskID, dependsOn string) error {
+	r.deps = append(r.deps, model.Dependency{TaskID: taskID, DependsOn: dependsOn})
+	return nil
+}
+
+func (r *importRepo) GetDependencies(ctx context.Context, taskID string) ([]model.Node, error) {
+	var nodes []model.Node
+	for _, d := range r.deps {
+		if d.TaskID == taskID {
+			nodes = append(nodes, r.nodes[d.DependsOn])
+		}
+	}
+	return nodes, nil
+}
+
+func (r *importRepo) GetDependents(ctx context.Context, taskID string) ([]model.Node, error) {
+	var nodes []model.Node
+	for _, d := range r.deps {
+		if d.DependsOn == taskID {
+			nodes = append(nodes, r.nodes[d.TaskID])
+		}
+	}
+	return nodes, nil
+}
+
+func (r *importRepo) ListAllDependencies(ctx context.Context) ([]model.Dependency, error) {
+	return r.deps, nil
+}
+
+func sampleExport() model.Export {
+	date := time.Date(2025, 7, 1, 9, 30, 0, 0, time.UTC)
+	due := date.Add(48 * time.Hour)
+
+	return model.Export{
+		Version:    model.ExportVersion,
+		ExportedAt: date,
+		Nodes: []model.ExportNode{
+			{ID: "parent", Type: model.Type.Task, Content: "Plan trip", Tags: []string{"travel", "a,b"}, Places: []string{"home"},
+				Status: model.Status.Blocked, Date: date, DueDate: &due, Recurrence: "weekly", Priority: 2, PriorStatus: model.Status.Todo},
+			{ID: "child", Type: model.Type.Task, Content: "Book flights", Status: model.Status.Todo, Date: date, ParentID: "parent"},
+			{ID: "note", Type: model.Type.Link, Content: "Guide", Link: "https://example.com", Draft: "read later", Date: date},
+		},
+		Notifications: []model.ExportNotification{
+			{ID: "n1", NodeID: "parent", NotificationType: model.NotificationType.DueDate, LastNotifiedAt: date, TimesNotified: 3},
+		},
+		Dependencies: []model.Dependency{
+			{TaskID: "parent", DependsOn: "child"},
+		},
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	for _, format := range []string{ExportFormatJSON, ExportFormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			want := sampleExport()
+			archived := time.Date(2025, 7, 3, 8, 0, 0, 0, time.UTC)
+			deleted := time.Date(2025, 7, 4, 18, 15, 0, 0, time.UTC)
+			want.Nodes = append(want.Nodes,
+				model.ExportNode{ID: "archived", Type: model.Type.Note, Content: "Old plan", Date: want.ExportedAt, ArchivedAt: &archived},
+				model.ExportNode{ID: "trashed", Type: model.Type.Task, Content: "Dropped", Status: model.Status.Todo, Date: want.ExportedAt, DeletedAt: &deleted},
+			)
+
+			var buf bytes.Buffer
+			err := WriteExport(&buf, want, format)
+			if err != nil {
+				t.Fatalf("WriteExport() error = %v", err)
+			}
+
+			got, err := ReadExport(&buf)
+			if err != nil {
+				t.Fatalf("ReadExport() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadExport() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestExportImportRoundTrip imports an export of the same database back, with timestamps read
+// from the database in local time and with sub-second precision.
+func TestExportImportRoundTrip(t *testing.T) {
+	zone := time.FixedZone("CEST", 2*60*60)
+	date := time.Date(2025, 7, 1, 9, 30, 0, 0, zone)
+	archived := time.Date(2025, 7, 3, 8, 0, 0, 250000000, zone)
+	deleted := time.Date(2025, 7, 4, 18, 15, 0, 750000000, zone)
+
+	repo := newImportRepo(
+		model.Node{ID: "archived", Type: model.Type.Note, Content: "Old plan", Date: date, ArchivedAt: &archived},
+		model.Node{ID: "trashed", Type: model.Type.Task, Content: "Dropped", Status: model.Status.Todo, Date: date, DeletedAt: &deleted},
+	)
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	export, err := s.Export(ctx, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteExport(&buf, export, ExportFormatJSON)
+	if err != nil {
+		t.Fatalf("WriteExport() error = %v", err)
+	}
+
+	read, err := ReadExport(&buf)
+	if err != nil {
+		t.Fatalf("ReadExport() error = %v", err)
+	}
+
+	report, err := s.Import(ctx, read, ImportOptions{Conflict: ConflictOverwrite})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	for _, c := range report.Changes {
+		if c.Action != ImportUnchanged {
+			t.Errorf("%s %s action = %q, want %q", c.Kind, c.ID, c.Action, ImportUnchanged)
+		}
+	}
+}
+
+func TestReadExportRejectsNewerVersion(t *testing.T) {
+	_, err := ReadExport(bytes.NewBufferString(`{"kind":"header","version":99}`))
+	if err == nil {
+		t.Fatal("ReadExport() error = nil, want version error")
+	}
+}
+
+func TestExportFilters(t *testing.T) {
+	old := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := newImportRepo(
+		model.Node{ID: "t1", Type: model.Type.Task, Date: old},
+		model.Node{ID: "t2", Type: model.Type.Task, Date: recent},
+		model.Node{ID: "n1", Type: model.Type.Note, Date: recent},
+	)
+	repo.deps = []model.Dependency{{TaskID: "t2", DependsOn: "t1"}, {TaskID: "t2", DependsOn: "n1"}}
+	repo.notifications["x"] = model.Notification{ID: "x", NodeID: "t2"}
+	repo.notifications["y"] = model.Notification{ID: "y", NodeID: "n1"}
+
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		opts          ExportOptions
+		nodes         int
+		notifications int
+		dependencies  int
+	}{
+		{"all", ExportOptions{}, 3, 2, 2},
+		{"tasks", ExportOptions{Type: model.Type.Task}, 2, 1, 1},
+		{"since", ExportOptions{Since: &since}, 2, 2, 1},
+		{"recent tasks", ExportOptions{Type: model.Type.Task, Since: &since}, 1, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Svc{Repo: repo}
+			export, err := s.Export(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+
+			if len(export.Nodes) != tt.nodes || len(export.Notifications) != tt.notifications || len(export.Dependencies) != tt.dependencies {
+				t.Errorf("Export() = %d nodes, %d notifications, %d dependencies; want %d, %d, %d",
+					len(export.Nodes), len(export.Notifications), len(export.Dependencies),
+					tt.nodes, tt.notifications, tt.dependencies)
+			}
+		})
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	existing := model.Node{ID: "parent", Type: model.Type.Note, Content: "Old content"}
+
+	tests := []struct {
+		name        string
+		conflict    string
+		dryRun      bool
+		wantNodes   int
+		wantContent string
+		wantAction  string
+	}{
+		{"skip", ConflictSkip, false, 3, "Old content", ImportSkip},
+		{"overwrite", ConflictOverwrite, false, 3, "Plan trip", ImportOverwrite},
+		{"new-id", ConflictNewID, false, 4, "Old content", ImportCreate},
+		{"dry run", ConflictOverwrite, true, 1, "Old content", ImportOverwrite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newImportRepo(existing)
+			s := &Svc{Repo: repo}
+
+			report, err := s.Import(context.Background(), sampleExport(), ImportOptions{Conflict: tt.conflict, DryRun: tt.dryRun})
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+
+			if len(repo.nodes) != tt.wantNodes {
+				t.Errorf("nodes after import = %d, want %d", len(repo.nodes), tt.wantNodes)
+			}
+
+			if got := repo.nodes["parent"].Content; got != tt.wantContent {
+				t.Errorf("parent content = %q, want %q", got, tt.wantContent)
+			}
+
+			if got := report.Changes[0].Action; got != tt.wantAction {
+				t.Errorf("parent action = %q, want %q", got, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestImportNewIDRewritesReferences(t *testing.T) {
+	repo := newImportRepo(model.Node{ID: "parent", Type: model.Type.Note, Content: "Old content"})
+	s := &Svc{Repo: repo}
+
+	report, err := s.Import(context.Background(), sampleExport(), ImportOptions{Conflict: ConflictNewID})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	newID := report.Changes[0].NewID
+	if newID == "" || newID == "parent" {
+		t.Fatalf("parent NewID = %q, want a fresh ID", newID)
+	}
+
+	if got := repo.nodes["child"].ParentID; got != newID {
+		t.Errorf("child ParentID = %q, want %q", got, newID)
+	}
+
+	if got := repo.notifications["n1"].NodeID; got != newID {
+		t.Errorf("notification NodeID = %q, want %q", got, newID)
+	}
+
+	want := []model.Dependency{{TaskID: newID, DependsOn: "child"}}
+	if !reflect.DeepEqual(repo.deps, want) {
+		t.Errorf("dependencies = %v, want %v", repo.deps, want)
+	}
+}
+
+func TestImportTwiceIsUnchanged(t *testing.T) {
+	repo := newImportRepo()
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	_, err := s.Import(ctx, sampleExport(), ImportOptions{})
+	if err != nil {
+		t.Fatalf("first Import() error = %v", err)
+	}
+
+	report, err := s.Import(ctx, sampleExport(), ImportOptions{Conflict: ConflictOverwrite})
+	if err != nil {
+		t.Fatalf("second Import() error = %v", err)
+	}
+
+	for _, c := range report.Changes {
+		if c.Action != ImportUnchanged {
+			t.Errorf("%s %s action = %q, want %q", c.Kind, c.ID, c.Action, ImportUnchanged)
+		}
+	}
+}
+
+func TestImportRejectsInvalidRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		export model.Export
+	}{
+		{"missing id", model.Export{Nodes: []model.ExportNode{{Type: model.Type.Note}}}},
+		{"invalid type", model.Export{Nodes: []model.ExportNode{{ID: "a", Type: "memo"}}}},
+		{"invalid status", model.Export{Nodes: []model.ExportNode{{ID: "a", Type: model.Type.Task, Status: "later"}}}},
+		{"dangling notification", model.Export{Notifications: []model.ExportNotification{{ID: "n"}}}},
+		{"self dependency", model.Export{Dependencies: []model.Dependency{{TaskID: "a", DependsOn: "a"}}}},
+		{"circular dependency", model.Export{Dependencies: []model.Dependency{
+			{TaskID: "a", DependsOn: "b"}, {TaskID: "b", DependsOn: "c"}, {TaskID: "c", DependsOn: "a"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Svc{Repo: newImportRepo()}
+			_, err := s.Import(context.Background(), tt.export, ImportOptions{})
+			if err == nil {
+				t.Error("Import() error = nil, want validation error")
+			}
+		})
+	}
+}
+
+func TestImportRejectsCycleWithExistingDependencies(t *testing.T) {
+	repo := newImportRepo(
+		model.Node{ID: "a", Type: model.Type.Task, Status: model.Status.Todo},
+		model.Node{ID: "b", Type: model.Type.Task, Status: model.Status.Blocked, PriorStatus: model.Status.Todo},
+	)
+	repo.deps = []model.Dependency{{TaskID: "b", DependsOn: "a"}}
+	s := &Svc{Repo: repo}
+
+	export := model.Export{Dependencies: []model.Dependency{{TaskID: "a", DependsOn: "b"}}}
+	_, err := s.Import(context.Background(), export, ImportOptions{})
+	if err == nil {
+		t.Fatal("Import() error = nil, want a circular dependency error")
+	}
+
+	if len(repo.deps) != 1 {
+		t.Errorf("dependencies after a rejected import = %v, want only b -> a", repo.deps)
+	}
+}
+
+func TestImportBlocksTasksWithOpenDependencies(t *testing.T) {
+	date := time.Date(2025, 7, 1, 9, 30, 0, 0, time.UTC)
+	repo := newImportRepo(
+		model.Node{ID: "done", Type: model.Type.Task, Status: model.Status.Done, Date: date},
+		model.Node{ID: "waiting", Type: model.Type.Task, Status: model.Status.InProgress, Date: date},
+	)
+	repo.deps = []model.Dependency{{TaskID: "waiting", DependsOn: "done"}}
+	s := &Svc{Repo: repo}
+
+	export := model.Export{
+		Nodes: []model.ExportNode{
+			{ID: "open", Type: model.Type.Task, Content: "Open", Status: model.Status.Todo, Date: date},
+			{ID: "dependent", Type: model.Type.Task, Content: "Dependent", Status: model.Status.Todo, Date: date},
+			{ID: "stale", Type: model.Type.Task, Content: "Stale", Status: model.Status.Blocked, PriorStatus: model.Status.Todo, Date: date},
+			{ID: "done", Type: model.Type.Task, Content: "Reopened", Status: model.Status.Todo, Date: date},
+		},
+		Dependencies: []model.Dependency{
+			{TaskID: "dependent", DependsOn: "open"},
+		},
+	}
+
+	_, err := s.Import(context.Background(), export, ImportOptions{Conflict: ConflictOverwrite})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	tests := []struct {
+		id          string
+		wantStatus  string
+		wantPrior   string
+		description string
+	}{
+		{"dependent", model.Status.Blocked, model.Status.Todo, "imported with an open dependency"},
+		{"stale", model.Status.Todo, "", "imported blocked without dependencies"},
+		{"waiting", model.Status.Blocked, model.Status.InProgress, "depends on a node reopened by the import"},
+	}
+
+	for _, tt := range tests {
+		got := repo.nodes[tt.id]
+		if got.Status != tt.wantStatus || got.PriorStatus != tt.wantPrior {
+			t.Errorf("%s (%s) = %q prior %q, want %q prior %q", tt.id, tt.description, got.Status, got.PriorStatus, tt.wantStatus, tt.wantPrior)
+		}
+	}
+}
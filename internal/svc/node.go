@@ -0,0 +1,184 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// EditText replaces the content of a node of any type.
+func (s *Svc) EditText(ctx context.Context, id, text string) (model.Node, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return model.Node{}, model.Errorf(model.ErrInvalid, "the new text is empty")
+	}
+
+	node, err := s.ResolveID(ctx, id)
+	if err != nil {
+		return model.Node{}, err
+	}
+
+	node.Content = text
+
+	err = s.Repo.Update(ctx, node)
+	if err != nil {
+		return model.Node{}, fmt.Errorf("error updating node: %w", err)
+	}
+
+	return node, nil
+}
+
+// ChangeTags adds ("add"), removes ("remove") or clears ("clear") the tags of a node.
+func (s *Svc) ChangeTags(ctx context.Context, id, operation string, tags []string) (model.Node, error) {
+	return s.changeLabels(ctx, id, operation, tags, func(n *model.Node) *[]string { return &n.Tags })
+}
+
+// ChangePlaces adds ("add"), removes ("remove") or clears ("clear") the places of a node.
+func (s *Svc) ChangePlaces(ctx context.Context, id, operation string, places []string) (model.Node, error) {
+	return s.changeLabels(ctx, id, operation, places, func(n *model.Node) *[]string { return &n.Places })
+}
+
+// changeLabels applies operation to the labels field picks out of the node.
+func (s *Svc) changeLabels(ctx context.Context, id, operation string, labels []string, field func(*model.Node) *[]string) (model.Node, error) {
+	node, err := s.ResolveID(ctx, id)
+	if err != nil {
+		return model.Node{}, err
+	}
+
+	current := field(&node)
+
+	switch operation {
+	case "add":
+		for _, label := range labels {
+			if !contains(*current, label) {
+				*current = append(*current, label)
+			}
+		}
+	case "remove":
+		kept := []string{}
+		for _, label := range *current {
+			if !contains(labels, label) {
+				kept = append(kept, label)
+			}
+		}
+		*current = kept
+	case "clear":
+		*current = []string{}
+	default:
+		return model.Node{}, model.Errorf(model.ErrInvalid, "invalid operation: %s", operation)
+	}
+
+	err = s.Repo.Update(ctx, node)
+	if err != nil {
+		return model.Node{}, fmt.Errorf("error updating node: %w", err)
+	}
+
+	return node, nil
+}
+
+// TypeChange describes the outcome of converting a node to another type.
+type TypeChange struct {
+	Node         model.Node
+	OriginalType string
+	NewType      string
+}
+
+// ConvertNode changes the type of a node, for example promoting a note into a task. A new task
+// starts as todo. Other types drop the task status and recurrence, so a task with dependencies
+// must lose them first. A link needs a URL, taken from the content if the node has none, and a
+// draft needs a name, which draft gives unless the node already has one.
+func (s *Svc) ConvertNode(ctx context.Context, id, nodeType, draft string) (TypeChange, error) {
+	if !model.Type.Validate(nodeType) {
+		return TypeChange{}, model.Errorf(model.ErrInvalid, "invalid type '%s', valid types are: %s", nodeType, strings.Join(model.Type.Values(), ", "))
+	}
+
+	node, err := s.ResolveID(ctx, id)
+	if err != nil {
+		return TypeChange{}, err
+	}
+
+	change := TypeChange{OriginalType: node.Type, NewType: nodeType}
+	if node.Type == nodeType && (nodeType != model.Type.Draft || draft == "" || draft == node.Draft) {
+		change.Node = node
+		return change, nil
+	}
+
+	if node.Type == model.Type.Task && nodeType != model.Type.Task {
+		err = s.checkNoDependencies(ctx, node)
+		if err != nil {
+			return TypeChange{}, err
+		}
+	}
+
+	switch nodeType {
+	case model.Type.Task:
+		if node.Status == "" {
+			node.Status = model.Status.Todo
+		}
+	default:
+		node.Status = ""
+		node.PriorStatus = ""
+		node.Recurrence = ""
+	}
+
+	switch nodeType {
+	case model.Type.Link:
+		if node.Link == "" {
+			url := urlPattern.FindString(node.Content)
+			if url == "" {
+				return TypeChange{}, model.Errorf(model.ErrInvalid, "a link needs a URL and '%s' has none", node.Content)
+			}
+			node.Link = url
+		}
+	case model.Type.Draft:
+		if draft != "" {
+			node.Draft = draft
+		}
+		if node.Draft == "" {
+			return TypeChange{}, model.Errorf(model.ErrInvalid, "a draft needs a name")
+		}
+	}
+
+	if nodeType != model.Type.Draft {
+		node.Draft = ""
+	}
+
+	node.Type = nodeType
+
+	err = s.Repo.Update(ctx, node)
+	if err != nil {
+		return TypeChange{}, fmt.Errorf("error updating node: %w", err)
+	}
+
+	change.Node = node
+	return change, nil
+}
+
+func (s *Svc) checkNoDependencies(ctx context.Context, task model.Node) error {
+	deps, err := s.Repo.GetDependencies(ctx, task.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving dependencies: %w", err)
+	}
+
+	dependents, err := s.Repo.GetDependents(ctx, task.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving dependents: %w", err)
+	}
+
+	if len(deps) > 0 || len(dependents) > 0 {
+		return model.Errorf(model.ErrInvalid, "task '%s' has dependencies, remove them before converting it", s.NodeShortIDs(ctx, task)[task.ID])
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+func TestChangeLabels(t *testing.T) {
+	note := model.Node{ID: "n", Type: model.Type.Note, Tags: []string{"a", "b"}}
+	s := &Svc{Repo: newDepRepo(note)}
+	ctx := context.Background()
+
+	tests := []struct {
+		operation string
+		tags      []string
+		want      []string
+	}{
+		{"add", []string{"b", "c"}, []string{"a", "b", "c"}},
+		{"remove", []string{"a", "x"}, []string{"b", "c"}},
+		{"clear", nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		node, err := s.ChangeTags(ctx, "n", tt.operation, tt.tags)
+		if err != nil {
+			t.Fatalf("ChangeTags(%s) error = %v", tt.operation, err)
+		}
+		if !sliceEqual(node.Tags, tt.want) {
+			t.Errorf("ChangeTags(%s) tags = %v, want %v", tt.operation, node.Tags, tt.want)
+		}
+	}
+
+	if _, err := s.ChangePlaces(ctx, "n", "replace", []string{"x"}); !errors.Is(err, model.ErrInvalid) {
+		t.Errorf("ChangePlaces(replace) error = %v, want a validation error", err)
+	}
+}
+
+func TestConvertNode(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    model.Node
+		to      string
+		draft   string
+		want    model.Node
+		wantErr bool
+	}{
+		{
+			name: "note to task",
+			node: model.Node{ID: "n", Type: model.Type.Note, Content: "call mom"},
+			to:   model.Type.Task,
+			want: model.Node{ID: "n", Type: model.Type.Task, Content: "call mom", Status: model.Status.Todo},
+		},
+		{
+			name: "task to note",
+			node: model.Node{ID: "n", Type: model.Type.Task, Content: "water plants", Status: model.Status.InProgress, Recurrence: "weekly"},
+			to:   model.Type.Note,
+			want: model.Node{ID: "n", Type: model.Type.Note, Content: "water plants"},
+		},
+		{
+			name: "note to link takes the URL from the content",
+			node: model.Node{ID: "n", Type: model.Type.Note, Content: "read https://go.dev/blog later"},
+			to:   model.Type.Link,
+			want: model.Node{ID: "n", Type: model.Type.Link, Content: "read https://go.dev/blog later", Link: "https://go.dev/blog"},
+		},
+		{
+			name:    "link without a URL",
+			node:    model.Node{ID: "n", Type: model.Type.Note, Content: "no url here"},
+			to:      model.Type.Link,
+			wantErr: true,
+		},
+		{
+			name:  "note to named draft",
+			node:  model.Node{ID: "n", Type: model.Type.Note, Content: "intro"},
+			to:    model.Type.Draft,
+			draft: "essay",
+			want:  model.Node{ID: "n", Type: model.Type.Draft, Content: "intro", Draft: "essay"},
+		},
+		{
+			name:    "draft without a name",
+			node:    model.Node{ID: "n", Type: model.Type.Note, Content: "intro"},
+			to:      model.Type.Draft,
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			node:    model.Node{ID: "n", Type: model.Type.Note},
+			to:      "poem",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Svc{Repo: newDepRepo(tt.node)}
+
+			change, err := s.ConvertNode(context.Background(), "n", tt.to, tt.draft)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrInvalid) {
+					t.Fatalf("ConvertNode() error = %v, want a validation error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertNode() error = %v", err)
+			}
+
+			got := change.Node
+			if got.Type != tt.want.Type || got.Status != tt.want.Status || got.Link != tt.want.Link ||
+				got.Draft != tt.want.Draft || got.Recurrence != tt.want.Recurrence {
+				t.Errorf("ConvertNode() = %+v, want %+v", got, tt.want)
+			}
+			if change.OriginalType != tt.node.Type {
+				t.Errorf("OriginalType = %s, want %s", change.OriginalType, tt.node.Type)
+			}
+		})
+	}
+}
+
+func TestConvertTaskWithDependencies(t *testing.T) {
+	repo := newDepRepo(task("a", model.Status.Todo), task("b", model.Status.Todo))
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	if _, err := s.AddDependency(ctx, "a", "b"); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := s.ConvertNode(ctx, id, model.Type.Note, ""); !errors.Is(err, model.ErrInvalid) {
+			t.Errorf("ConvertNode(%s) error = %v, want a validation error", id, err)
+		}
+	}
+}
@@ -14,19 +14,87 @@ var (
 	tagPattern    = regexp.MustCompile(`#(\w+)`)
 	placePattern  = regexp.MustCompile(`@(\w+)`)
 	statusPattern = regexp.MustCompile(`:([a-zA-Z0-9-]+)`)
-	datePattern   = regexp.MustCompile(`\^([\d\-T:_]+)`)
+	datePattern   = regexp.MustCompile(`(?i)\^(\d[\d\-T:_]*|(?:` + relativeDates + `)(?:-\d{1,2}:\d{2})?\b)`)
 	urlPattern    = regexp.MustCompile(`https?://[^\s]+`)
 	draftPattern  = regexp.MustCompile(`\+([a-zA-Z0-9-_]+)`)
+	priorPattern  = regexp.MustCompile(`(?i)(?:^|\s)!([0-4]|urgent|high|medium|med|low)\b`)
+	parentPattern = regexp.MustCompile(`(?:^|\s)<([0-9a-fA-F][0-9a-fA-F-]{3,35})\b`)
+	recurPattern  = regexp.MustCompile(`(?i)\*((?:daily|weekly|monthly|yearly)(?::\w+)?|every-\d+[dwmy])\b`)
 )
 
+// relativeDates lists the relative expressions accepted after ^, see ParseDate.
+const relativeDates = `today|tod|tomorrow|tmr|yesterday|next-week|next-month|next-year|eo[wmy]|` +
+	`mon(?:day)?|tue(?:sday)?|wed(?:nesday)?|thu(?:rsday)?|fri(?:day)?|sat(?:urday)?|sun(?:day)?|\+\d+[dwmy]`
+
+// Parse turns captured text into a node. Sigils anywhere in the text set its fields and are
+// removed from the content, which keeps its line breaks.
 func Parse(input string) (model.Node, error) {
 	log.Printf("Parsing input: %s", input)
 
+	node, err := parseSigils(input)
+	if err != nil {
+		return model.Node{}, err
+	}
+
+	if node.Recurrence != "" && node.Status == "" {
+		node.Status = model.Status.Todo
+	}
+
+	// Set the default type if not already set (draft type will be preserved)
+	if node.Type == "" {
+		if node.Status != "" {
+			node.Type = model.Type.Task
+		} else if node.Link != "" {
+			node.Type = model.Type.Link
+		} else {
+			node.Type = model.Type.Note
+		}
+	}
+
+	return node, nil
+}
+
+// parseSigils reads the sigils in input into a node without the defaults Parse fills in, so
+// callers can tell a status that was given from one that was implied.
+func parseSigils(input string) (model.Node, error) {
+	original := input
+
 	node := model.Node{
 		Date: time.Now(),
 	}
 	node.GenID()
 
+	// Process due date first, so ^+3d is not taken for a draft and ^tomorrow-17:00 not for a status
+	dateMatch := datePattern.FindStringSubmatch(input)
+	if len(dateMatch) > 1 {
+		dateStr := strings.TrimSpace(dateMatch[1])
+		log.Printf("Captured date string: %s", dateStr)
+
+		dueDate, err := ParseDate(dateStr)
+		if err != nil {
+			log.Printf("Failed to parse date: %s", dateStr)
+			return model.Node{}, fmt.Errorf("invalid due date format: %w", err)
+		}
+
+		log.Printf("Parsed due date (in local timezone): %v", dueDate)
+		node.DueDate = &dueDate
+		log.Printf("Set node.DueDate = %v", *node.DueDate)
+	}
+	input = datePattern.ReplaceAllString(input, "")
+
+	// Process recurrence - a recurring node is always a task
+	recurMatch := recurPattern.FindStringSubmatch(input)
+	if len(recurMatch) > 1 {
+		rule := strings.ToLower(recurMatch[1])
+		_, err := model.ParseRecurrence(rule)
+		if err != nil {
+			return model.Node{}, err
+		}
+		node.Recurrence = rule
+		node.Type = model.Type.Task
+	}
+	input = recurPattern.ReplaceAllString(input, "")
+
 	draftMatch := draftPattern.FindStringSubmatch(input)
 	if len(draftMatch) > 1 {
 		draft := draftMatch[1]
@@ -63,6 +131,20 @@ func Parse(input string) (model.Node, error) {
 	}
 	input = urlPattern.ReplaceAllString(input, "")
 
+	// Process parent reference - resolved to a full ID when the node is captured
+	parentMatch := parentPattern.FindStringSubmatch(input)
+	if len(parentMatch) > 1 {
+		node.ParentID = strings.ToLower(parentMatch[1])
+	}
+	input = parentPattern.ReplaceAllStringFunc(input, leadingSpace)
+
+	// Process priority
+	priorMatch := priorPattern.FindStringSubmatch(input)
+	if len(priorMatch) > 1 {
+		node.Priority, _ = model.Priority.Parse(priorMatch[1])
+	}
+	input = priorPattern.ReplaceAllStringFunc(input, leadingSpace)
+
 	// Process status - for drafts, we can still have a status but won't change the type
 	statusMatch := statusPattern.FindStringSubmatch(input)
 	if len(statusMatch) > 1 {
@@ -73,55 +155,38 @@ func Parse(input string) (model.Node, error) {
 	}
 	input = statusPattern.ReplaceAllString(input, "")
 
-	// Process due date
-	dateMatch := datePattern.FindStringSubmatch(input)
-	if len(dateMatch) > 1 {
-		dateStr := strings.TrimSpace(dateMatch[1])
-		log.Printf("Captured date string: %s", dateStr)
-
-		var dueDate time.Time
-		var err error
-
-		formats := []string{
-			"2006-01-02-15-04-05",
-			"2006-01-02T15:04:05",
-			"2006-01-02_15:04:05",
-			"2006-01-02 15:04:05",
-			"2006-01-02",
-		}
+	node.Content = tidyContent(original, input)
 
-		parsed := false
-		for _, format := range formats {
-			dueDate, err = time.ParseInLocation(format, dateStr, time.Local)
-			if err == nil {
-				parsed = true
-				break
-			}
-		}
-
-		if !parsed {
-			log.Printf("Failed to parse date with any format: %s", dateStr)
-			return model.Node{}, fmt.Errorf("invalid due date format: unable to parse %s", dateStr)
-		}
+	return node, nil
+}
 
-		log.Printf("Parsed due date (in local timezone): %v", dueDate)
-		node.DueDate = &dueDate
-		log.Printf("Set node.DueDate = %v", *node.DueDate)
+// leadingSpace keeps the space or line break a sigil match starts with, so removing a sigil at
+// the start of a line does not join it to the line before.
+func leadingSpace(match string) string {
+	if match != "" && (match[0] == '\n' || match[0] == '\r') {
+		return match[:1]
 	}
-	input = datePattern.ReplaceAllString(input, "")
+	return " "
+}
 
-	node.Content = strings.TrimSpace(input)
+// tidyContent trims the spaces removed sigils leave in the lines of content, keeping the
+// indentation the lines had in original, and the blank lines around the content. Removing a
+// sigil never removes a line break, so the lines of both match.
+func tidyContent(original, content string) string {
+	before := strings.Split(original, "\n")
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		var indent string
+		if i < len(before) {
+			indent = before[i][:len(before[i])-len(strings.TrimLeft(before[i], " \t"))]
+		}
 
-	// Set the default type if not already set (draft type will be preserved)
-	if node.Type == "" {
-		if node.Status != "" {
-			node.Type = model.Type.Task
-		} else if node.Link != "" {
-			node.Type = model.Type.Link
-		} else {
-			node.Type = model.Type.Note
+		line = strings.TrimSpace(line)
+		if line != "" {
+			line = indent + line
 		}
+		lines[i] = line
 	}
 
-	return node, nil
+	return strings.TrimSpace(strings.Join(lines, "\n"))
 }
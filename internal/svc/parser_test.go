@@ -1,6 +1,7 @@
 package svc
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -85,3 +86,94 @@ func TestParseDueDate(t *testing.T) {
 		t.Errorf("Parse() Content = %v, want %v", node.Content, "A task with due date")
 	}
 }
+
+func TestParseRecurrence(t *testing.T) {
+	node, err := Parse("Write release notes *weekly:fri #release")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if node.Recurrence != "weekly:fri" {
+		t.Errorf("Parse() Recurrence = %v, want %v", node.Recurrence, "weekly:fri")
+	}
+
+	if node.Type != model.Type.Task || node.Status != model.Status.Todo {
+		t.Errorf("Parse() Type = %v, Status = %v, want a todo task", node.Type, node.Status)
+	}
+
+	if node.Content != "Write release notes" {
+		t.Errorf("Parse() Content = %v, want %v", node.Content, "Write release notes")
+	}
+
+	_, err = Parse("Water plants *weekly:someday")
+	if err == nil {
+		t.Error("Parse() expected error for invalid recurrence weekday")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantPriority int
+		wantContent  string
+	}{
+		{
+			name:         "numeric priority",
+			input:        "Fix login !1 :todo",
+			wantPriority: model.Priority.Urgent,
+			wantContent:  "Fix login",
+		},
+		{
+			name:         "named priority",
+			input:        "!high Review budget :todo",
+			wantPriority: model.Priority.High,
+			wantContent:  "Review budget",
+		},
+		{
+			name:         "exclamation inside a word",
+			input:        "Great news!1 more",
+			wantPriority: model.Priority.None,
+			wantContent:  "Great news!1 more",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if node.Priority != tt.wantPriority {
+				t.Errorf("Parse() Priority = %v, want %v", node.Priority, tt.wantPriority)
+			}
+			if strings.Join(strings.Fields(node.Content), " ") != tt.wantContent {
+				t.Errorf("Parse() Content = %q, want %q", node.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestParseParent(t *testing.T) {
+	node, err := Parse("Book venue <e0e9a1b2 :todo")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if node.ParentID != "e0e9a1b2" {
+		t.Errorf("Parse() ParentID = %v, want %v", node.ParentID, "e0e9a1b2")
+	}
+
+	if strings.Join(strings.Fields(node.Content), " ") != "Book venue" {
+		t.Errorf("Parse() Content = %q, want %q", node.Content, "Book venue")
+	}
+
+	node, err = Parse("if a <b then swap")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if node.ParentID != "" {
+		t.Errorf("Parse() ParentID = %v, want none", node.ParentID)
+	}
+}
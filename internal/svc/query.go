@@ -0,0 +1,348 @@
+package svc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+var fieldPattern = regexp.MustCompile(`^(?i)([a-z]+)(<=|>=|<|>|=|:)(.*)$`)
+
+// ParseQuery parses a list query such as
+//
+//	type:task and (#work or #ops) and not :done and due<+7d sort:due
+//
+// Terms are #tag, @place, :status, !priority, field:value for the type, tag, place, status and
+// priority fields, due and created compared with <, <=, >, >=, = or : to a date ParseDate
+// accepts, due:none and due:any, and words or "quoted text" the content must contain. Terms
+// next to each other must all hold; and, or, not and parentheses combine them, not binding
+// tighter than and, and and than or. sort:field[,field] orders the results by due, created,
+// priority, status, type or text, descending with a leading -.
+//
+// Dates without a time of day cover the whole day: due<=fri includes Friday, and due:today
+// matches any time today.
+func ParseQuery(input string) (model.Query, error) {
+	return parseQueryAt(input, time.Now())
+}
+
+func parseQueryAt(input string, now time.Time) (model.Query, error) {
+	tokens, err := splitQuery(input)
+	if err != nil {
+		return model.Query{}, err
+	}
+
+	p := &queryParser{now: now}
+	for _, tok := range tokens {
+		if !tok.quoted && strings.HasPrefix(strings.ToLower(tok.text), "sort:") {
+			err = p.parseSort(tok.text[len("sort:"):])
+			if err != nil {
+				return model.Query{}, err
+			}
+			continue
+		}
+		p.tokens = append(p.tokens, tok)
+	}
+
+	var query model.Query
+	query.Sort = p.sort
+
+	if len(p.tokens) == 0 {
+		return query, nil
+	}
+
+	query.Where, err = p.parseOr()
+	if err != nil {
+		return model.Query{}, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return model.Query{}, model.Errorf(model.ErrInvalid, "unexpected '%s' in query", p.tokens[p.pos].text)
+	}
+
+	return query, nil
+}
+
+type queryToken struct {
+	text   string
+	quoted bool
+}
+
+// splitQuery splits input at spaces and parentheses, keeping "quoted text" whole.
+func splitQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, queryToken{text: string(r)})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, model.Errorf(model.ErrInvalid, "unterminated quote in query")
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i+1 : end]), quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()"`, runes[end]) {
+				end++
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i:end])})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	sort   []model.SortKey
+	now    time.Time
+}
+
+// keyword reports whether the next token is the operator or parenthesis word.
+func (p *queryParser) keyword(word string) bool {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return false
+	}
+	return strings.EqualFold(p.tokens[p.pos].text, word)
+}
+
+// end reports whether the tokens of the current group are used up.
+func (p *queryParser) end() bool {
+	return p.pos >= len(p.tokens) || p.keyword(")") || p.keyword(model.CondOr)
+}
+
+func (p *queryParser) parseOr() (*model.Cond, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []*model.Cond{left}
+	for p.keyword(model.CondOr) {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, right)
+	}
+
+	if len(args) == 1 {
+		return left, nil
+	}
+	return &model.Cond{Op: model.CondOr, Args: args}, nil
+}
+
+func (p *queryParser) parseAnd() (*model.Cond, error) {
+	var args []*model.Cond
+	for !p.end() {
+		if p.keyword(model.CondAnd) {
+			p.pos++
+			if len(args) == 0 || p.end() || p.keyword(model.CondAnd) {
+				return nil, model.Errorf(model.ErrInvalid, "'and' needs a term on each side")
+			}
+		}
+
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, cond)
+	}
+
+	if len(args) == 0 {
+		return nil, model.Errorf(model.ErrInvalid, "missing term in query")
+	}
+	return model.All(args...), nil
+}
+
+func (p *queryParser) parseNot() (*model.Cond, error) {
+	if p.keyword(model.CondNot) {
+		p.pos++
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &model.Cond{Op: model.CondNot, Args: []*model.Cond{cond}}, nil
+	}
+
+	if p.pos >= len(p.tokens) || p.keyword(model.CondOr) || p.keyword(model.CondAnd) {
+		return nil, model.Errorf(model.ErrInvalid, "missing term in query")
+	}
+
+	if p.keyword("(") {
+		p.pos++
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, model.Errorf(model.ErrInvalid, "missing ) in query")
+		}
+		p.pos++
+		return cond, nil
+	}
+
+	if p.keyword(")") {
+		return nil, model.Errorf(model.ErrInvalid, "unexpected ) in query")
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	if tok.quoted {
+		return textCond(tok.text), nil
+	}
+	return p.parseTerm(tok.text)
+}
+
+// parseTerm reads a single condition.
+func (p *queryParser) parseTerm(term string) (*model.Cond, error) {
+	if len(term) > 1 {
+		switch term[0] {
+		case '#':
+			return labelCond(model.FieldTag, term[1:]), nil
+		case '@':
+			return labelCond(model.FieldPlace, term[1:]), nil
+		case ':':
+			return statusCond(term[1:])
+		case '!':
+			return priorityCond(term[1:])
+		}
+	}
+
+	m := fieldPattern.FindStringSubmatch(term)
+	if m == nil {
+		return textCond(term), nil
+	}
+
+	field, op, value := strings.ToLower(m[1]), m[2], m[3]
+	if op == ":" {
+		op = model.CondEq
+	}
+	if value == "" {
+		return nil, model.Errorf(model.ErrInvalid, "'%s' needs a value", term)
+	}
+
+	if field == model.FieldDue || field == model.FieldCreated {
+		return p.dateCond(field, op, value)
+	}
+
+	if op != model.CondEq {
+		return nil, model.Errorf(model.ErrInvalid, "%s can only be matched with : in '%s'", field, term)
+	}
+
+	switch field {
+	case model.FieldType:
+		if !model.Type.Validate(value) {
+			return nil, model.Errorf(model.ErrInvalid, "invalid type '%s', valid types are: %s", value, strings.Join(model.Type.Values(), ", "))
+		}
+		return &model.Cond{Op: model.CondEq, Field: model.FieldType, Value: value}, nil
+	case model.FieldTag, model.FieldPlace:
+		return labelCond(field, value), nil
+	case model.FieldStatus:
+		return statusCond(value)
+	case model.FieldPriority:
+		return priorityCond(value)
+	case model.FieldText:
+		return textCond(value), nil
+	default:
+		return nil, model.Errorf(model.ErrInvalid, "unknown field '%s' in '%s', quote the term to match it as text", field, term)
+	}
+}
+
+// dateCond compares a due or creation date with value. A date without a time of day stands for
+// the whole day.
+func (p *queryParser) dateCond(field, op, value string) (*model.Cond, error) {
+	if field == model.FieldDue && op == model.CondEq {
+		switch strings.ToLower(value) {
+		case "none":
+			return &model.Cond{Op: model.CondEq, Field: field}, nil
+		case "any":
+			return &model.Cond{Op: model.CondNot, Args: []*model.Cond{{Op: model.CondEq, Field: field}}}, nil
+		}
+	}
+
+	date, err := parseDateAt(value, p.now)
+	if err != nil {
+		return nil, err
+	}
+
+	at := func(op string, t time.Time) *model.Cond {
+		return &model.Cond{Op: op, Field: field, Value: t.UTC().Format(model.DateTimeFormat)}
+	}
+
+	wholeDay := date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0
+	if !wholeDay {
+		return at(op, date), nil
+	}
+
+	next := date.AddDate(0, 0, 1)
+	switch op {
+	case model.CondEq:
+		return model.All(at(model.CondGe, date), at(model.CondLt, next)), nil
+	case model.CondLe:
+		return at(model.CondLt, next), nil
+	case model.CondGt:
+		return at(model.CondGe, next), nil
+	default:
+		return at(op, date), nil
+	}
+}
+
+func (p *queryParser) parseSort(spec string) error {
+	if spec == "" {
+		return model.Errorf(model.ErrInvalid, "sort needs a field")
+	}
+
+	for _, field := range strings.Split(strings.ToLower(spec), ",") {
+		key := model.SortKey{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+
+		switch key.Field {
+		case model.FieldDue, model.FieldCreated, model.FieldPriority, model.FieldStatus, model.FieldType, model.FieldText:
+			p.sort = append(p.sort, key)
+		default:
+			return model.Errorf(model.ErrInvalid, "cannot sort by '%s', sort by due, created, priority, status, type or text", key.Field)
+		}
+	}
+
+	return nil
+}
+
+func labelCond(field, name string) *model.Cond {
+	return &model.Cond{Op: model.CondEq, Field: field, Value: name}
+}
+
+func statusCond(status string) (*model.Cond, error) {
+	if !model.ValidStatus(status) {
+		return nil, model.Errorf(model.ErrInvalid, "invalid status in query: %s", status)
+	}
+	return &model.Cond{Op: model.CondEq, Field: model.FieldStatus, Value: status}, nil
+}
+
+func priorityCond(priority string) (*model.Cond, error) {
+	n, ok := model.Priority.Parse(priority)
+	if !ok {
+		return nil, model.Errorf(model.ErrInvalid, "invalid priority in query: %s", priority)
+	}
+	return &model.Cond{Op: model.CondEq, Field: model.FieldPriority, Value: strconv.Itoa(n)}, nil
+}
+
+func textCond(text string) *model.Cond {
+	return &model.Cond{Op: model.CondEq, Field: model.FieldText, Value: text}
+}
@@ -0,0 +1,105 @@
+package svc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// condString renders a condition compactly, as in and(tag=work, not(status=done)).
+func condString(c *model.Cond) string {
+	if c == nil {
+		return ""
+	}
+
+	switch c.Op {
+	case model.CondAnd, model.CondOr, model.CondNot:
+		args := make([]string, len(c.Args))
+		for i, a := range c.Args {
+			args[i] = condString(a)
+		}
+		return c.Op + "(" + strings.Join(args, ", ") + ")"
+	default:
+		return c.Field + c.Op + c.Value
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 7, 2, 10, 30, 0, 0, time.Local)
+	day := func(month time.Month, d int) string {
+		return time.Date(2025, month, d, 0, 0, 0, 0, time.Local).UTC().Format(model.DateTimeFormat)
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		wantWhere string
+		wantSort  []model.SortKey
+		wantErr   bool
+	}{
+		{name: "empty", input: "  "},
+		{name: "sigils", input: "#work @office :wip !high", wantWhere: "and(tag=work, place=office, status=wip, priority=2)"},
+		{name: "fields", input: "type:task tag:work Status:done", wantWhere: "and(type=task, tag=work, status=done)"},
+		{name: "and binds tighter than or", input: "#a #b or #c and #d", wantWhere: "or(and(tag=a, tag=b), and(tag=c, tag=d))"},
+		{name: "parentheses", input: "(#work or #ops) and not :done", wantWhere: "and(or(tag=work, tag=ops), not(status=done))"},
+		{name: "nested not", input: "not not #a", wantWhere: "not(not(tag=a))"},
+		{name: "text", input: `"fix login" OR report`, wantWhere: "or(text=fix login, text=report)"},
+		{name: "quoted keyword is text", input: `"or"`, wantWhere: "text=or"},
+		{name: "due before a day includes it", input: "due<=2025-07-04", wantWhere: "due<" + day(7, 5)},
+		{name: "due on a day", input: "due:today", wantWhere: "and(due>=" + day(7, 2) + ", due<" + day(7, 3) + ")"},
+		{name: "due after a relative day", input: "due>+7d", wantWhere: "due>=" + day(7, 10)},
+		{name: "created since", input: "created>=2025-07-01", wantWhere: "created>=" + day(7, 1)},
+		{name: "due none and any", input: "due:none or due:any", wantWhere: "or(due=, not(due=))"},
+		{
+			name:      "sort",
+			input:     "#work sort:due,-priority",
+			wantWhere: "tag=work",
+			wantSort:  []model.SortKey{{Field: model.FieldDue}, {Field: model.FieldPriority, Desc: true}},
+		},
+		{name: "sort only", input: "sort:-created", wantSort: []model.SortKey{{Field: model.FieldCreated, Desc: true}}},
+		{name: "unknown field", input: "owner:me", wantErr: true},
+		{name: "unknown sort field", input: "sort:owner", wantErr: true},
+		{name: "invalid status", input: ":someday", wantErr: true},
+		{name: "invalid type", input: "type:event", wantErr: true},
+		{name: "invalid date", input: "due<2025-13-45", wantErr: true},
+		{name: "compared label", input: "tag>work", wantErr: true},
+		{name: "missing value", input: "tag:", wantErr: true},
+		{name: "dangling and", input: "#a and", wantErr: true},
+		{name: "dangling or", input: "#a or", wantErr: true},
+		{name: "dangling not", input: "#a not", wantErr: true},
+		{name: "missing )", input: "(#a or #b", wantErr: true},
+		{name: "unexpected )", input: "#a)", wantErr: true},
+		{name: "unterminated quote", input: `"fix login`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQueryAt(tt.input, now)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrInvalid) {
+					t.Errorf("parseQueryAt(%q) error = %v, want a validation error", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseQueryAt(%q) error = %v", tt.input, err)
+			}
+
+			if where := condString(got.Where); where != tt.wantWhere {
+				t.Errorf("parseQueryAt(%q) where = %s, want %s", tt.input, where, tt.wantWhere)
+			}
+			if len(got.Sort) != len(tt.wantSort) {
+				t.Fatalf("parseQueryAt(%q) sort = %v, want %v", tt.input, got.Sort, tt.wantSort)
+			}
+			for i := range got.Sort {
+				if got.Sort[i] != tt.wantSort[i] {
+					t.Errorf("parseQueryAt(%q) sort = %v, want %v", tt.input, got.Sort, tt.wantSort)
+				}
+			}
+		})
+	}
+}
@@ -9,15 +9,27 @@ import (
 
 type Repo interface {
 	Create(ctx context.Context, node model.Node) error
+	CreateAll(ctx context.Context, nodes []model.Node) error
+	Import(ctx context.Context, batch model.ImportBatch) error
 	Get(ctx context.Context, id string) (model.Node, error)
 	Update(ctx context.Context, node model.Node) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context) ([]model.Node, error)
+	ListAll(ctx context.Context) ([]model.Node, error)
+	ListFiltered(ctx context.Context, filter model.Filter) ([]model.Node, error)
+	Search(ctx context.Context, match string, filter model.Filter, limit int) ([]model.SearchResult, error)
 	GetNodesByDay(day time.Time) ([]model.Node, error)
 	GetAllTasks(ctx context.Context) ([]model.Node, error)
 	GetOverdueTasks(ctx context.Context, notificationType string) ([]model.Node, error)
-	GetTaskByID(ctx context.Context, id string) (model.Node, error)
-	UpdateTask(ctx context.Context, node model.Node) error
+	ListByIDPrefix(ctx context.Context, prefix string) ([]model.Node, error)
+	ListIDs(ctx context.Context) ([]string, error)
+	ListTrash(ctx context.Context) ([]model.Node, error)
+	GetChildren(ctx context.Context, parentID string) ([]model.Node, error)
+	AddDependency(ctx context.Context, taskID, dependsOn string) error
+	RemoveDependency(ctx context.Context, taskID, dependsOn string) error
+	GetDependencies(ctx context.Context, taskID string) ([]model.Node, error)
+	GetDependents(ctx context.Context, taskID string) ([]model.Node, error)
+	ListAllDependencies(ctx context.Context) ([]model.Dependency, error)
 	CreateNotification(ctx context.Context, notification model.Notification) error
 	GetNotification(ctx context.Context, id string) (model.Notification, error)
 	GetNotificationByNodeAndType(ctx context.Context, nodeID, notificationType string) (model.Notification, error)
@@ -25,4 +37,8 @@ type Repo interface {
 	DeleteNotification(ctx context.Context, id string) error
 	DeleteNotificationByNode(ctx context.Context, nodeID string) error
 	ListNotifications(ctx context.Context) ([]model.Notification, error)
+	SaveView(ctx context.Context, view model.View) error
+	GetView(ctx context.Context, name string) (model.View, error)
+	ListViews(ctx context.Context) ([]model.View, error)
+	DeleteView(ctx context.Context, name string) error
 }
@@ -0,0 +1,134 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// maxCandidates bounds how many matching nodes an ambiguous ID error lists.
+const maxCandidates = 10
+
+// AmbiguousIDError is returned for an ID prefix that more than one node starts with.
+type AmbiguousIDError struct {
+	Ref        string
+	Candidates []model.Node
+}
+
+func (e *AmbiguousIDError) Error() string {
+	var ids []string
+	for _, n := range e.Candidates {
+		ids = append(ids, n.ID)
+	}
+	short := model.ShortIDs(ids, ids)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "ID '%s' matches %d nodes, use a longer prefix:", e.Ref, len(e.Candidates))
+	for i, n := range e.Candidates {
+		if i == maxCandidates {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(e.Candidates)-maxCandidates)
+			break
+		}
+		fmt.Fprintf(&b, "\n  %-8s %s", short[n.ID], candidateContent(n.Content))
+	}
+
+	return b.String()
+}
+
+// Is makes errors.Is(err, model.ErrAmbiguousID) hold.
+func (e *AmbiguousIDError) Is(target error) bool {
+	return target == model.ErrAmbiguousID
+}
+
+// ResolveID returns the node ref refers to, by its full ID or by a prefix of it. A prefix that
+// several nodes start with is an *AmbiguousIDError rather than a guess. Nodes in the trash are
+// left out.
+func (s *Svc) ResolveID(ctx context.Context, ref string) (model.Node, error) {
+	return s.resolve(ctx, ref, false)
+}
+
+// resolveTrashed is ResolveID for the nodes in the trash.
+func (s *Svc) resolveTrashed(ctx context.Context, ref string) (model.Node, error) {
+	return s.resolve(ctx, ref, true)
+}
+
+func (s *Svc) resolve(ctx context.Context, ref string, trashed bool) (model.Node, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return model.Node{}, model.Errorf(model.ErrInvalid, "no ID given")
+	}
+
+	found, err := s.Repo.ListByIDPrefix(ctx, ref)
+	if err != nil {
+		return model.Node{}, fmt.Errorf("error looking up ID '%s': %w", ref, err)
+	}
+
+	var nodes []model.Node
+	for _, n := range found {
+		if n.IsTrashed() == trashed {
+			nodes = append(nodes, n)
+		}
+	}
+
+	switch len(nodes) {
+	case 0:
+		if trashed {
+			return model.Node{}, model.Errorf(model.ErrNotFound, "no node with ID '%s' in the trash", ref)
+		}
+		return model.Node{}, model.Errorf(model.ErrNotFound, "node with ID '%s' not found", ref)
+	case 1:
+		return nodes[0], nil
+	}
+
+	// A full ID that is also the prefix of a longer one still means itself
+	for _, n := range nodes {
+		if strings.EqualFold(n.ID, ref) {
+			return n, nil
+		}
+	}
+
+	return model.Node{}, &AmbiguousIDError{Ref: ref, Candidates: nodes}
+}
+
+// ShortIDs returns the shortest prefix that tells each of ids apart from every other node's ID,
+// see model.ShortIDs.
+func (s *Svc) ShortIDs(ctx context.Context, ids []string) (map[string]string, error) {
+	all, err := s.Repo.ListIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing IDs: %w", err)
+	}
+
+	return model.ShortIDs(ids, all), nil
+}
+
+// NodeShortIDs returns the short IDs of nodes for messages. If the IDs cannot be listed, it
+// falls back to their first characters, which may not tell them apart.
+func (s *Svc) NodeShortIDs(ctx context.Context, nodes ...model.Node) map[string]string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+
+	short, err := s.ShortIDs(ctx, ids)
+	if err != nil {
+		log.Printf("Error getting short IDs: %v", err)
+		short = make(map[string]string, len(nodes))
+		for _, n := range nodes {
+			short[n.ID] = n.ShortID()
+		}
+	}
+
+	return short
+}
+
+// candidateContent shortens a node's content to one line for listing.
+func candidateContent(content string) string {
+	runes := []rune(strings.Join(strings.Fields(content), " "))
+	if len(runes) > 50 {
+		return string(runes[:47]) + "..."
+	}
+	return string(runes)
+}
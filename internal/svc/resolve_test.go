@@ -0,0 +1,72 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+func TestResolveID(t *testing.T) {
+	repo := newDepRepo(
+		task("3ca9e7cd", model.Status.Todo),
+		task("3ca9f012", model.Status.Todo),
+		task("7d01", model.Status.Todo),
+		task("7d01aa00", model.Status.Todo),
+	)
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr error
+	}{
+		{name: "full ID", ref: "3ca9e7cd", want: "3ca9e7cd"},
+		{name: "unique prefix", ref: "3ca9f", want: "3ca9f012"},
+		{name: "full ID that prefixes another", ref: "7d01", want: "7d01"},
+		{name: "ambiguous prefix", ref: "3ca9", wantErr: model.ErrAmbiguousID},
+		{name: "unknown", ref: "ffff", wantErr: model.ErrNotFound},
+		{name: "empty", ref: " ", wantErr: model.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := s.ResolveID(ctx, tt.ref)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ResolveID(%q) error = %v, want %v", tt.ref, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveID(%q) error = %v", tt.ref, err)
+			}
+			if node.ID != tt.want {
+				t.Errorf("ResolveID(%q) = %s, want %s", tt.ref, node.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmbiguousIDErrorListsCandidates(t *testing.T) {
+	s := &Svc{Repo: newDepRepo(task("3ca9e7cd", model.Status.Todo), task("3ca9f012", model.Status.Todo))}
+
+	_, err := s.ResolveID(context.Background(), "3ca9")
+
+	var ambiguous *AmbiguousIDError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("ResolveID() error = %v, want an *AmbiguousIDError", err)
+	}
+	if len(ambiguous.Candidates) != 2 {
+		t.Errorf("Candidates = %d, want 2", len(ambiguous.Candidates))
+	}
+	for _, prefix := range []string{"3ca9e", "3ca9f"} {
+		if !strings.Contains(err.Error(), prefix) {
+			t.Errorf("error %q does not list %s", err.Error(), prefix)
+		}
+	}
+}
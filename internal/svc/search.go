@@ -0,0 +1,151 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+const DefaultSearchLimit = 20
+
+// SearchQuery is a search input split into the full-text match expression and the node filters.
+type SearchQuery struct {
+	Match  string
+	Filter model.Filter
+}
+
+// ParseSearchQuery splits a search input into FTS5 terms and #tag, @place and :status filters.
+// Words are matched as whole tokens, "quoted text" as a phrase, and a trailing * makes a word
+// or phrase a prefix match. OR and NOT are kept as operators; all other terms must match.
+func ParseSearchQuery(input string) (SearchQuery, error) {
+	var query SearchQuery
+	var terms []string
+
+	tokens, err := splitSearchInput(input)
+	if err != nil {
+		return SearchQuery{}, err
+	}
+
+	for _, tok := range tokens {
+		if tok.phrase {
+			terms = append(terms, ftsTerm(tok.text, tok.prefix))
+			continue
+		}
+
+		switch {
+		case tok.text == "OR" || tok.text == "NOT":
+			terms = append(terms, tok.text)
+		case strings.HasPrefix(tok.text, "#") && len(tok.text) > 1:
+			query.Filter.Tags = append(query.Filter.Tags, tok.text[1:])
+		case strings.HasPrefix(tok.text, "@") && len(tok.text) > 1:
+			query.Filter.Places = append(query.Filter.Places, tok.text[1:])
+		case strings.HasPrefix(tok.text, ":") && len(tok.text) > 1:
+			status := tok.text[1:]
+			if !model.ValidStatus(status) {
+				return SearchQuery{}, fmt.Errorf("invalid status filter: %s", status)
+			}
+			query.Filter.Status = status
+		default:
+			text := strings.TrimSuffix(tok.text, "*")
+			if text == "" {
+				continue
+			}
+			terms = append(terms, ftsTerm(text, tok.prefix))
+		}
+	}
+
+	if len(terms) == 0 {
+		return SearchQuery{}, fmt.Errorf("search query needs at least one word to match")
+	}
+
+	first, last := terms[0], terms[len(terms)-1]
+	if first == "OR" || first == "NOT" || last == "OR" || last == "NOT" {
+		return SearchQuery{}, fmt.Errorf("OR and NOT must be placed between search terms")
+	}
+
+	query.Match = strings.Join(terms, " ")
+	return query, nil
+}
+
+type searchToken struct {
+	text   string
+	phrase bool
+	prefix bool
+}
+
+func splitSearchInput(input string) ([]searchToken, error) {
+	var tokens []searchToken
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated phrase in search query")
+			}
+
+			tok := searchToken{text: string(runes[i+1 : end]), phrase: true}
+			i = end + 1
+			if i < len(runes) && runes[i] == '*' {
+				tok.prefix = true
+				i++
+			}
+
+			if strings.TrimSpace(tok.text) != "" {
+				tokens = append(tokens, tok)
+			}
+			continue
+		}
+
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+			end++
+		}
+
+		text := string(runes[i:end])
+		tokens = append(tokens, searchToken{text: text, prefix: strings.HasSuffix(text, "*")})
+		i = end
+	}
+
+	return tokens, nil
+}
+
+// ftsTerm quotes a term so punctuation in it is not read as FTS5 syntax.
+func ftsTerm(text string, prefix bool) string {
+	term := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+	if prefix {
+		term += "*"
+	}
+	return term
+}
+
+// Search returns up to limit nodes matching a search input, most relevant first.
+// See ParseSearchQuery for the accepted syntax.
+func (s *Svc) Search(ctx context.Context, input string, limit int) ([]model.SearchResult, error) {
+	query, err := ParseSearchQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+
+	results, err := s.Repo.Search(ctx, query.Match, query.Filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,97 @@
+package svc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantMatch  string
+		wantFilter model.Filter
+		wantErr    bool
+	}{
+		{
+			name:      "single word",
+			input:     "release",
+			wantMatch: `"release"`,
+		},
+		{
+			name:      "phrase",
+			input:     `"release notes" draft`,
+			wantMatch: `"release notes" "draft"`,
+		},
+		{
+			name:      "prefix",
+			input:     "deploy*",
+			wantMatch: `"deploy"*`,
+		},
+		{
+			name:      "prefix phrase",
+			input:     `"release no"*`,
+			wantMatch: `"release no"*`,
+		},
+		{
+			name:      "punctuation is quoted",
+			input:     "deploy-script",
+			wantMatch: `"deploy-script"`,
+		},
+		{
+			name:      "operators",
+			input:     "deploy OR release NOT staging",
+			wantMatch: `"deploy" OR "release" NOT "staging"`,
+		},
+		{
+			name:      "filters",
+			input:     "deploy #ops #infra @office :done",
+			wantMatch: `"deploy"`,
+			wantFilter: model.Filter{
+				Tags:   []string{"ops", "infra"},
+				Places: []string{"office"},
+				Status: "done",
+			},
+		},
+		{
+			name:    "only filters",
+			input:   "#ops :todo",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated phrase",
+			input:   `"release notes`,
+			wantErr: true,
+		},
+		{
+			name:    "dangling operator",
+			input:   "deploy OR",
+			wantErr: true,
+		},
+		{
+			name:    "invalid status",
+			input:   "deploy :someday",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := ParseSearchQuery(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSearchQuery(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if query.Match != tt.wantMatch {
+				t.Errorf("ParseSearchQuery(%q) Match = %s; want %s", tt.input, query.Match, tt.wantMatch)
+			}
+			if !reflect.DeepEqual(query.Filter, tt.wantFilter) {
+				t.Errorf("ParseSearchQuery(%q) Filter = %+v; want %+v", tt.input, query.Filter, tt.wantFilter)
+			}
+		})
+	}
+}
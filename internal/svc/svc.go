@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/adrianpk/tyn/internal/config"
@@ -28,14 +30,20 @@ func New(repo Repo, config *config.Config) *Svc {
 
 // Capture parses the input text and stores the resulting node
 func (s *Svc) Capture(text string) (model.Node, error) {
-	node, err := s.Parser(text)
+	return s.CaptureSub("", text)
+}
+
+// CaptureSub captures a node as a child of the node referenced by parentRef.
+// An empty parentRef keeps the parent given with a <id token, if any.
+func (s *Svc) CaptureSub(parentRef, text string) (model.Node, error) {
+	ctx := context.Background()
+
+	node, err := s.parseCapture(ctx, parentRef, text)
 	if err != nil {
 		return model.Node{}, err
 	}
 
-	node.GenID()
-
-	err = s.Repo.Create(context.Background(), node)
+	err = s.Repo.Create(ctx, node)
 	if err != nil {
 		return model.Node{}, err
 	}
@@ -43,84 +51,71 @@ func (s *Svc) Capture(text string) (model.Node, error) {
 	return node, nil
 }
 
-func (s *Svc) List(filter model.Filter) ([]model.Node, error) {
-	nodes, err := s.Repo.List(context.Background())
-	if err != nil {
-		return nil, err
-	}
+// CaptureAll captures each of texts as a node, as CaptureSub does, and stores them in a single
+// transaction. Blank texts are skipped. If any text does not parse, nothing is stored and the
+// error names its position, counting from 1.
+func (s *Svc) CaptureAll(ctx context.Context, parentRef string, texts []string) ([]model.Node, error) {
+	var nodes []model.Node
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
 
-	if isEmptyFilter(filter) {
-		return nodes, nil
+		node, err := s.parseCapture(ctx, parentRef, text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		nodes = append(nodes, node)
 	}
 
-	var filteredNodes []model.Node
-	for _, node := range nodes {
-		if matches(node, filter) {
-			filteredNodes = append(filteredNodes, node)
-		}
+	if len(nodes) == 0 {
+		return nil, nil
 	}
 
-	return filteredNodes, nil
-}
+	err := s.Repo.CreateAll(ctx, nodes)
+	if err != nil {
+		return nil, fmt.Errorf("error storing nodes: %w", err)
+	}
 
-func isEmptyFilter(filter model.Filter) bool {
-	return filter.Type == "" && filter.Status == "" && len(filter.Tags) == 0 && len(filter.Places) == 0
+	return nodes, nil
 }
 
-func matches(node model.Node, filter model.Filter) bool {
-	if filter.Type != "" && node.Type != filter.Type {
-		return false
+// parseCapture parses text into a new node, under the node parentRef refers to, if any.
+func (s *Svc) parseCapture(ctx context.Context, parentRef, text string) (model.Node, error) {
+	node, err := s.Parser(text)
+	if err != nil {
+		return model.Node{}, err
 	}
 
-	if filter.Status != "" && node.Status != filter.Status {
-		return false
+	node.GenID()
+
+	if parentRef != "" {
+		node.ParentID = parentRef
 	}
 
-	if len(filter.Tags) > 0 {
-		tagMatch := false
-		for _, tag := range filter.Tags {
-			if hasTag(node, tag) {
-				tagMatch = true
-				break
-			}
-		}
-		if !tagMatch {
-			return false
+	if node.ParentID != "" {
+		parent, err := s.ResolveID(ctx, node.ParentID)
+		if err != nil {
+			return model.Node{}, fmt.Errorf("error resolving parent: %w", err)
 		}
-	}
+		node.ParentID = parent.ID
 
-	if len(filter.Places) > 0 {
-		placeMatch := false
-		for _, place := range filter.Places {
-			if hasPlace(node, place) {
-				placeMatch = true
-				break
-			}
+		// Children of a task are tasks themselves
+		if parent.Type == model.Type.Task && node.Type == model.Type.Note {
+			node.Type = model.Type.Task
 		}
-		if !placeMatch {
-			return false
+		if node.Type == model.Type.Task && node.Status == "" {
+			node.Status = model.Status.Todo
 		}
 	}
 
-	return true
-}
-
-func hasTag(node model.Node, tag string) bool {
-	for _, t := range node.Tags {
-		if t == tag {
-			return true
-		}
-	}
-	return false
+	return node, nil
 }
 
-func hasPlace(node model.Node, place string) bool {
-	for _, p := range node.Places {
-		if p == place {
-			return true
-		}
-	}
-	return false
+// List returns the nodes matching filter. Filtering is done by the repository.
+func (s *Svc) List(filter model.Filter) ([]model.Node, error) {
+	return s.Repo.ListFiltered(context.Background(), filter)
 }
 
 // GetOverdueTasks retrieves tasks with due dates in the past that aren't marked as done
@@ -168,36 +163,239 @@ func (s *Svc) GetAllTasks(ctx context.Context) ([]model.Node, error) {
 	return s.Repo.GetAllTasks(ctx)
 }
 
-func (s *Svc) UpdateTask(ctx context.Context, id string, tags, places []string, dueDate string, text string) error {
-	task, err := s.Repo.GetTaskByID(ctx, id)
+// UpdateNode replaces the tags, places, due date and text of a node of any type. Nil labels and
+// empty strings leave the current values.
+func (s *Svc) UpdateNode(ctx context.Context, id string, tags, places []string, dueDate string, text string) error {
+	node, err := s.ResolveID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("error retrieving task: %w", err)
+		return fmt.Errorf("error retrieving node: %w", err)
 	}
 
 	if tags != nil {
-		task.Tags = tags
+		node.Tags = tags
 	}
 
 	if places != nil {
-		task.Places = places
+		node.Places = places
 	}
 
 	if dueDate != "" {
-		date, err := time.Parse("2006-01-02", dueDate)
+		date, err := ParseDate(dueDate)
 		if err != nil {
 			return fmt.Errorf("invalid due date format: %w", err)
 		}
-		task.DueDate = &date
+		node.DueDate = &date
 	}
 
 	if text != "" {
-		task.Content = text
+		node.Content = text
 	}
 
-	err = s.Repo.UpdateTask(ctx, task)
+	err = s.Repo.Update(ctx, node)
 	if err != nil {
-		return fmt.Errorf("error updating task: %w", err)
+		return fmt.Errorf("error updating node: %w", err)
 	}
 
 	return nil
 }
+
+// StatusChange describes the outcome of a task status change.
+type StatusChange struct {
+	Task           model.Node
+	OriginalStatus string
+	NewStatus      string
+	// Next is the occurrence spawned when a recurring task is completed.
+	Next *model.Node
+	// OpenChildren lists the subtasks still open when a task is completed.
+	OpenChildren []model.Node
+	// Unblocked and Blocked list the dependent tasks whose status changed as a result.
+	Unblocked []model.Node
+	Blocked   []model.Node
+	// BlockedBy lists the open dependencies that keep the task blocked. The status asked for
+	// is kept as its prior status and applies once they are closed.
+	BlockedBy []model.Node
+}
+
+// ChangeStatus sets ("set") or cycles ("next", "prev") the status of a task.
+// Completing a recurring task keeps it as history and creates its next occurrence.
+// Closing or reopening a task unblocks or blocks the tasks that depend on it.
+func (s *Svc) ChangeStatus(ctx context.Context, id, operation, status string) (StatusChange, error) {
+	task, err := s.ResolveID(ctx, id)
+	if err != nil {
+		return StatusChange{}, err
+	}
+
+	if task.Type != model.Type.Task {
+		return StatusChange{}, model.Errorf(model.ErrInvalid, "node with ID '%s' is not a task", id)
+	}
+
+	change := StatusChange{OriginalStatus: task.Status}
+
+	switch operation {
+	case "set":
+		if !model.ValidStatus(status) {
+			return StatusChange{}, model.Errorf(model.ErrInvalid, "invalid status: %s", status)
+		}
+		change.NewStatus = status
+	case "next":
+		change.NewStatus = model.NextStatus(task.Status)
+	case "prev":
+		change.NewStatus = model.PreviousStatus(task.Status)
+	default:
+		return StatusChange{}, model.Errorf(model.ErrInvalid, "invalid operation: %s", operation)
+	}
+
+	wasClosed := task.IsClosed()
+	task.Status = change.NewStatus
+	// An explicit change takes over from the status saved when the task was blocked.
+	task.PriorStatus = ""
+
+	// A task stays blocked while it has open dependencies, whatever open status it is given.
+	if !task.IsClosed() && task.Status != model.Status.Blocked {
+		change.BlockedBy, err = s.openDependencies(ctx, task.ID)
+		if err != nil {
+			return StatusChange{}, err
+		}
+		if len(change.BlockedBy) > 0 {
+			task.PriorStatus = task.Status
+			task.Status = model.Status.Blocked
+			change.NewStatus = model.Status.Blocked
+		}
+	}
+
+	var next *model.Node
+	if task.Recurrence != "" && change.NewStatus == model.Status.Done && change.OriginalStatus != model.Status.Done {
+		n, err := nextOccurrence(task, time.Now())
+		if err != nil {
+			return StatusChange{}, err
+		}
+		next = &n
+		// The completed occurrence stays as history; the rule moves to the new one.
+		task.Recurrence = ""
+	}
+
+	// The next occurrence is created first, so the rule is never lost: if that fails the task
+	// is left as it was, and if closing the task fails the new occurrence is removed again.
+	if next != nil {
+		err = s.Repo.Create(ctx, *next)
+		if err != nil {
+			return StatusChange{}, fmt.Errorf("error creating next occurrence: %w", err)
+		}
+	}
+
+	err = s.Repo.Update(ctx, task)
+	if err != nil {
+		if next != nil {
+			if delErr := s.Repo.Delete(ctx, next.ID); delErr != nil {
+				log.Printf("Error removing next occurrence %s: %v", next.ID, delErr)
+			}
+		}
+		return StatusChange{}, fmt.Errorf("error updating task: %w", err)
+	}
+
+	if next != nil {
+		log.Printf("Created next occurrence %s of recurring task %s", next.ID, task.ID)
+	}
+
+	if change.NewStatus == model.Status.Done {
+		children, err := s.Repo.GetChildren(ctx, task.ID)
+		if err != nil {
+			return StatusChange{}, fmt.Errorf("error retrieving subtasks: %w", err)
+		}
+		for _, child := range children {
+			if !child.IsClosed() {
+				change.OpenChildren = append(change.OpenChildren, child)
+			}
+		}
+	}
+
+	if task.IsClosed() != wasClosed {
+		change.Blocked, change.Unblocked, err = s.refreshDependents(ctx, task)
+		if err != nil {
+			return StatusChange{}, err
+		}
+	}
+
+	change.Task = task
+	change.Next = next
+	return change, nil
+}
+
+// nextOccurrence copies a recurring task into a new todo with its due date rolled forward.
+// Tasks without a due date recur from the moment they are completed.
+func nextOccurrence(task model.Node, now time.Time) (model.Node, error) {
+	rule, err := model.ParseRecurrence(task.Recurrence)
+	if err != nil {
+		return model.Node{}, err
+	}
+
+	from := now
+	if task.DueDate != nil {
+		from = *task.DueDate
+	}
+	due := rule.NextAfter(from, now)
+
+	next := model.Node{
+		Type:       task.Type,
+		Content:    task.Content,
+		Link:       task.Link,
+		Tags:       append([]string(nil), task.Tags...),
+		Places:     append([]string(nil), task.Places...),
+		Status:     model.Status.Todo,
+		Draft:      task.Draft,
+		Date:       now,
+		DueDate:    &due,
+		Recurrence: task.Recurrence,
+		Priority:   task.Priority,
+		ParentID:   task.ParentID,
+	}
+	next.GenID()
+
+	return next, nil
+}
+
+// PriorityChange describes the outcome of a task priority change.
+type PriorityChange struct {
+	Task             model.Node
+	OriginalPriority int
+	NewPriority      int
+}
+
+// ChangePriority sets ("set"), raises ("up") or lowers ("down") the priority of a task.
+func (s *Svc) ChangePriority(ctx context.Context, id, operation, priority string) (PriorityChange, error) {
+	task, err := s.ResolveID(ctx, id)
+	if err != nil {
+		return PriorityChange{}, err
+	}
+
+	if task.Type != model.Type.Task {
+		return PriorityChange{}, model.Errorf(model.ErrInvalid, "node with ID '%s' is not a task", id)
+	}
+
+	change := PriorityChange{OriginalPriority: task.Priority}
+
+	switch operation {
+	case "set":
+		p, ok := model.Priority.Parse(priority)
+		if !ok {
+			return PriorityChange{}, model.Errorf(model.ErrInvalid, "invalid priority: %s", priority)
+		}
+		change.NewPriority = p
+	case "up":
+		change.NewPriority = model.RaisePriority(task.Priority)
+	case "down":
+		change.NewPriority = model.LowerPriority(task.Priority)
+	default:
+		return PriorityChange{}, model.Errorf(model.ErrInvalid, "invalid operation: %s", operation)
+	}
+
+	task.Priority = change.NewPriority
+
+	err = s.Repo.Update(ctx, task)
+	if err != nil {
+		return PriorityChange{}, fmt.Errorf("error updating task: %w", err)
+	}
+
+	change.Task = task
+	return change, nil
+}
@@ -1,6 +1,8 @@
 package svc
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -158,3 +160,134 @@ func timePointersEqual(a, b *time.Time) bool {
 	}
 	return a.UTC().Equal(b.UTC())
 }
+
+func TestNextOccurrence(t *testing.T) {
+	due := time.Date(2025, 7, 4, 17, 0, 0, 0, time.Local)
+	now := time.Date(2025, 7, 4, 12, 0, 0, 0, time.Local)
+
+	task := model.Node{
+		ID:         "done-task",
+		Type:       model.Type.Task,
+		Content:    "Timesheet",
+		Tags:       []string{"admin"},
+		Status:     model.Status.Done,
+		DueDate:    &due,
+		Recurrence: "weekly:fri",
+	}
+
+	next, err := nextOccurrence(task, now)
+	if err != nil {
+		t.Fatalf("nextOccurrence() error = %v", err)
+	}
+
+	if next.ID == "" || next.ID == task.ID {
+		t.Errorf("nextOccurrence() ID = %q, want a new ID", next.ID)
+	}
+
+	if next.Status != model.Status.Todo {
+		t.Errorf("nextOccurrence() Status = %v, want %v", next.Status, model.Status.Todo)
+	}
+
+	expected := time.Date(2025, 7, 11, 17, 0, 0, 0, time.Local)
+	if !timePointersEqual(next.DueDate, &expected) {
+		t.Errorf("nextOccurrence() DueDate = %v, want %v", next.DueDate, expected)
+	}
+
+	if next.Recurrence != task.Recurrence || !sliceEqual(next.Tags, task.Tags) {
+		t.Errorf("nextOccurrence() = %+v, want recurrence and tags copied", next)
+	}
+}
+
+// recurRepo is a depRepo whose Create and Update can be made to fail.
+type recurRepo struct {
+	*depRepo
+	failCreate bool
+	failUpdate bool
+}
+
+func (r *recurRepo) Create(ctx context.Context, node model.Node) error {
+	if r.failCreate {
+		return errors.New("disk full")
+	}
+	r.nodes[node.ID] = node
+	return nil
+}
+
+func (r *recurRepo) Update(ctx context.Context, node model.Node) error {
+	if r.failUpdate {
+		return errors.New("disk full")
+	}
+	return r.depRepo.Update(ctx, node)
+}
+
+func (r *recurRepo) Delete(ctx context.Context, id string) error {
+	delete(r.nodes, id)
+	return nil
+}
+
+func TestChangeStatusRecurring(t *testing.T) {
+	tests := []struct {
+		name       string
+		failCreate bool
+		failUpdate bool
+		wantErr    bool
+		wantNodes  int
+		wantStatus string
+		wantRule   string
+	}{
+		{name: "completes and creates the next occurrence", wantNodes: 2, wantStatus: model.Status.Done},
+		{name: "create fails", failCreate: true, wantErr: true, wantNodes: 1, wantStatus: model.Status.Todo, wantRule: "daily"},
+		{name: "update fails", failUpdate: true, wantErr: true, wantNodes: 1, wantStatus: model.Status.Todo, wantRule: "daily"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recurring := task("a", model.Status.Todo)
+			recurring.Recurrence = "daily"
+			repo := &recurRepo{depRepo: newDepRepo(recurring), failCreate: tt.failCreate, failUpdate: tt.failUpdate}
+			s := &Svc{Repo: repo}
+
+			_, err := s.ChangeStatus(context.Background(), "a", "set", model.Status.Done)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ChangeStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(repo.nodes) != tt.wantNodes {
+				t.Errorf("ChangeStatus() left %d nodes, want %d", len(repo.nodes), tt.wantNodes)
+			}
+
+			got := repo.nodes["a"]
+			if got.Status != tt.wantStatus || got.Recurrence != tt.wantRule {
+				t.Errorf("task a = %s %q, want %s %q", got.Status, got.Recurrence, tt.wantStatus, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	note := model.Node{ID: "n", Type: model.Type.Note}
+	s := &Svc{Repo: newDepRepo(task("a", model.Status.Todo), note)}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"missing task", func() error { _, err := s.ChangeStatus(ctx, "x", "next", ""); return err }, model.ErrNotFound},
+		{"missing dependency", func() error { _, err := s.AddDependency(ctx, "a", "x"); return err }, model.ErrNotFound},
+		{"update missing task", func() error { return s.UpdateNode(ctx, "x", nil, nil, "", "text") }, model.ErrNotFound},
+		{"not a task", func() error { _, err := s.ChangePriority(ctx, "n", "up", ""); return err }, model.ErrInvalid},
+		{"invalid status", func() error { _, err := s.ChangeStatus(ctx, "a", "set", "later"); return err }, model.ErrInvalid},
+		{"invalid operation", func() error { _, err := s.ChangePriority(ctx, "a", "sideways", ""); return err }, model.ErrInvalid},
+		{"invalid due date", func() error { return s.UpdateNode(ctx, "a", nil, nil, "someday", "") }, model.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
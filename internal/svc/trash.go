@@ -0,0 +1,249 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// TrashNodes moves the nodes ids refer to into the trash, along with their subtasks. All IDs
+// are resolved first, so an unknown one leaves everything in place. Tasks that depended on a
+// trashed task are unblocked.
+func (s *Svc) TrashNodes(ctx context.Context, ids []string) ([]model.Node, error) {
+	nodes, err := s.resolveAll(ctx, ids, s.ResolveID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err = s.withDescendants(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range nodes {
+		nodes[i].DeletedAt = &now
+
+		err = s.Repo.Update(ctx, nodes[i])
+		if err != nil {
+			return nil, fmt.Errorf("error moving node to the trash: %w", err)
+		}
+	}
+
+	for _, n := range nodes {
+		if n.Type != model.Type.Task {
+			continue
+		}
+
+		_, _, err = s.refreshDependents(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
+
+// Trash returns the nodes in the trash, most recently deleted first.
+func (s *Svc) Trash(ctx context.Context) ([]model.Node, error) {
+	nodes, err := s.Repo.ListTrash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing trash: %w", err)
+	}
+
+	return nodes, nil
+}
+
+// RestoreNodes takes the nodes ids refer to out of the trash, with the subtasks trashed along
+// with them. Restored tasks block their dependents again while they are open.
+func (s *Svc) RestoreNodes(ctx context.Context, ids []string) ([]model.Node, error) {
+	nodes, err := s.resolveAll(ctx, ids, s.resolveTrashed)
+	if err != nil {
+		return nil, err
+	}
+
+	trash, err := s.Trash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes = trashedWith(nodes, trash)
+
+	for i := range nodes {
+		nodes[i].DeletedAt = nil
+
+		err = s.Repo.Update(ctx, nodes[i])
+		if err != nil {
+			return nil, fmt.Errorf("error restoring node: %w", err)
+		}
+	}
+
+	for i, n := range nodes {
+		if n.Type != model.Type.Task {
+			continue
+		}
+
+		nodes[i], _, err = s.refreshBlocked(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+
+		_, _, err = s.refreshDependents(ctx, nodes[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
+
+// EmptyTrash deletes the nodes that went into the trash before before, and their
+// notifications, for good. The zero time empties the whole trash.
+func (s *Svc) EmptyTrash(ctx context.Context, before time.Time) ([]model.Node, error) {
+	trash, err := s.Trash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []model.Node
+	for _, n := range trash {
+		if !before.IsZero() && !n.DeletedAt.Before(before) {
+			continue
+		}
+
+		err = s.Repo.DeleteNotificationByNode(ctx, n.ID)
+		if err != nil {
+			return deleted, fmt.Errorf("error deleting notifications: %w", err)
+		}
+
+		err = s.Repo.Delete(ctx, n.ID)
+		if err != nil {
+			return deleted, fmt.Errorf("error deleting node: %w", err)
+		}
+
+		deleted = append(deleted, n)
+	}
+
+	return deleted, nil
+}
+
+// PurgeTrash empties the nodes that have been in the trash longer than the configured
+// retention. A retention of 0 keeps them until the trash is emptied by hand.
+func (s *Svc) PurgeTrash(ctx context.Context) ([]model.Node, error) {
+	var retention time.Duration
+	if s.Config != nil {
+		retention = s.Config.Snapshot().TrashRetention
+	}
+	if retention <= 0 {
+		return nil, nil
+	}
+
+	return s.EmptyTrash(ctx, time.Now().Add(-retention))
+}
+
+// ArchiveNodes archives the nodes ids refer to, along with their subtasks, or unarchives them
+// when archive is false. It returns the nodes whose state changed.
+func (s *Svc) ArchiveNodes(ctx context.Context, ids []string, archive bool) ([]model.Node, error) {
+	nodes, err := s.resolveAll(ctx, ids, s.ResolveID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err = s.withDescendants(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var changed []model.Node
+	for _, n := range nodes {
+		if n.IsArchived() == archive {
+			continue
+		}
+
+		n.ArchivedAt = nil
+		if archive {
+			n.ArchivedAt = &now
+		}
+
+		err = s.Repo.Update(ctx, n)
+		if err != nil {
+			return nil, fmt.Errorf("error updating node: %w", err)
+		}
+
+		changed = append(changed, n)
+	}
+
+	return changed, nil
+}
+
+// resolveAll resolves every ID with resolve, dropping repeats.
+func (s *Svc) resolveAll(ctx context.Context, ids []string, resolve func(context.Context, string) (model.Node, error)) ([]model.Node, error) {
+	if len(ids) == 0 {
+		return nil, model.Errorf(model.ErrInvalid, "no ID given")
+	}
+
+	seen := map[string]bool{}
+	var nodes []model.Node
+	for _, id := range ids {
+		n, err := resolve(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[n.ID] {
+			seen[n.ID] = true
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes, nil
+}
+
+// withDescendants adds the subtasks of nodes, at any depth, that are not in the trash.
+func (s *Svc) withDescendants(ctx context.Context, nodes []model.Node) ([]model.Node, error) {
+	seen := map[string]bool{}
+	for _, n := range nodes {
+		seen[n.ID] = true
+	}
+
+	for i := 0; i < len(nodes); i++ {
+		children, err := s.Repo.GetChildren(ctx, nodes[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving subtasks: %w", err)
+		}
+
+		for _, child := range children {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				nodes = append(nodes, child)
+			}
+		}
+	}
+
+	return nodes, nil
+}
+
+// trashedWith adds to nodes the descendants in trash that went into the trash at the same
+// time as their parent.
+func trashedWith(nodes, trash []model.Node) []model.Node {
+	seen := map[string]bool{}
+	for _, n := range nodes {
+		seen[n.ID] = true
+	}
+
+	for i := 0; i < len(nodes); i++ {
+		for _, n := range trash {
+			if seen[n.ID] || n.ParentID != nodes[i].ID || !n.DeletedAt.Equal(*nodes[i].DeletedAt) {
+				continue
+			}
+
+			seen[n.ID] = true
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,196 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/config"
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// trashRepo extends depRepo with subtasks and the trash, leaving trashed nodes out of the
+// queries that TynRepo leaves them out of.
+type trashRepo struct {
+	*depRepo
+	deleted []string
+}
+
+func newTrashRepo(nodes ...model.Node) *trashRepo {
+	return &trashRepo{depRepo: newDepRepo(nodes...)}
+}
+
+func (r *trashRepo) GetChildren(ctx context.Context, parentID string) ([]model.Node, error) {
+	var children []model.Node
+	for _, n := range r.nodes {
+		if n.ParentID == parentID && !n.IsTrashed() {
+			children = append(children, n)
+		}
+	}
+	return children, nil
+}
+
+func (r *trashRepo) GetDependencies(ctx context.Context, taskID string) ([]model.Node, error) {
+	return r.live(r.depRepo.GetDependencies(ctx, taskID))
+}
+
+func (r *trashRepo) GetDependents(ctx context.Context, taskID string) ([]model.Node, error) {
+	return r.live(r.depRepo.GetDependents(ctx, taskID))
+}
+
+func (r *trashRepo) live(nodes []model.Node, err error) ([]model.Node, error) {
+	var kept []model.Node
+	for _, n := range nodes {
+		if !n.IsTrashed() {
+			kept = append(kept, n)
+		}
+	}
+	return kept, err
+}
+
+func (r *trashRepo) ListTrash(ctx context.Context) ([]model.Node, error) {
+	var trash []model.Node
+	for _, n := range r.nodes {
+		if n.IsTrashed() {
+			trash = append(trash, n)
+		}
+	}
+	return trash, nil
+}
+
+func (r *trashRepo) Delete(ctx context.Context, id string) error {
+	delete(r.nodes, id)
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *trashRepo) DeleteNotificationByNode(ctx context.Context, nodeID string) error {
+	return nil
+}
+
+// node returns the stored node id, addressable for its pointer methods.
+func (r *trashRepo) node(id string) *model.Node {
+	n := r.nodes[id]
+	return &n
+}
+
+func subtask(id, parentID string) model.Node {
+	n := task(id, model.Status.Todo)
+	n.ParentID = parentID
+	return n
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	repo := newTrashRepo(task("a", model.Status.Todo), subtask("a1", "a"), subtask("a2", "a1"), task("b", model.Status.Todo))
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	if _, err := s.AddDependency(ctx, "b", "a"); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	trashed, err := s.TrashNodes(ctx, []string{"a"})
+	if err != nil {
+		t.Fatalf("TrashNodes() error = %v", err)
+	}
+	if len(trashed) != 3 {
+		t.Errorf("TrashNodes() moved %d nodes, want the task and its 2 subtasks", len(trashed))
+	}
+	if got := repo.nodes["b"].Status; got != model.Status.Todo {
+		t.Errorf("dependent status after trashing = %s, want %s", got, model.Status.Todo)
+	}
+	if _, err := s.ResolveID(ctx, "a"); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("ResolveID() of a trashed node error = %v, want not found", err)
+	}
+
+	restored, err := s.RestoreNodes(ctx, []string{"a"})
+	if err != nil {
+		t.Fatalf("RestoreNodes() error = %v", err)
+	}
+	if len(restored) != 3 {
+		t.Errorf("RestoreNodes() restored %d nodes, want 3", len(restored))
+	}
+	for _, id := range []string{"a", "a1", "a2"} {
+		if repo.node(id).IsTrashed() {
+			t.Errorf("%s is still in the trash", id)
+		}
+	}
+	if got := repo.nodes["b"].Status; got != model.Status.Blocked {
+		t.Errorf("dependent status after restoring = %s, want %s", got, model.Status.Blocked)
+	}
+
+	if _, err := s.RestoreNodes(ctx, []string{"b"}); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("RestoreNodes() of a node outside the trash error = %v, want not found", err)
+	}
+}
+
+func TestTrashNodesResolvesAllFirst(t *testing.T) {
+	repo := newTrashRepo(task("a", model.Status.Todo))
+	s := &Svc{Repo: repo}
+
+	_, err := s.TrashNodes(context.Background(), []string{"a", "missing"})
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Fatalf("TrashNodes() error = %v, want not found", err)
+	}
+	if repo.node("a").IsTrashed() {
+		t.Error("TrashNodes() moved a node although another ID was unknown")
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	now := time.Now()
+	old, recent := now.Add(-48*time.Hour), now.Add(-time.Hour)
+
+	nodes := []model.Node{
+		{ID: "old", Type: model.Type.Note, DeletedAt: &old},
+		{ID: "recent", Type: model.Type.Note, DeletedAt: &recent},
+		{ID: "kept", Type: model.Type.Note},
+	}
+
+	tests := []struct {
+		name      string
+		retention time.Duration
+		want      []string
+	}{
+		{"past retention", 24 * time.Hour, []string{"old"}},
+		{"retention off", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTrashRepo(nodes...)
+			s := &Svc{Repo: repo, Config: &config.Config{TrashRetention: tt.retention}}
+
+			_, err := s.PurgeTrash(context.Background())
+			if err != nil {
+				t.Fatalf("PurgeTrash() error = %v", err)
+			}
+			if !sliceEqual(repo.deleted, tt.want) {
+				t.Errorf("PurgeTrash() deleted %v, want %v", repo.deleted, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveNodes(t *testing.T) {
+	repo := newTrashRepo(task("a", model.Status.Todo), subtask("a1", "a"), task("b", model.Status.Todo))
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	changed, err := s.ArchiveNodes(ctx, []string{"a"}, true)
+	if err != nil {
+		t.Fatalf("ArchiveNodes() error = %v", err)
+	}
+	if len(changed) != 2 || !repo.node("a1").IsArchived() || repo.node("b").IsArchived() {
+		t.Errorf("ArchiveNodes() archived %d nodes, want the task and its subtask", len(changed))
+	}
+
+	changed, err = s.ArchiveNodes(ctx, []string{"a", "b"}, false)
+	if err != nil {
+		t.Fatalf("ArchiveNodes() error = %v", err)
+	}
+	if len(changed) != 2 || repo.node("a").IsArchived() || repo.node("a1").IsArchived() {
+		t.Errorf("unarchiving changed %d nodes, want 2", len(changed))
+	}
+}
@@ -0,0 +1,110 @@
+package svc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// SaveView saves query under name, replacing the query and journal section of a view saved
+// under it before. The query is checked but stored as written, so relative dates such as +7d
+// are resolved each time the view runs.
+func (s *Svc) SaveView(ctx context.Context, name, query, section string) (model.View, error) {
+	if !model.ValidViewName(name) {
+		return model.View{}, model.Errorf(model.ErrInvalid, "invalid view name '%s', use letters, digits, '_', '.' and '-'", name)
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return model.View{}, model.Errorf(model.ErrInvalid, "view '%s' needs a query", name)
+	}
+
+	_, err := ParseQuery(query)
+	if err != nil {
+		return model.View{}, err
+	}
+
+	view := model.View{Name: name, Query: query, Section: strings.TrimSpace(section), CreatedAt: time.Now()}
+
+	existing, err := s.View(ctx, name)
+	if err == nil {
+		view.CreatedAt = existing.CreatedAt
+	} else if !errors.Is(err, model.ErrNotFound) {
+		return model.View{}, err
+	}
+
+	err = s.Repo.SaveView(ctx, view)
+	if err != nil {
+		return model.View{}, fmt.Errorf("error saving view: %w", err)
+	}
+
+	return view, nil
+}
+
+// View returns the view saved under name.
+func (s *Svc) View(ctx context.Context, name string) (model.View, error) {
+	view, err := s.Repo.GetView(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.View{}, model.Errorf(model.ErrNotFound, "no view named '%s'", name)
+	}
+	if err != nil {
+		return model.View{}, fmt.Errorf("error getting view: %w", err)
+	}
+
+	return view, nil
+}
+
+// Views returns the saved views by name.
+func (s *Svc) Views(ctx context.Context) ([]model.View, error) {
+	views, err := s.Repo.ListViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing views: %w", err)
+	}
+
+	return views, nil
+}
+
+// DeleteView deletes the view saved under name and returns it.
+func (s *Svc) DeleteView(ctx context.Context, name string) (model.View, error) {
+	view, err := s.View(ctx, name)
+	if err != nil {
+		return model.View{}, err
+	}
+
+	err = s.Repo.DeleteView(ctx, name)
+	if err != nil {
+		return model.View{}, fmt.Errorf("error deleting view: %w", err)
+	}
+
+	return view, nil
+}
+
+// RunView lists the nodes the view saved under name matches.
+func (s *Svc) RunView(ctx context.Context, name string) (model.View, []model.Node, error) {
+	view, err := s.View(ctx, name)
+	if err != nil {
+		return model.View{}, nil, err
+	}
+
+	nodes, err := s.ViewNodes(ctx, view)
+	if err != nil {
+		return model.View{}, nil, err
+	}
+
+	return view, nodes, nil
+}
+
+// ViewNodes lists the nodes view matches, as tn list would with its query.
+func (s *Svc) ViewNodes(ctx context.Context, view model.View) ([]model.Node, error) {
+	query, err := ParseQuery(view.Query)
+	if err != nil {
+		return nil, fmt.Errorf("view '%s': %w", view.Name, err)
+	}
+
+	return s.Repo.ListFiltered(ctx, model.Filter{Query: query})
+}
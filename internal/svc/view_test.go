@@ -0,0 +1,139 @@
+package svc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adrianpk/tyn/internal/model"
+)
+
+// viewRepo keeps views in memory and records the filter views are listed with.
+type viewRepo struct {
+	Repo
+	views  map[string]model.View
+	filter model.Filter
+}
+
+func newViewRepo(views ...model.View) *viewRepo {
+	r := &viewRepo{views: make(map[string]model.View)}
+	for _, v := range views {
+		r.views[v.Name] = v
+	}
+	return r
+}
+
+func (r *viewRepo) SaveView(ctx context.Context, view model.View) error {
+	r.views[view.Name] = view
+	return nil
+}
+
+func (r *viewRepo) GetView(ctx context.Context, name string) (model.View, error) {
+	view, ok := r.views[name]
+	if !ok {
+		return model.View{}, sql.ErrNoRows
+	}
+	return view, nil
+}
+
+func (r *viewRepo) DeleteView(ctx context.Context, name string) error {
+	delete(r.views, name)
+	return nil
+}
+
+func (r *viewRepo) ListFiltered(ctx context.Context, filter model.Filter) ([]model.Node, error) {
+	r.filter = filter
+	return []model.Node{{ID: "a", Type: model.Type.Task}}, nil
+}
+
+func TestSaveView(t *testing.T) {
+	created := time.Date(2025, 7, 1, 9, 0, 0, 0, time.Local)
+	existing := model.View{Name: "urgent", Query: "#urgent", CreatedAt: created}
+
+	tests := []struct {
+		name      string
+		viewName  string
+		query     string
+		section   string
+		wantQuery string
+		wantErr   bool
+	}{
+		{name: "new view", viewName: "office", query: " :wip @office ", section: "At the office", wantQuery: ":wip @office"},
+		{name: "replaces a view", viewName: "urgent", query: "#urgent and not :done", wantQuery: "#urgent and not :done"},
+		{name: "name with spaces", viewName: "at office", query: "@office", wantErr: true},
+		{name: "name like a flag", viewName: "-x", query: "@office", wantErr: true},
+		{name: "empty query", viewName: "empty", query: "  ", wantErr: true},
+		{name: "invalid query", viewName: "broken", query: "(#a or", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newViewRepo(existing)
+			s := &Svc{Repo: repo}
+
+			got, err := s.SaveView(context.Background(), tt.viewName, tt.query, tt.section)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrInvalid) {
+					t.Errorf("SaveView() error = %v, want a validation error", err)
+				}
+				if _, ok := repo.views[tt.viewName]; ok && tt.viewName != existing.Name {
+					t.Errorf("SaveView() stored view %q although it failed", tt.viewName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SaveView() error = %v", err)
+			}
+
+			stored := repo.views[tt.viewName]
+			if stored != got {
+				t.Errorf("stored view = %+v, want %+v", stored, got)
+			}
+			if got.Query != tt.wantQuery {
+				t.Errorf("SaveView() query = %q, want %q", got.Query, tt.wantQuery)
+			}
+			if got.Section != tt.section {
+				t.Errorf("SaveView() section = %q, want %q", got.Section, tt.section)
+			}
+			if tt.viewName == existing.Name && !got.CreatedAt.Equal(created) {
+				t.Errorf("SaveView() created = %v, want the original %v", got.CreatedAt, created)
+			}
+		})
+	}
+}
+
+func TestRunView(t *testing.T) {
+	repo := newViewRepo(model.View{Name: "urgent", Query: "#urgent sort:due"})
+	s := &Svc{Repo: repo}
+	ctx := context.Background()
+
+	view, nodes, err := s.RunView(ctx, "urgent")
+	if err != nil {
+		t.Fatalf("RunView() error = %v", err)
+	}
+	if view.Name != "urgent" || len(nodes) != 1 {
+		t.Errorf("RunView() = %+v, %d nodes, want the urgent view and 1 node", view, len(nodes))
+	}
+
+	query := repo.filter.Query
+	if condString(query.Where) != "tag=urgent" || len(query.Sort) != 1 || query.Sort[0].Field != model.FieldDue {
+		t.Errorf("RunView() listed with %s %v, want tag=urgent sorted by due", condString(query.Where), query.Sort)
+	}
+
+	_, _, err = s.RunView(ctx, "missing")
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("RunView() of a missing view error = %v, want %v", err, model.ErrNotFound)
+	}
+
+	_, err = s.DeleteView(ctx, "missing")
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("DeleteView() of a missing view error = %v, want %v", err, model.ErrNotFound)
+	}
+
+	_, err = s.DeleteView(ctx, "urgent")
+	if err != nil || len(repo.views) != 0 {
+		t.Errorf("DeleteView() error = %v, %d views left, want none", err, len(repo.views))
+	}
+}